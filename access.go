@@ -0,0 +1,55 @@
+package configuration
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// accessState counts reads of fields via Get (see WithAccessTracking).
+// It has its own mutex because Get holds only the read lock of the configurator.
+type accessState struct {
+	mu    sync.Mutex
+	reads map[string]int64 // path to the field -> number of reads
+}
+
+func (s *accessState) record(path string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.reads[path]++
+	s.mu.Unlock()
+}
+
+// ReadCounts returns numbers of reads of all fields via Get since the configurator was created (see WithAccessTracking)
+// by paths to the fields (e.g. `Database.Host`). Returns nil if the tracking isn't enabled.
+func (c configurator) ReadCounts() map[string]int64 {
+	if c.access == nil {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	c.access.mu.Lock()
+	defer c.access.mu.Unlock()
+
+	counts := map[string]int64{}
+	walkFields(reflect.ValueOf(c.config), nil, c.opts.tags(), func(path string, _ reflect.StructField, _ reflect.Value) {
+		counts[path] = c.access.reads[path]
+	})
+	return counts
+}
+
+// UnreadFields returns sorted paths to the fields which were never read via Get since the configurator was created,
+// e.g. to find dead settings at the end of a test run (see WithAccessTracking). Returns nil if the tracking isn't enabled.
+func (c configurator) UnreadFields() []string {
+	var unread []string
+	for path, n := range c.ReadCounts() {
+		if n == 0 {
+			unread = append(unread, path)
+		}
+	}
+	sort.Strings(unread)
+	return unread
+}
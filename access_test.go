@@ -0,0 +1,42 @@
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAccessTracking(t *testing.T) {
+	cfg := struct {
+		Host     string `default:"localhost"`
+		Port     int    `default:"80"`
+		Database struct {
+			Name   string `default:"app"`
+			Legacy string `default:"unused"`
+		}
+	}{}
+
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()), WithAccessTracking())
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, []string{"Database.Legacy", "Database.Name", "Host", "Port"}, c.UnreadFields())
+
+	_, _ = c.Get("host")
+	_, _ = c.Get("Host")
+	_, _ = c.Get("database.name")
+	_, _ = c.Get("Missing")
+	c.Explain() // dumps aren't reads
+
+	assert.Equal(t, []string{"Database.Legacy", "Port"}, c.UnreadFields())
+	assert.Equal(t, map[string]int64{"Host": 2, "Port": 0, "Database.Name": 1, "Database.Legacy": 0}, c.ReadCounts())
+
+	c, err = New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	_, _ = c.Get("Host")
+	assert.Nil(t, c.UnreadFields(), "tracking is disabled")
+	assert.Nil(t, c.ReadCounts())
+}
@@ -0,0 +1,125 @@
+//go:build !tinygo
+// +build !tinygo
+
+package configuration
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// NewAdminHandler creates http.Handler (to mount under e.g. /debug/config) which shows the effective
+// configuration with the providers which set every field:
+//
+//	GET: [{"path": "Database.Host", "value": "localhost", "source": "envProvider"}, ...]
+//
+// Values of fields tagged `secret` are masked (see MaskFull).
+// If `overrides` is not nil, PATCH requests with a JSON object like {"Database.Host": "db.internal"}
+// put values into the override provider and reload the configurator (see Reload). For this to work,
+// overrides must be one of the providers of the configurator (normally the first one).
+// Unknown paths are rejected with 400 and values which cannot be set (see Set) with 422 before any of them is stored;
+// if the reload fails, the previous overrides are restored.
+// `authorize` is called for every request (if not nil), the request is rejected with 403 when it returns false.
+// PATCH requests are rejected with 403 if `authorize` is nil, so live values can't be changed by anyone who
+// can reach the endpoint.
+//
+// Note: the configuration object is updated in place, readers are not synchronized with PATCH requests.
+func NewAdminHandler(c configurator, overrides *overrideProvider, authorize func(r *http.Request) bool) http.Handler {
+	return &adminHandler{
+		configurator: c,
+		overrides:    overrides,
+		authorize:    authorize,
+	}
+}
+
+type adminHandler struct {
+	mu           sync.Mutex
+	configurator configurator
+	overrides    *overrideProvider
+	authorize    func(r *http.Request) bool
+}
+
+func (h *adminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.authorize != nil && !h.authorize(r) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodGet:
+		h.show(w)
+
+	case http.MethodPatch:
+		if h.overrides == nil {
+			http.Error(w, "overrides are disabled", http.StatusMethodNotAllowed)
+			return
+		}
+		if h.authorize == nil {
+			http.Error(w, "overrides require authorization", http.StatusForbidden)
+			return
+		}
+
+		var values map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&values); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if code, err := h.check(values); err != nil {
+			http.Error(w, err.Error(), code)
+			return
+		}
+
+		previous := h.overrides.Values()
+		for path, val := range values {
+			_ = h.overrides.Set(path, val)
+		}
+		if err := h.configurator.ReloadContext(r.Context()); err != nil {
+			h.overrides.replace(previous)
+			if _, rbErr := h.configurator.reload(r.Context()); rbErr != nil {
+				h.configurator.opts.errorf("adminHandler: cannot reload with the previous overrides: %v", rbErr)
+			}
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		h.show(w)
+
+	default:
+		w.Header().Set("Allow", "GET, PATCH")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// check returns the status code and the error for the first path (in sorted order) which isn't a field
+// of the configuration or whose value cannot be set
+func (h *adminHandler) check(values map[string]string) (int, error) {
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		err := h.configurator.checkValue(path, values[path])
+		if _, ok := err.(*FieldError); ok {
+			return http.StatusUnprocessableEntity, err
+		}
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+	}
+	return 0, nil
+}
+
+func (h *adminHandler) show(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	fields := h.configurator.Explain()
+	for i := range fields {
+		fields[i].Value = fields[i].Masked()
+	}
+	_ = json.NewEncoder(w).Encode(fields)
+}
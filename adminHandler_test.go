@@ -0,0 +1,133 @@
+//go:build !tinygo
+// +build !tinygo
+
+package configuration
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminHandler(t *testing.T) {
+	cfg := struct {
+		Name     string `default:"test_name"`
+		LogLevel string `json:"log_level" default:"info"`
+		Database struct {
+			Port int `default:"5432" validate:"min=1"`
+		}
+	}{}
+	overrides := NewOverrideProvider()
+
+	c, err := New(&cfg, WithProviders(overrides, NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	var (
+		handler = NewAdminHandler(c, &overrides, func(r *http.Request) bool {
+			return r.Header.Get("Authorization") == "secret"
+		})
+		do = func(method, body string) (*httptest.ResponseRecorder, []FieldValue) {
+			r := httptest.NewRequest(method, "/debug/config", strings.NewReader(body))
+			r.Header.Set("Authorization", "secret")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			var fields []FieldValue
+			_ = json.Unmarshal(w.Body.Bytes(), &fields)
+			return w, fields
+		}
+	)
+
+	w, fields := do(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []FieldValue{
+		{Path: "Database.Port", Value: float64(5432), Source: "defaultProvider"},
+		{Path: "Name", Value: "test_name", Source: "defaultProvider"},
+		{Path: "log_level", Value: "info", Source: "defaultProvider"},
+	}, fields)
+
+	w, fields = do(http.MethodPatch, `{"log_level": "debug"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, FieldValue{Path: "log_level", Value: "debug", Source: "overrideProvider"}, fields[2])
+	assert.Equal(t, "debug", cfg.LogLevel)
+
+	w, _ = do(http.MethodPatch, `not json`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, _ = do(http.MethodPatch, `{"Name": "other", "Databse.Port": "5433"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "unknown path")
+	w, _ = do(http.MethodPatch, `{"Name": "other", "Database.Port": "0"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "invalid value")
+	assert.Equal(t, map[string]string{"log_level": "debug"}, overrides.Values(), "nothing is stored")
+	assert.Equal(t, "test_name", cfg.Name)
+
+	c.SetHealthCheck(func() error {
+		if cfg.Name == "unhealthy" {
+			return errors.New("unhealthy")
+		}
+		return nil
+	})
+	w, _ = do(http.MethodPatch, `{"Name": "unhealthy"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code, "reload fails")
+	assert.Equal(t, map[string]string{"log_level": "debug"}, overrides.Values(), "previous overrides are restored")
+	assert.Equal(t, "test_name", cfg.Name)
+	assert.Equal(t, "debug", cfg.LogLevel)
+
+	w, _ = do(http.MethodDelete, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	r := httptest.NewRequest(http.MethodGet, "/debug/config", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestAdminHandler_ReadOnly(t *testing.T) {
+	cfg := struct {
+		Name string `default:"test_name"`
+	}{}
+
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	w := httptest.NewRecorder()
+	NewAdminHandler(c, nil, nil).ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"Name": "x"}`)))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "test_name", cfg.Name)
+}
+
+func TestAdminHandler_PatchWithoutAuthorize(t *testing.T) {
+	cfg := struct {
+		Name string `default:"test_name"`
+	}{}
+	overrides := NewOverrideProvider()
+
+	c, err := New(&cfg, WithProviders(overrides, NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	handler := NewAdminHandler(c, &overrides, nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"Name": "x"}`)))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "test_name", cfg.Name)
+	assert.Empty(t, overrides.Values())
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code, "GET doesn't require authorize")
+}
@@ -0,0 +1,48 @@
+package configuration
+
+import (
+	"sort"
+	"strings"
+)
+
+// gKeyAliases maps new keys to the old ones (see SetKeyAliases)
+var gKeyAliases map[string][]string
+
+// SetKeyAliases makes providers fall back to old keys while keys are being renamed: env provider looks for old
+// variables, flag provider accepts old flags and file provider reads old keys if new ones aren't set.
+// Keys of the map are old keys, values are new ones in the form used by the provider:
+//
+//	SetKeyAliases(map[string]string{
+//		"DATABASE_HOST": "DB_HOST", // ENV variables (with prefixes)
+//		"database-host": "db-host", // flags
+//		"database.host": "db.host", // paths in files joined with `.`
+//	})
+//
+// Every use of an old key is logged as an error (see WithLogger), so it's visible with LogErrors level.
+// Like SetTagName, it must be called before creating providers. A nil map removes all aliases.
+func SetKeyAliases(aliases map[string]string) {
+	gKeyAliases = make(map[string][]string, len(aliases))
+	for oldKey, newKey := range aliases {
+		gKeyAliases[newKey] = append(gKeyAliases[newKey], oldKey)
+	}
+	for _, oldKeys := range gKeyAliases {
+		sort.Strings(oldKeys)
+	}
+}
+
+// keyAliasesOf returns old keys of the new one in the stable order
+func keyAliasesOf(key string) []string {
+	return gKeyAliases[key]
+}
+
+// isOldKeyPrefix reports whether the key is an old key or a prefix of an old path: `database` of `database.host`
+func isOldKeyPrefix(key string) bool {
+	for _, oldKeys := range gKeyAliases {
+		for _, oldKey := range oldKeys {
+			if oldKey == key || strings.HasPrefix(oldKey, key+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package configuration
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetKeyAliases(t *testing.T) {
+	SetKeyAliases(map[string]string{
+		"OLD_HOST":          "NEW_HOST",
+		"OLDER_HOST":        "NEW_HOST",
+		"alias_old_timeout": "alias_new_timeout",
+		"alias_old_name":    "alias_new_name",
+		"database.host":     "db.host",
+	})
+	defer SetKeyAliases(nil)
+
+	cfg := struct {
+		Host    string `env:"NEW_HOST"`
+		Timeout string `flag:"alias_new_timeout"`
+		Name    string `flag:"alias_new_name||name"`
+		DB      struct {
+			Host string `yaml:"host"`
+			Port int    `yaml:"port"`
+		} `yaml:"db"`
+	}{}
+	os.Args = []string{"smth", "-alias_old_timeout=5s", "-alias_old_name=old", "-alias_new_name=new"}
+
+	var logs []string
+	c, err := New(&cfg,
+		WithProviders(
+			NewEnvProvider().WithEnv(map[string]string{"OLDER_HOST": "older.internal"}),
+			NewFlagProvider(&cfg),
+			NewFileProvider("./testdata/aliases.yml").WithStrictKeys(),
+		),
+		WithLogger(func(format string, v ...interface{}) { logs = append(logs, fmt.Sprintf(format, v...)) }),
+		WithLogLevel(LogErrors),
+	)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	assert.Equal(t, "older.internal", cfg.Host)
+	assert.Equal(t, "5s", cfg.Timeout)
+	assert.Equal(t, "new", cfg.Name, "the new flag has priority")
+	assert.Equal(t, "old.internal", cfg.DB.Host)
+	assert.Equal(t, 5433, cfg.DB.Port)
+	assert.Equal(t, []string{
+		"envProvider: variable [OLDER_HOST] is deprecated, use [NEW_HOST]",
+		"flagProvider: flag [-alias_old_timeout] is deprecated, use [-alias_new_timeout]",
+		"fileProvider: key [database.host] in [./testdata/aliases.yml] is deprecated, use [db.host]",
+	}, logs)
+}
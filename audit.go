@@ -0,0 +1,28 @@
+package configuration
+
+import "time"
+
+// Methods reported in SecretAccess
+const (
+	AccessGet     = "Get"
+	AccessExplain = "Explain"
+)
+
+// SecretAccess describes the read of the field tagged `secret:"true"` via Get or Explain (see WithSecretAudit)
+type SecretAccess struct {
+	Path   string    `json:"path"`   // e.g. `Database.Password`
+	Method string    `json:"method"` // AccessGet or AccessExplain
+	Source string    `json:"source"` // name of the provider which set the value
+	Time   time.Time `json:"time"`
+}
+
+// auditSecrets reports reads of the secret fields to the hook of WithSecretAudit.
+// It must be called after the configurator is unlocked, so the hook can call methods of the configurator.
+func (c configurator) auditSecrets(accesses []SecretAccess) {
+	if c.opts.secretAudit == nil {
+		return
+	}
+	for _, access := range accesses {
+		c.opts.secretAudit(access)
+	}
+}
@@ -0,0 +1,50 @@
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSecretAudit(t *testing.T) {
+	cfg := struct {
+		Host     string `default:"localhost"`
+		Database struct {
+			Password string `env:"AUDIT_DB_PASSWORD" default:"" secret:"true"`
+		}
+	}{}
+
+	var (
+		accesses []SecretAccess
+		c        configurator
+		err      error
+	)
+	c, err = New(&cfg,
+		WithProviders(NewEnvProvider().WithEnv(map[string]string{"AUDIT_DB_PASSWORD": "s3cret"}), NewDefaultProvider()),
+		WithSecretAudit(func(access SecretAccess) {
+			c.Sources() // the configurator is unlocked
+			accesses = append(accesses, access)
+		}),
+	)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Empty(t, accesses, "InitValues doesn't read values")
+
+	_, _ = c.Get("Host")
+	assert.Empty(t, accesses)
+
+	val, ok := c.Get("database.password")
+	assert.True(t, ok)
+	assert.Equal(t, "s3cret", val)
+	c.Explain()
+
+	if assert.Len(t, accesses, 2) {
+		assert.Equal(t, "Database.Password", accesses[0].Path)
+		assert.Equal(t, AccessGet, accesses[0].Method)
+		assert.Equal(t, "envProvider", accesses[0].Source)
+		assert.False(t, accesses[0].Time.IsZero())
+		assert.Equal(t, AccessExplain, accesses[1].Method)
+	}
+}
@@ -0,0 +1,124 @@
+package configuration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Bootstrap describes the chain of providers, read from the bootstrap file by NewProvidersFromFile:
+//
+//	providers:
+//	  - url: env://?prefix=APP
+//	  - type: file
+//	    path: /etc/app/config.yml
+//	    options: {naming: kebab}
+//	  - url: vault://secret/app?token_file=${VAULT_TOKEN_FILE}
+//	    optional: true
+//	  - type: default
+type Bootstrap struct {
+	Providers []BootstrapProvider `json:"providers" yaml:"providers"`
+}
+
+// BootstrapProvider is the provider of the bootstrap file: the URL (see NewProviderFromURL) or its parts.
+// Values are expanded with os.ExpandEnv.
+type BootstrapProvider struct {
+	URL      string                 `json:"url,omitempty" yaml:"url,omitempty"`
+	Type     string                 `json:"type,omitempty" yaml:"type,omitempty"`         // the scheme of the URL, e.g. `env`, `file`
+	Path     string                 `json:"path,omitempty" yaml:"path,omitempty"`         // e.g. the name of the file
+	Options  map[string]interface{} `json:"options,omitempty" yaml:"options,omitempty"`   // query params of the URL
+	Optional bool                   `json:"optional,omitempty" yaml:"optional,omitempty"` // skipped if it cannot be created
+}
+
+// NewProvidersFromFile creates the chain of providers described in the bootstrap file (JSON or YAML, see Bootstrap),
+// so sources of the configuration can be changed without a rebuild. Schemes of custom providers must be
+// registered with RegisterProviderScheme before. Unknown keys of the file are errors.
+func NewProvidersFromFile(fileName string) ([]Provider, error) {
+	return newProvidersFromFile(fileName, nil)
+}
+
+// newProvidersFromFile is the same as NewProvidersFromFile, skipped optional providers are logged with the options
+func newProvidersFromFile(fileName string, o *options) ([]Provider, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	var bootstrap Bootstrap
+	if strings.HasSuffix(strings.ToLower(fileName), ".json") {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&bootstrap)
+	} else {
+		err = yaml.UnmarshalStrict(b, &bootstrap)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap [%s]: %v", fileName, err)
+	}
+
+	providers, err := bootstrap.newProviders(o)
+	if err != nil {
+		return nil, fmt.Errorf("bootstrap [%s]: %v", fileName, err)
+	}
+	return providers, nil
+}
+
+// NewProviders creates providers of the bootstrap in their order
+func (b Bootstrap) NewProviders() ([]Provider, error) {
+	return b.newProviders(nil)
+}
+
+func (b Bootstrap) newProviders(o *options) ([]Provider, error) {
+	if len(b.Providers) == 0 {
+		return nil, errors.New("no providers")
+	}
+
+	var providers []Provider
+	for i, bp := range b.Providers {
+		p, err := bp.newProvider()
+		switch {
+		case err != nil && bp.Optional:
+			o.errorf("configurator: optional provider #%d is skipped: %v", i+1, err)
+			continue
+		case err != nil:
+			return nil, fmt.Errorf("provider #%d: %v", i+1, err)
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
+
+func (bp BootstrapProvider) newProvider() (Provider, error) {
+	rawURL, err := bp.url()
+	if err != nil {
+		return nil, err
+	}
+	return NewProviderFromURL(rawURL)
+}
+
+// url builds the URL from the parts of the provider
+func (bp BootstrapProvider) url() (string, error) {
+	switch {
+	case bp.URL != "" && (bp.Type != "" || bp.Path != "" || len(bp.Options) > 0):
+		return "", errors.New("either url or type, path and options must be set")
+	case bp.URL != "":
+		return os.ExpandEnv(bp.URL), nil
+	case bp.Type == "":
+		return "", errors.New("either url or type must be set")
+	}
+
+	params := url.Values{}
+	for k, v := range bp.Options {
+		params.Set(k, os.ExpandEnv(fmt.Sprint(v)))
+	}
+
+	u := url.URL{Scheme: os.ExpandEnv(bp.Type), Path: os.ExpandEnv(bp.Path), RawQuery: params.Encode()}
+	return u.String(), nil
+}
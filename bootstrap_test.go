@@ -0,0 +1,93 @@
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBootstrapProvider_URL(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider BootstrapProvider
+		expected string
+		wantErr  bool
+	}{
+		{name: "url", provider: BootstrapProvider{URL: "env://?prefix=APP"}, expected: "env://?prefix=APP"},
+		{name: "type", provider: BootstrapProvider{Type: "default"}, expected: "default:"},
+		{name: "relative path", provider: BootstrapProvider{Type: "file", Path: "./app.yml"}, expected: "file://./app.yml"},
+		{name: "absolute path", provider: BootstrapProvider{Type: "file", Path: "/etc/app.yml"}, expected: "file:///etc/app.yml"},
+		{
+			name:     "options",
+			provider: BootstrapProvider{Type: "env", Options: map[string]interface{}{"prefix": "APP", "naming": "derived"}},
+			expected: "env:?naming=derived&prefix=APP",
+		},
+		{name: "url and type", provider: BootstrapProvider{URL: "env://", Type: "env"}, wantErr: true},
+		{name: "empty", provider: BootstrapProvider{}, wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.provider.url()
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, got)
+		})
+	}
+}
+
+func TestNewProvidersFromFile(t *testing.T) {
+	removeEnv, err := setEnv("BOOTSTRAP_TEST_ENV", "prod")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	defer removeEnv()
+
+	providers, err := NewProvidersFromFile("./testdata/bootstrap/chain.yml")
+	assert.NoError(t, err)
+	assert.Len(t, providers, 3, "the optional provider of the unknown scheme is skipped")
+	assert.IsType(t, envProvider{}, providers[0])
+	assert.Equal(t, "BOOTSTRAP", providers[0].(envProvider).prefix)
+	assert.IsType(t, fileProvider{}, providers[1])
+	assert.Equal(t, "./testdata/bootstrap/prod.yml", providers[1].(fileProvider).fileName)
+	assert.IsType(t, defaultProvider{}, providers[2])
+
+	providers, err = NewProvidersFromFile("./testdata/bootstrap/chain.json")
+	assert.NoError(t, err)
+	assert.Len(t, providers, 1)
+
+	_, err = NewProvidersFromFile("./testdata/bootstrap/typo.yml")
+	assert.Error(t, err)
+	_, err = NewProvidersFromFile("./testdata/bootstrap/missing.yml")
+	assert.Error(t, err)
+	_, err = Bootstrap{}.NewProviders()
+	assert.EqualError(t, err, "no providers")
+	_, err = Bootstrap{Providers: []BootstrapProvider{{Type: "default"}, {URL: "consul://app"}}}.NewProviders()
+	assert.EqualError(t, err, "provider #2: unknown provider scheme [consul] in [consul://app]")
+}
+
+func TestWithBootstrapFile(t *testing.T) {
+	removeEnv, err := setEnv("BOOTSTRAP_TEST_ENV", "prod")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	defer removeEnv()
+
+	cfg := struct {
+		LogLevel string `default:"info"`
+		Port     int    `default:"8080"`
+	}{}
+	c, err := New(&cfg, WithBootstrapFile("./testdata/bootstrap/chain.yml"))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, "warn", cfg.LogLevel)
+	assert.Equal(t, 8080, cfg.Port)
+
+	_, err = New(&cfg, WithBootstrapFile("./testdata/bootstrap/missing.yml"))
+	assert.Error(t, err)
+}
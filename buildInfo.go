@@ -0,0 +1,157 @@
+package configuration
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+)
+
+// Identity of the build which is set by the linker, it takes priority over debug.ReadBuildInfo:
+//
+//	go build -ldflags "-X github.com/BoRuDar/configuration.BuildVersion=v1.2.3 \
+//		-X github.com/BoRuDar/configuration.BuildCommit=$(git rev-parse HEAD) \
+//		-X github.com/BoRuDar/configuration.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+var (
+	BuildVersion string
+	BuildCommit  string
+	BuildDate    string // RFC 3339
+)
+
+// Keys of the `build` tag which are set by the build info provider
+const (
+	BuildKeyVersion = "version"
+	BuildKeyCommit  = "commit"
+	BuildKeyDate    = "date"
+	BuildKeyGo      = "go"
+	BuildKeyModule  = "module"
+	BuildKeyDirty   = "dirty"
+	BuildKeyRelease = "release"
+)
+
+// BuildInfo is the identity of the binary (see ReadBuildInfo)
+type BuildInfo struct {
+	Version   string // e.g. `v1.2.3`, `(devel)` for `go run` and `go build` in the module
+	Commit    string
+	Date      string // RFC 3339
+	GoVersion string
+	Module    string // the path of the main module
+	Dirty     bool   // the working tree had uncommitted changes
+}
+
+// ReadBuildInfo returns the identity of the binary: values of BuildVersion, BuildCommit and BuildDate
+// or ones recorded by the go command (the version of the main module and VCS settings of Go 1.18+)
+func ReadBuildInfo() BuildInfo {
+	result := BuildInfo{GoVersion: runtime.Version()}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		result.Module, result.Version = info.Main.Path, info.Main.Version
+		result.Commit, result.Date, result.Dirty = vcsSettings(info)
+	}
+	if BuildVersion != "" {
+		result.Version = BuildVersion
+	}
+	if BuildCommit != "" {
+		result.Commit = BuildCommit
+	}
+	if BuildDate != "" {
+		result.Date = BuildDate
+	}
+	return result
+}
+
+// IsRelease reports whether the version is a semantic version without the pre-release part
+// and the working tree was clean, e.g. `v1.2.3` but not `v1.3.0-rc.1` or `(devel)`
+func (b BuildInfo) IsRelease() bool {
+	v, err := ParseVersion(b.Version)
+	return err == nil && v.PreRelease == "" && !b.Dirty
+}
+
+func (b BuildInfo) value(key string) (string, bool) {
+	switch key {
+	case BuildKeyVersion:
+		return b.Version, true
+	case BuildKeyCommit:
+		return b.Commit, true
+	case BuildKeyDate:
+		return b.Date, true
+	case BuildKeyGo:
+		return b.GoVersion, true
+	case BuildKeyModule:
+		return b.Module, true
+	case BuildKeyDirty:
+		return strconv.FormatBool(b.Dirty), true
+	case BuildKeyRelease:
+		return strconv.FormatBool(b.IsRelease()), true
+	}
+	return "", false
+}
+
+// NewBuildInfoProvider creates the provider which sets the identity of the build (see ReadBuildInfo)
+// into fields tagged with `build`, and values of `defaultRelease` tags in release builds (see IsRelease),
+// so defaults which go after it can be stricter in releases:
+//
+//	Version  string `build:"version"` // also commit, date, go, module, dirty and release
+//	LogLevel string `defaultRelease:"warn" default:"debug"`
+func NewBuildInfoProvider() buildInfoProvider {
+	return buildInfoProvider{info: ReadBuildInfo()}
+}
+
+type buildInfoProvider struct {
+	info BuildInfo
+	opts *options // of the configurator (see optionsBinder)
+}
+
+func (bp buildInfoProvider) withOptions(o *options) Provider {
+	bp.opts = o
+	return bp
+}
+
+// WithBuildInfo replaces the identity of the build, e.g. to test release defaults
+func (bp buildInfoProvider) WithBuildInfo(info BuildInfo) buildInfoProvider {
+	bp.info = info
+	return bp
+}
+
+func (bp buildInfoProvider) Provide(field reflect.StructField, v reflect.Value, path ...string) bool {
+	ok, _ := bp.ProvideError(context.Background(), field, v, path...)
+	return ok
+}
+
+// ProvideError returns the error if the key of the `build` tag is unknown or the value cannot be set
+func (bp buildInfoProvider) ProvideError(_ context.Context, field reflect.StructField, v reflect.Value, _ ...string) (bool, error) {
+	valStr, source := "", ""
+	if key := getBuildTag(field); key != "" {
+		val, ok := bp.info.value(key)
+		if !ok {
+			err := fmt.Errorf("unknown key of build info [%s]", key)
+			bp.opts.errorf("buildInfoProvider: %v", err)
+			return false, parseError(err)
+		}
+		valStr, source = val, key
+	} else if release := getDefaultReleaseTag(field); release != "" && bp.info.IsRelease() {
+		valStr, source = release, "defaultRelease"
+	}
+	if valStr == "" {
+		return false, nil
+	}
+
+	if err := setField(field, v, valStr, bp.opts.strict()); err != nil {
+		bp.opts.errorf("buildInfoProvider: %v", err)
+		return false, parseError(err)
+	}
+	bp.opts.logf("buildInfoProvider: set [%v] from [%s] to field [%s]", logValue(field, valStr), source, field.Name)
+	return true, nil
+}
+
+// DescribeKey returns the key of the build info or `defaultRelease` tag
+func (bp buildInfoProvider) DescribeKey(field reflect.StructField, _ ...string) string {
+	if key := getBuildTag(field); key != "" {
+		return "build info " + key
+	}
+	if getDefaultReleaseTag(field) != "" && bp.info.IsRelease() {
+		return "defaultRelease tag"
+	}
+	return ""
+}
@@ -0,0 +1,11 @@
+//go:build !go1.18
+// +build !go1.18
+
+package configuration
+
+import "runtime/debug"
+
+// vcsSettings returns nothing: VCS settings are recorded since Go 1.18 (use BuildCommit and BuildDate)
+func vcsSettings(*debug.BuildInfo) (commit, date string, dirty bool) {
+	return "", "", false
+}
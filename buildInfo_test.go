@@ -0,0 +1,109 @@
+package configuration
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildInfo_IsRelease(t *testing.T) {
+	tests := []struct {
+		info     BuildInfo
+		expected bool
+	}{
+		{info: BuildInfo{Version: "v1.2.3"}, expected: true},
+		{info: BuildInfo{Version: "1.2.3+build.5"}, expected: true},
+		{info: BuildInfo{Version: "v1.2.3", Dirty: true}, expected: false},
+		{info: BuildInfo{Version: "v1.3.0-rc.1"}, expected: false},
+		{info: BuildInfo{Version: "v0.0.0-20240101120000-abcdef123456"}, expected: false},
+		{info: BuildInfo{Version: "(devel)"}, expected: false},
+		{info: BuildInfo{}, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.info.Version, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.info.IsRelease())
+		})
+	}
+}
+
+func TestReadBuildInfo_Linker(t *testing.T) {
+	defer func() { BuildVersion, BuildCommit, BuildDate = "", "", "" }()
+	BuildVersion, BuildCommit, BuildDate = "v1.2.3", "abc123", "2024-05-01T10:00:00Z"
+
+	info := ReadBuildInfo()
+	assert.Equal(t, "v1.2.3", info.Version)
+	assert.Equal(t, "abc123", info.Commit)
+	assert.Equal(t, "2024-05-01T10:00:00Z", info.Date)
+	assert.NotEmpty(t, info.GoVersion)
+}
+
+type buildConfig struct {
+	Version  Version   `build:"version"`
+	Commit   string    `build:"commit"`
+	Date     time.Time `build:"date"`
+	Release  bool      `build:"release"`
+	LogLevel string    `defaultRelease:"warn" default:"debug"`
+	Debug    bool      `defaultRelease:"false" default:"true"`
+}
+
+func TestBuildInfoProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		info     BuildInfo
+		expected buildConfig
+	}{
+		{
+			name: "release",
+			info: BuildInfo{Version: "v1.2.3", Commit: "abc123", Date: "2024-05-01T10:00:00Z"},
+			expected: buildConfig{
+				Version:  Version{Major: 1, Minor: 2, Patch: 3},
+				Commit:   "abc123",
+				Date:     time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+				Release:  true,
+				LogLevel: "warn",
+				Debug:    false,
+			},
+		},
+		{
+			name: "pre-release",
+			info: BuildInfo{Version: "v1.3.0-rc.1", Commit: "def456", Date: "2024-06-01T10:00:00Z"},
+			expected: buildConfig{
+				Version:  Version{Major: 1, Minor: 3, PreRelease: "rc.1"},
+				Commit:   "def456",
+				Date:     time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC),
+				Release:  false,
+				LogLevel: "debug",
+				Debug:    true,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var cfg buildConfig
+			c, err := New(&cfg, WithProviders(NewBuildInfoProvider().WithBuildInfo(test.info), NewDefaultProvider()))
+			if err != nil {
+				t.Fatal("unexpected err: ", err)
+			}
+			assert.NoError(t, c.InitValues())
+			assert.Equal(t, test.expected, cfg)
+			assert.Equal(t, "buildInfoProvider", c.Sources()["Commit"])
+		})
+	}
+}
+
+func TestBuildInfoProvider_UnknownKey(t *testing.T) {
+	cfg := struct {
+		Branch string `build:"branch"`
+	}{}
+	c, err := New(&cfg, WithProviders(NewBuildInfoProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+	assert.True(t, errors.Is(err, ErrParse))
+	assert.Contains(t, err.Error(), "unknown key of build info [branch]")
+}
@@ -0,0 +1,21 @@
+//go:build go1.18
+// +build go1.18
+
+package configuration
+
+import "runtime/debug"
+
+// vcsSettings returns the revision, the time of the commit and the state of the working tree recorded by the go command
+func vcsSettings(info *debug.BuildInfo) (commit, date string, dirty bool) {
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			commit = s.Value
+		case "vcs.time":
+			date = s.Value
+		case "vcs.modified":
+			dirty = s.Value == "true"
+		}
+	}
+	return commit, date, dirty
+}
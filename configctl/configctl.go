@@ -0,0 +1,253 @@
+// Package configctl implements the companion CLI for configuration structs:
+//
+//	configctl validate <file>...   validates config files (-json prints reports of violations)
+//	configctl explain [<file>]     shows effective values and providers which set them
+//	configctl schema               prints JSON Schema (Helm values.schema.json)
+//	configctl docs                 prints Markdown table of all fields
+//	configctl diff <left> <right>  shows fields which differ between two sources
+//
+// Go can't load types at runtime, so the CLI is built by the application with its own struct:
+//
+//	func main() {
+//		configctl.Main(func() interface{} { return &Config{} })
+//	}
+package configctl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/BoRuDar/configuration"
+)
+
+const usage = `usage: configctl <command> [arguments]
+
+commands:
+  validate [-json] <file>...
+                      validate config files, -json prints reports of all violations
+  explain [<file>]    show effective values (ENV, file, defaults) and their sources
+  schema              print JSON Schema of the config
+  docs                print Markdown docs of the config
+  diff <left> <right> show fields which differ between two sources: files
+                      or comma-separated provider URLs (file://prod.yml,env://?prefix=PROD)
+`
+
+// Main runs the CLI with os.Args and exits with non-zero code on error
+func Main(newCfg func() interface{}) {
+	if err := Run(os.Args[1:], newCfg, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// Run executes the command from args (without the name of the program) writing the output to w.
+// newCfg must return a new pointer to the configuration struct for every call.
+func Run(args []string, newCfg func() interface{}, w io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	switch cmd, args := args[0], args[1:]; cmd {
+	case "validate":
+		return validate(args, newCfg, w)
+	case "explain":
+		return explain(args, newCfg, w)
+	case "schema":
+		return generate(configuration.GenerateHelmSchema, newCfg, w)
+	case "docs":
+		return generate(func(cfgPtr interface{}) ([]byte, error) {
+			return configuration.GenerateMarkdownDocs(cfgPtr, configuration.NewEnvProvider()) // the same as explain reads
+		}, newCfg, w)
+	case "diff":
+		return diff(args, newCfg, w)
+	default:
+		return fmt.Errorf("unknown command [%s]\n%s", cmd, usage)
+	}
+}
+
+func validate(files []string, newCfg func() interface{}, w io.Writer) error {
+	if len(files) > 0 && files[0] == "-json" {
+		return validateJSON(files[1:], newCfg, w)
+	}
+	if len(files) == 0 {
+		return errors.New("validate: no files")
+	}
+
+	failed := 0
+	for _, file := range files {
+		if err := load(newCfg(), file, false); err != nil {
+			fmt.Fprintf(w, "%s: %v\n", file, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(w, "%s: ok\n", file)
+	}
+	if failed > 0 {
+		return fmt.Errorf("validate: %d of %d files are invalid", failed, len(files))
+	}
+	return nil
+}
+
+// fileReport is the report of violations of the file printed by `validate -json`
+type fileReport struct {
+	File string `json:"file"`
+	configuration.ValidationReport
+}
+
+func validateJSON(files []string, newCfg func() interface{}, w io.Writer) error {
+	if len(files) == 0 {
+		return errors.New("validate: no files")
+	}
+
+	var (
+		reports = make([]fileReport, 0, len(files))
+		failed  = 0
+	)
+	for _, file := range files {
+		report := fileReport{File: file}
+		c, err := newConfigurator(newCfg(), file, false)
+		if err != nil {
+			report.Violations = []configuration.Violation{{Severity: configuration.SeverityError, Message: err.Error()}}
+		} else {
+			_ = c.InitValues() // violations are in the report
+			report.ValidationReport = c.ValidationReport()
+		}
+		if !report.Valid {
+			failed++
+		}
+		reports = append(reports, report)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(reports); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("validate: %d of %d files are invalid", failed, len(files))
+	}
+	return nil
+}
+
+func explain(args []string, newCfg func() interface{}, w io.Writer) error {
+	if len(args) > 1 {
+		return errors.New("explain: too many arguments")
+	}
+	file := ""
+	if len(args) == 1 {
+		file = args[0]
+	}
+
+	c, err := newConfigurator(newCfg(), file, true)
+	if err != nil {
+		return err
+	}
+	if err := c.InitValues(); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tVALUE\tSOURCE")
+	for _, f := range c.Explain() {
+		fmt.Fprintf(tw, "%s\t%v\t%s\n", f.Path, f.Masked(), f.Source)
+	}
+	return tw.Flush()
+}
+
+func diff(args []string, newCfg func() interface{}, w io.Writer) error {
+	if len(args) != 2 {
+		return errors.New("diff: expected two sources")
+	}
+	left, err := sourceProviders(args[0])
+	if err != nil {
+		return err
+	}
+	right, err := sourceProviders(args[1])
+	if err != nil {
+		return err
+	}
+
+	diffs, err := configuration.Diff(newCfg, left, right)
+	if err != nil {
+		return err
+	}
+	if len(diffs) == 0 {
+		_, err := fmt.Fprintln(w, "no differences")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "PATH\t%s\t%s\n", args[0], args[1])
+	for _, d := range diffs {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", d.Path, diffValue(d.MaskedLeft(), d.LeftSource), diffValue(d.MaskedRight(), d.RightSource))
+	}
+	return tw.Flush()
+}
+
+// sourceProviders creates providers of the source for diff: the file or comma-separated provider URLs, and defaults
+func sourceProviders(source string) ([]configuration.Provider, error) {
+	if !strings.Contains(source, "://") {
+		if _, err := os.Stat(source); err != nil {
+			return nil, err
+		}
+		return []configuration.Provider{configuration.NewFileProvider(source), configuration.NewDefaultProvider()}, nil
+	}
+
+	providers, err := configuration.NewProvidersFromURLs(strings.Split(source, ",")...)
+	if err != nil {
+		return nil, err
+	}
+	return append(providers, configuration.NewDefaultProvider()), nil
+}
+
+func diffValue(val interface{}, source string) string {
+	if source == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%v (%s)", val, source)
+}
+
+func generate(fn func(cfgPtr interface{}) ([]byte, error), newCfg func() interface{}, w io.Writer) error {
+	b, err := fn(newCfg())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func load(cfgPtr interface{}, file string, withEnv bool) error {
+	c, err := newConfigurator(cfgPtr, file, withEnv)
+	if err != nil {
+		return err
+	}
+	return c.InitValues()
+}
+
+// newConfigurator creates the configurator with the providers: ENV (if withEnv), the file (if not empty) and defaults
+func newConfigurator(cfgPtr interface{}, file string, withEnv bool, opts ...configuration.Option) (configurator, error) {
+	var providers []configuration.Provider
+	if withEnv {
+		providers = append(providers, configuration.NewEnvProvider())
+	}
+	if file != "" {
+		if _, err := os.Stat(file); err != nil {
+			return nil, err
+		}
+		providers = append(providers, configuration.NewFileProvider(file))
+	}
+	providers = append(providers, configuration.NewDefaultProvider())
+
+	return configuration.New(cfgPtr, append(opts, configuration.WithProviders(providers...))...)
+}
+
+type configurator interface {
+	InitValues() error
+	Explain() []configuration.FieldValue
+	ValidationReport() configuration.ValidationReport
+}
@@ -0,0 +1,111 @@
+package configctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/BoRuDar/configuration"
+	"github.com/stretchr/testify/assert"
+)
+
+type testConfig struct {
+	Name     string `json:"name"`
+	LogLevel string `json:"log_level" env:"CONFIGCTL_LOG_LEVEL" default:"info"`
+	Database struct {
+		Host     string `json:"host"`
+		Password string `json:"password" secret:"true"`
+	} `json:"database"`
+}
+
+func newTestConfig() interface{} { return &testConfig{} }
+
+func TestRun_Validate(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, Run([]string{"validate", "./testdata/valid.yml"}, newTestConfig, &buf))
+	assert.Equal(t, "./testdata/valid.yml: ok\n", buf.String())
+
+	buf.Reset()
+	err := Run([]string{"validate", "./testdata/valid.yml", "./testdata/invalid.yml", "./testdata/missing.yml"}, newTestConfig, &buf)
+	assert.EqualError(t, err, "validate: 2 of 3 files are invalid")
+	assert.Contains(t, buf.String(), "./testdata/invalid.yml: ")
+	assert.Contains(t, buf.String(), "./testdata/missing.yml: ")
+
+	assert.Error(t, Run([]string{"validate"}, newTestConfig, &buf))
+}
+
+func TestRun_ValidateJSON(t *testing.T) {
+	var buf bytes.Buffer
+	err := Run([]string{"validate", "-json", "./testdata/valid.yml", "./testdata/invalid.yml", "./testdata/missing.yml"}, newTestConfig, &buf)
+	assert.EqualError(t, err, "validate: 2 of 3 files are invalid")
+
+	var reports []fileReport
+	if err := json.Unmarshal(buf.Bytes(), &reports); err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Len(t, reports, 3)
+	assert.True(t, reports[0].Valid)
+	assert.Empty(t, reports[0].Violations)
+	assert.False(t, reports[1].Valid)
+	assert.Equal(t, []configuration.Violation{
+		{Severity: configuration.SeverityError, Path: "name", Constraint: configuration.ConstraintSet, Message: configuration.ErrNotSet.Error()},
+		{Severity: configuration.SeverityError, Path: "database.password", Constraint: configuration.ConstraintSet, Message: configuration.ErrNotSet.Error()},
+	}, reports[1].Violations)
+	assert.False(t, reports[2].Valid)
+	assert.Len(t, reports[2].Violations, 1)
+
+	assert.Error(t, Run([]string{"validate", "-json"}, newTestConfig, &buf))
+}
+
+func TestRun_Explain(t *testing.T) {
+	if err := os.Setenv("CONFIGCTL_LOG_LEVEL", "debug"); err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	defer os.Unsetenv("CONFIGCTL_LOG_LEVEL")
+
+	var buf bytes.Buffer
+	assert.NoError(t, Run([]string{"explain", "./testdata/valid.yml"}, newTestConfig, &buf))
+	assert.Equal(t, `PATH               VALUE        SOURCE
+database.host      db.internal  fileProvider
+database.password  ******       fileProvider
+log_level          debug        envProvider
+name               svc          fileProvider
+`, buf.String())
+
+	assert.Error(t, Run([]string{"explain", "a.yml", "b.yml"}, newTestConfig, &buf))
+}
+
+func TestRun_Generators(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, Run([]string{"schema"}, newTestConfig, &buf))
+	assert.Contains(t, buf.String(), `"$schema": "http://json-schema.org/draft-07/schema#"`)
+
+	buf.Reset()
+	assert.NoError(t, Run([]string{"docs"}, newTestConfig, &buf))
+	assert.Contains(t, buf.String(), "| `log_level` | string | `CONFIGCTL_LOG_LEVEL` |  | `info` |  |\n")
+}
+
+func TestRun_Diff(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, Run([]string{"diff", "./testdata/valid.yml", "file://./testdata/prod.yml"}, newTestConfig, &buf))
+	assert.Equal(t, `PATH               ./testdata/valid.yml        file://./testdata/prod.yml
+database.host      db.internal (fileProvider)  db.prod.internal (fileProvider)
+database.password  ****** (fileProvider)       ****** (fileProvider)
+log_level          info (defaultProvider)      warn (fileProvider)
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, Run([]string{"diff", "./testdata/valid.yml", "./testdata/valid.yml"}, newTestConfig, &buf))
+	assert.Equal(t, "no differences\n", buf.String())
+
+	assert.Error(t, Run([]string{"diff", "./testdata/valid.yml"}, newTestConfig, &buf))
+	assert.Error(t, Run([]string{"diff", "./testdata/valid.yml", "./testdata/missing.yml"}, newTestConfig, &buf))
+	assert.Error(t, Run([]string{"diff", "./testdata/valid.yml", "consul://prod"}, newTestConfig, &buf))
+}
+
+func TestRun_Unknown(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Error(t, Run(nil, newTestConfig, &buf))
+	assert.Error(t, Run([]string{"deploy"}, newTestConfig, &buf))
+}
@@ -2,20 +2,38 @@
 package configuration
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
 	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
-// New creates a new instance of the configurator
-func New(
-	cfgPtr interface{}, // must be a pointer to a struct
-	providers []Provider,
-	loggingEnabled bool,
-	failIfCannotSet bool,
-) (configurator, error) {
-	if len(providers) == 0 {
+const pathSeparator = "."
+
+// New creates a new instance of the configurator:
+//
+//	New(&cfg, WithProviders(NewEnvProvider(), NewDefaultProvider()), WithLogger(log.Printf))
+func New(cfgPtr interface{}, opts ...Option) (configurator, error) { // cfgPtr must be a pointer to a struct
+	o := newOptions(opts)
+	if o.environments != nil {
+		providers, err := o.environments.providers(o.environment)
+		if err != nil {
+			return configurator{}, err
+		}
+		o.providers = append(o.providers, providers...)
+	}
+	if o.bootstrapFile != "" {
+		providers, err := newProvidersFromFile(o.bootstrapFile, o)
+		if err != nil {
+			return configurator{}, err
+		}
+		o.providers = append(o.providers, providers...)
+	}
+	if len(o.providers) == 0 {
 		return configurator{}, errors.New("providers not found")
 	}
 
@@ -23,33 +41,363 @@ func New(
 		return configurator{}, errors.New("not a pointer to the struct")
 	}
 
-	gLoggingEnabled = loggingEnabled
-	gFailIfCannotSet = failIfCannotSet
-	gLogger = log.Printf
+	if err := checkTypeCycles(reflect.TypeOf(cfgPtr).Elem(), o.maxDepth); err != nil {
+		return configurator{}, err
+	}
+
+	if err := checkTypeSize(reflect.TypeOf(cfgPtr).Elem(), o.maxFields); err != nil {
+		return configurator{}, err
+	}
+
+	if err := checkConflicts(reflect.TypeOf(cfgPtr).Elem(), o.tagNames); err != nil {
+		return configurator{}, err
+	}
+
+	if err := checkFlagTags(o.providers, o.tagNames); err != nil {
+		return configurator{}, err
+	}
+
+	if err := checkTTLs(reflect.TypeOf(cfgPtr).Elem(), o.tagNames); err != nil {
+		return configurator{}, err
+	}
+
+	var access *accessState
+	if o.trackAccess {
+		access = &accessState{reads: map[string]int64{}}
+	}
 
 	return configurator{
 		config:    cfgPtr,
-		providers: providers,
+		providers: o.bind(o.providers),
+		sources:   map[string]string{},
+		stats:     &initStats{},
+		history:   &snapshots{},
+		frozen:    &frozenState{},
+		watch:     &watchState{},
+		access:    access,
+		opts:      o,
+		mu:        &sync.RWMutex{},
 	}, nil
 }
 
+// lock locks the configurator for writing, returns the function which unlocks it
+func (c configurator) lock() func() {
+	c.mu.Lock()
+	return c.mu.Unlock
+}
+
 type configurator struct {
 	config    interface{}
 	providers []Provider
+	sources   map[string]string // path to the field -> name of the provider which set it
+	stats     *initStats
+	history   *snapshots
+	frozen    *frozenState
+	watch     *watchState
+	access    *accessState // nil unless WithAccessTracking
+	opts      *options
+	mu        *sync.RWMutex // guards the configuration object, providers, sources, stats, history, frozen and watch
+
+	failures *[]error     // fields which cannot be set during the current InitValues call (nil with StopOnFirstError)
+	unset    *UnsetReport // fields which are not set by any provider during the current InitValues call (see WithUnsetReport)
+	group    bool         // fields of the group are being filled, failures are checked by fillUpGroup
+}
+
+// loadErrors describes all fields which cannot be set during InitValues
+type loadErrors []error
+
+// Unwrap returns errors of all fields (for errors.Is and errors.As of Go 1.20+)
+func (e loadErrors) Unwrap() []error {
+	return e
+}
+
+// Is reports whether any of the errors matches the target (errors.Is of older Go versions ignores Unwrap() []error)
+func (e loadErrors) Is(target error) bool {
+	return anyIs(e, target)
+}
+
+// As finds the first of the errors which matches the target (see Is)
+func (e loadErrors) As(target interface{}) bool {
+	return anyAs(e, target)
+}
+
+func (e loadErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = "\t" + err.Error()
+	}
+	return msg(MsgFieldsNotSet, len(e), strings.Join(msgs, "\n"))
+}
+
+type initStats struct {
+	generation int64     // number of InitValues calls
+	updatedAt  time.Time // time of the last InitValues call
+	timings    Timings   // of the last InitValues call
+
+	fieldsProcessed int       // by the last InitValues call
+	manifest        *Manifest // of the last InitValues call, nil unless WithTrace
+
+	lastErr  error       // returned by the last InitValues call (see ValidationReport)
+	warnings []Violation // failures of fields tagged `severity:"warn"` during the last InitValues call
 }
 
 // InitValues sets values into struct field using given set of providers
 // respecting their order: first defined -> first executed
 func (c configurator) InitValues() error {
-	return c.fillUp(c.config)
+	return c.InitValuesContext(context.Background())
+}
+
+// InitValuesContext is the same as InitValues but stops with ctx.Err() once the context is done.
+// The context is passed to providers which implement ContextProvider, so remote lookups respect deadlines.
+func (c configurator) InitValuesContext(ctx context.Context) error {
+	defer c.lock()()
+	defer c.beginChange()()
+
+	if err := c.initValues(ctx); err != nil {
+		return err
+	}
+	c.history.push(c.snapshot())
+	return nil
+}
+
+func (c configurator) initValues(ctx context.Context) (err error) {
+	ctx = withRunCache(ctx)
+	c.stats.generation++
+	c.stats.updatedAt = time.Now()
+	c.stats.timings = newTimings()
+	c.stats.fieldsProcessed = 0
+	c.stats.warnings = nil
+	if c.opts.trace {
+		c.stats.manifest = newManifest(c.providers, c.stats.updatedAt)
+	}
+	defer func() {
+		c.stats.lastErr = err
+		if err == nil {
+			c.watch.expires = expirations(c.config, c.opts.tags(), time.Now())
+		}
+		c.stats.timings.Total = time.Since(c.stats.updatedAt)
+		if c.stats.manifest != nil {
+			c.stats.manifest.finish()
+		}
+		if c.opts.timingReport {
+			c.opts.logger("configurator: InitValues %v", c.stats.timings)
+		}
+	}()
+
+	var keysErrs []error
+	for _, provider := range c.providers {
+		if kc, ok := provider.(KeysChecker); ok {
+			keysErrs = append(keysErrs, kc.CheckKeys(reflect.TypeOf(c.config).Elem())...)
+		}
+	}
+	for _, err := range keysErrs {
+		c.opts.errorf("%v", err)
+	}
+	if c.opts.ignoredKeys != nil {
+		c.opts.ignoredKeys(ignoredKeys(c.providers, reflect.TypeOf(c.config).Elem()))
+	}
+
+	if c.opts.unsetReport {
+		c.unset = newUnsetReport()
+	}
+	if c.opts.stopOnFirstError {
+		if len(keysErrs) > 0 {
+			return loadErrors(keysErrs)
+		}
+		if err := c.fillUp(ctx, c.config); err != nil {
+			return err
+		}
+		return c.unsetError(nil)
+	}
+
+	failures := keysErrs
+	c.failures = &failures
+	if err := c.fillUp(ctx, c.config); err != nil {
+		return err
+	}
+	return c.unsetError(failures)
+}
+
+// unsetError returns failures of fields together with the report of unset fields (see WithUnsetReport)
+func (c configurator) unsetError(failures []error) error {
+	if c.unset == nil || len(c.unset.Fields) == 0 {
+		if len(failures) > 0 {
+			return loadErrors(failures)
+		}
+		return nil
+	}
+
+	c.opts.fatalf("%v", c.unset)
+	if len(failures) == 0 {
+		return c.unset
+	}
+	return loadErrors(append(failures, c.unset))
+}
+
+// Sources returns names of providers which set the fields during the last InitValues call.
+// Keys are paths to the fields joined with `.` (e.g. `Database.Host`).
+func (c configurator) Sources() map[string]string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.copySources()
+}
+
+func (c configurator) copySources() map[string]string {
+	sources := make(map[string]string, len(c.sources))
+	for path, name := range c.sources {
+		sources[path] = name
+	}
+	return sources
+}
+
+// FieldValue is the effective value of the field of the configuration object
+type FieldValue struct {
+	Path   string      `json:"path"` // e.g. `Database.Host`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source,omitempty"` // name of the provider which set the value (see Sources)
+	Secret bool        `json:"-"`                // `secret:"true"` or `secret:"<mask>"`
+	Mask   string      `json:"-"`                // the strategy of masking the secret (see MaskFull)
+}
+
+// Masked returns the value or its mask if the field is secret
+func (f FieldValue) Masked() interface{} {
+	if f.Secret {
+		return MaskSecret(f.Mask, f.Value)
+	}
+	return f.Value
+}
+
+// Explain returns effective values of all fields (sorted by path) with the providers which set them
+func (c configurator) Explain() []FieldValue {
+	c.mu.RLock()
+	var fields []FieldValue
+	walkFields(reflect.ValueOf(c.config), nil, c.opts.tags(), func(path string, field reflect.StructField, v reflect.Value) {
+		mask := getMask(field)
+		fields = append(fields, FieldValue{Path: path, Value: deepCopy(v).Interface(), Source: c.sources[path], Secret: mask != "", Mask: mask})
+	})
+	c.mu.RUnlock()
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Path < fields[j].Path })
+	var accesses []SecretAccess
+	for _, f := range fields {
+		if f.Secret {
+			accesses = append(accesses, SecretAccess{Path: f.Path, Method: AccessExplain, Source: f.Source, Time: time.Now()})
+		}
+	}
+	c.auditSecrets(accesses)
+	return fields
+}
+
+// Get returns a copy of the value of the field located at the path (e.g. `Database.Host`, case-insensitive)
+func (c configurator) Get(path string) (interface{}, bool) {
+	c.mu.RLock()
+	var (
+		val    interface{}
+		found  bool
+		access []SecretAccess
+	)
+	walkFields(reflect.ValueOf(c.config), nil, c.opts.tags(), func(p string, field reflect.StructField, v reflect.Value) {
+		if !found && strings.EqualFold(p, path) {
+			val, found = deepCopy(v).Interface(), true
+			c.access.record(p)
+			if isSecret(field) {
+				access = []SecretAccess{{Path: p, Method: AccessGet, Source: c.sources[p], Time: time.Now()}}
+			}
+		}
+	})
+	c.mu.RUnlock()
+
+	c.auditSecrets(access)
+	return val, found
+}
+
+// Set changes the value of the field located at the path (e.g. `Database.Host`): the value is converted
+// to the type of the field, persisted to the first provider which implements WritableProvider and only
+// then set into the configuration object, so the object is left untouched if any step fails.
+func (c configurator) Set(path, value string) error {
+	defer c.lock()()
+	defer c.beginChange()()
+
+	path, v, newVal, err := c.parseValue(path, value)
+	if err != nil {
+		return err
+	}
+
+	for _, provider := range c.providers {
+		wp, ok := provider.(WritableProvider)
+		if !ok {
+			continue
+		}
+		if err := wp.Set(path, value); err != nil {
+			return fmt.Errorf("configurator: %s: %v", providerName(provider), err)
+		}
+
+		v.Set(newVal)
+		c.sources[path] = providerName(provider)
+		return nil
+	}
+	return errors.New("configurator: writable provider not found")
+}
+
+// checkValue returns the error if Set would reject the value for the path (the field isn't changed)
+func (c configurator) checkValue(path, value string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, _, _, err := c.parseValue(path, value)
+	return err
+}
+
+// parseValue returns the path of the field matching the path case-insensitively (as it's used by sources),
+// the field and the value converted to its type, normalized and validated
+func (c configurator) parseValue(path, value string) (fieldPath string, v, newVal reflect.Value, err error) {
+	var (
+		field reflect.StructField
+		found bool
+	)
+	walkFields(reflect.ValueOf(c.config), nil, c.opts.tags(), func(p string, f reflect.StructField, fv reflect.Value) {
+		if !found && strings.EqualFold(p, path) {
+			fieldPath, field, v, found = p, f, fv, true
+		}
+	})
+	if !found {
+		return "", v, newVal, errors.New(msg(MsgFieldNotFound, path))
+	}
+	if !v.CanSet() {
+		return "", v, newVal, fmt.Errorf("configurator: field [%s] cannot be set", fieldPath)
+	}
+
+	newVal = reflect.New(field.Type).Elem()
+	if err := setField(field, newVal, value, c.opts.strictCoercion); err != nil {
+		return "", v, newVal, &FieldError{Path: fieldPath, Tag: string(field.Tag), Err: parseError(err), name: field.Name}
+	}
+	if err := c.normalize(field, newVal); err != nil {
+		return "", v, newVal, &FieldError{Path: fieldPath, Tag: string(field.Tag), Err: parseError(err), name: field.Name}
+	}
+	if err := c.validate(field, newVal); err != nil {
+		return "", v, newVal, &FieldError{Path: fieldPath, Tag: string(field.Tag), Err: invalidError(err), name: field.Name}
+	}
+	return fieldPath, v, newVal, nil
 }
 
-// SetLogger changes gLogger
-func (configurator) SetLogger(l Logger) {
-	gLogger = l
+// SetLogger changes the logger of the configurator.
+//
+// Deprecated: use WithLogger option of New.
+func (c configurator) SetLogger(l Logger) {
+	if c.mu != nil {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+	}
+	if c.opts != nil {
+		c.opts.logger = l
+	}
 }
 
-func (c configurator) fillUp(i interface{}, parentPath ...string) error {
+func (c configurator) fillUp(ctx context.Context, i interface{}, parentPath ...string) error {
 	var (
 		t = reflect.TypeOf(i)
 		v = reflect.ValueOf(i)
@@ -60,48 +408,252 @@ func (c configurator) fillUp(i interface{}, parentPath ...string) error {
 		v = v.Elem()
 	}
 
-	for i := 0; i < t.NumField(); i++ {
+	if len(parentPath) > c.opts.maxDepth { // e.g. recursive maps of structs
+		return depthError(parentPath, c.opts.maxDepth)
+	}
+
+	fields := structFields(t, c.opts.tags())
+	for _, f := range fields {
 		var (
-			tField      = t.Field(i)
-			vField      = v.Field(i)
-			currentPath = append(parentPath, tField.Name)
+			tField      = f.field
+			vField      = v.FieldByIndex(f.index)
+			currentPath = append(parentPath, getFieldKey(tField))
 		)
 
-		if tField.Type.Kind() == reflect.Struct {
-			if err := c.fillUp(vField.Addr().Interface(), currentPath...); err != nil {
+		if isInternalField(tField) {
+			c.opts.logf("configurator: skip internal field [%s]", tField.Name)
+			continue
+		}
+
+		if isGroup(tField) && !isLeafStruct(tField.Type) {
+			if err := c.fillUpGroup(ctx, tField, vField, currentPath); err != nil {
+				c.opts.errorf("%v", err)
+				c.opts.fatalf("%v", err)
+				if c.failures == nil || ctx.Err() != nil {
+					return err
+				}
+				*c.failures = append(*c.failures, err)
+			}
+			continue
+		}
+
+		if isPayload(tField) && !isLeafStruct(tField.Type) {
+			if err := c.fillUpPayload(ctx, tField, vField, currentPath); err != nil {
+				c.opts.errorf("%v", err)
+				c.opts.fatalf("%v", err)
+				if c.failures == nil || ctx.Err() != nil {
+					return err
+				}
+				*c.failures = append(*c.failures, err)
+			}
+			continue
+		}
+
+		if tField.Type.Kind() == reflect.Struct && !isLeafStruct(tField.Type) {
+			if err := c.fillUp(ctx, vField.Addr().Interface(), currentPath...); err != nil {
 				return err
 			}
 			continue
 		}
 
-		if tField.Type.Kind() == reflect.Ptr && tField.Type.Elem().Kind() == reflect.Struct {
+		if tField.Type.Kind() == reflect.Ptr && tField.Type.Elem().Kind() == reflect.Struct && !isLeafStruct(tField.Type) {
 			vField.Set(reflect.New(tField.Type.Elem()))
-			if err := c.fillUp(vField.Interface(), currentPath...); err != nil {
+			if err := c.fillUp(ctx, vField.Interface(), currentPath...); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if isStructMap(tField.Type) {
+			if err := c.fillUpMap(ctx, tField.Type, vField, currentPath); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := c.countField(currentPath); err != nil {
+			return err
+		}
+		if err := c.applyProviders(ctx, tField, vField, currentPath); err != nil {
+			if c.failures == nil || ctx.Err() != nil {
 				return err
 			}
+			*c.failures = append(*c.failures, err)
+		}
+	}
+	c.copyShadowed(v, fields)
+	return nil
+}
+
+// fillUpMap populates `map[string]SomeStruct` (or `map[string]*SomeStruct`) fields:
+// keys are fetched from providers which implement KeysProvider and every value goes through fillUp
+func (c configurator) fillUpMap(ctx context.Context, t reflect.Type, v reflect.Value, currentPath []string) error {
+	var (
+		keys []string
+		seen = map[string]bool{}
+	)
+	for _, provider := range c.providers {
+		kp, ok := provider.(KeysProvider)
+		if !ok {
 			continue
 		}
+		for _, key := range kp.Keys(currentPath...) {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	if len(keys) == 0 {
+		c.opts.logf("configurator: no keys found for the map [%v]", currentPath)
+		return nil
+	}
+
+	var (
+		result   = reflect.MakeMapWithSize(t, len(keys))
+		elemType = t.Elem()
+	)
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
 
-		if err := c.applyProviders(tField, vField, currentPath); err != nil {
+	for _, key := range keys {
+		elem := reflect.New(elemType)
+		if err := c.fillUp(ctx, elem.Interface(), append(currentPath[:len(currentPath):len(currentPath)], key)...); err != nil {
 			return err
 		}
+
+		if t.Elem().Kind() == reflect.Ptr {
+			result.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), elem)
+			continue
+		}
+		result.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), elem.Elem())
 	}
+	v.Set(result)
 	return nil
 }
 
-func (c configurator) applyProviders(field reflect.StructField, v reflect.Value, currentPath []string) error {
-	logf("configurator: current path: %v", currentPath)
+// isStructMap reports whether the type is a map with string keys and struct (or pointer to struct) values
+func isStructMap(t reflect.Type) bool {
+	if t.Kind() != reflect.Map || t.Key().Kind() != reflect.String {
+		return false
+	}
+
+	elem := t.Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem.Kind() == reflect.Struct && !isLeafStruct(elem)
+}
+
+// provide calls the richest method implemented by the provider: ProvideError, ProvideContext or Provide
+func provide(ctx context.Context, p Provider, field reflect.StructField, v reflect.Value, path []string) (bool, error) {
+	switch provider := p.(type) {
+	case ErrorProvider:
+		return provider.ProvideError(ctx, field, v, path...)
+	case ContextProvider:
+		return provider.ProvideContext(ctx, field, v, path...), nil
+	}
+	return p.Provide(field, v, path...), nil
+}
+
+// providerName returns the name of the type of the provider, e.g. `envProvider`
+func providerName(p Provider) string {
+	t := reflect.TypeOf(p)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+func (c configurator) applyProviders(ctx context.Context, field reflect.StructField, v reflect.Value, currentPath []string) error {
+	var (
+		path     = strings.Join(currentPath, pathSeparator)
+		firstErr *FieldError // the first provider which found the value but failed
+	)
+	c.opts.setCurrentPath(path)
+	defer c.opts.setCurrentPath("")
+	c.opts.logf("configurator: current path: %v", currentPath)
+
+	started := time.Now()
+	defer func() { c.stats.timings.Fields[path] += time.Since(started) }()
+
+	var (
+		source   string
+		attempts []TraceAttempt // answers of providers for the manifest of WithTrace
+	)
+	if c.stats.manifest != nil {
+		defer func() { c.stats.manifest.add(field, v, path, source, attempts) }()
+	}
 
+	var original reflect.Value // restored if the field tagged `severity:"warn"` cannot be set
+	if isWarnOnly(field) {
+		original = deepCopy(v)
+	}
 	for _, provider := range c.providers {
-		if provider.Provide(field, v, currentPath...) {
-			logf("\n")
+		if err := ctx.Err(); err != nil {
+			return &FieldError{Path: path, Tag: string(field.Tag), Err: unavailableError(err), name: field.Name}
+		}
+
+		started := time.Now()
+		ok, err := provide(ctx, provider, field, v, currentPath)
+		c.stats.timings.Providers[providerName(provider)] += time.Since(started)
+		if ok {
+			if err := c.normalize(field, v); err != nil {
+				err = withPosition(provider, currentPath, err)
+				firstErr = &FieldError{Path: path, Tag: string(field.Tag), Provider: providerName(provider), Err: parseError(err), name: field.Name}
+				attempts = append(attempts, traceAttempt(provider, TraceFailed, err))
+				break
+			}
+			if err := c.validate(field, v); err != nil {
+				err = withPosition(provider, currentPath, err)
+				firstErr = &FieldError{Path: path, Tag: string(field.Tag), Provider: providerName(provider), Err: invalidError(err), name: field.Name}
+				attempts = append(attempts, traceAttempt(provider, TraceFailed, err))
+				break
+			}
+			source = providerName(provider)
+			c.sources[path] = source
+			attempts = append(attempts, traceAttempt(provider, TraceSet, nil))
+			c.opts.logf("\n")
 			return nil
 		}
+		if err != nil {
+			attempts = append(attempts, traceAttempt(provider, TraceFailed, err))
+		} else {
+			attempts = append(attempts, traceAttempt(provider, TraceNotFound, nil))
+		}
+		if err != nil && firstErr == nil {
+			firstErr = &FieldError{Path: path, Tag: string(field.Tag), Provider: providerName(provider), Err: err, name: field.Name}
+		}
+	}
+
+	fieldErr := firstErr
+	switch {
+	case fieldErr != nil:
+	case isRequired(field, c.opts.tagNames):
+		fieldErr = &FieldError{Path: path, Tag: string(field.Tag), Err: ErrRequired, name: field.Name}
+	case c.opts.allowUnset:
+		c.opts.logf("configurator: field [%s] is not set\n", path)
+		return nil
+	default:
+		fieldErr = &FieldError{Path: path, Tag: string(field.Tag), Err: ErrNotSet, name: field.Name}
+	}
+	c.opts.errorf("%v", fieldErr)
+	if original.IsValid() && fieldErr.Err != ErrRequired {
+		v.Set(original)
+		if firstErr != nil { // the value is found but invalid, unset optional fields aren't violations
+			c.stats.warnings = append(c.stats.warnings, newViolation(SeverityWarn, fieldErr))
+		}
+		c.opts.errorf("configurator: field [%s] is not critical (severity warn), keeping [%v]", path, original)
+		return nil
+	}
+	if c.unset != nil && firstErr == nil {
+		c.unset.add(fieldErr, c.providers, field, currentPath)
+		return nil
 	}
-	errMsg := fmt.Sprintf("configurator: field [%s] with tags [%v] cannot be set!", field.Name, field.Tag)
-	logf(errMsg)
-	if gFailIfCannotSet {
-		fatalf(errMsg)
+	if !c.group {
+		c.opts.fatalf("%v", fieldErr)
 	}
-	return errors.New(errMsg)
+	return fieldErr
 }
@@ -1,8 +1,17 @@
 package configuration
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"math/big"
+	"net/mail"
 	"os"
+	"reflect"
+	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,11 +19,6 @@ import (
 )
 
 func TestConfigurator(t *testing.T) {
-	defer func() {
-		gFailIfCannotSet = false
-		gLoggingEnabled = false
-	}()
-
 	// setting command line flag
 	os.Args = []string{"smth", "-name=flag_value"}
 
@@ -46,12 +50,12 @@ func TestConfigurator(t *testing.T) {
 		}
 	}{}
 
-	configurator, err := New(&cfg, []Provider{
+	configurator, err := New(&cfg, WithProviders(
 		NewFlagProvider(&cfg),
 		NewEnvProvider(),
 		NewFileProvider("./testdata/input.yml"),
 		NewDefaultProvider(),
-	}, true, true)
+	), WithLogger(log.Printf), FailIfCannotSet())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -96,7 +100,7 @@ func TestConfigurator_Errors(t *testing.T) {
 	for name, test := range tests {
 		test := test
 		t.Run(name, func(t *testing.T) {
-			_, err := New(test.input, test.providers, false, false)
+			_, err := New(test.input, WithProviders(test.providers...))
 			if err == nil {
 				t.Fatal("expected error but got nil")
 			}
@@ -104,6 +108,118 @@ func TestConfigurator_Errors(t *testing.T) {
 	}
 }
 
+func TestConfigurator_Severity(t *testing.T) {
+	cfg := struct {
+		Critical string `env:"SEVERITY_CRITICAL"`
+		Optional string `env:"SEVERITY_OPTIONAL" severity:"warn"`
+		Port     int    `env:"SEVERITY_PORT" default:"80" severity:"warn"`
+		Limit    int    `env:"SEVERITY_LIMIT" validate:"max=10" severity:"warn"`
+		Token    string `env:"SEVERITY_TOKEN" severity:"warn" required:"true"`
+	}{Limit: 5}
+
+	var logs []string
+	c, err := New(&cfg,
+		WithProviders(NewEnvProvider().WithEnv(map[string]string{
+			"SEVERITY_CRITICAL": "set",
+			"SEVERITY_PORT":     "http",
+			"SEVERITY_LIMIT":    "100",
+			"SEVERITY_TOKEN":    "token",
+		}), NewDefaultProvider()),
+		WithLogger(func(format string, v ...interface{}) { logs = append(logs, fmt.Sprintf(format, v...)) }),
+		WithLogLevel(LogErrors),
+		WithStrictCoercion(),
+	)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, "", cfg.Optional)
+	assert.Equal(t, 80, cfg.Port, "the next provider sets the value")
+	assert.Equal(t, 5, cfg.Limit, "the invalid value is not kept")
+	assert.Contains(t, logs, "configurator: field [Optional] is not critical (severity warn), keeping []")
+	assert.Contains(t, logs, "configurator: field [Limit] is not critical (severity warn), keeping [5]")
+
+	cfg.Critical, cfg.Token = "", ""
+	c, err = New(&cfg, WithProviders(NewEnvProvider().WithEnv(nil)))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+	assert.True(t, errors.Is(err, &FieldError{Path: "Critical"}))
+	assert.True(t, errors.Is(err, &FieldError{Path: "Token"}), "required fields are critical")
+	assert.False(t, errors.Is(err, &FieldError{Path: "Optional"}))
+}
+
+func TestConfigurator_Conflicts(t *testing.T) {
+	type db struct {
+		Host string `env:"DB_HOST" flag:"db_host"`
+	}
+	tests := map[string]struct {
+		input    interface{}
+		expected string
+	}{
+		"env": {
+			input: &struct {
+				Primary db
+				Replica struct {
+					Host string `env:"db_host"`
+				}
+			}{},
+			expected: "configurator: fields [Primary.Host] and [Replica.Host] use the same env variable [DB_HOST]",
+		},
+		"flag": {
+			input: &struct {
+				Primary *db
+				Replica struct {
+					Host string `flag:"db_host|localhost|replica host"`
+				}
+			}{},
+			expected: "configurator: fields [Primary.Host] and [Replica.Host] use the same flag [db_host]",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			_, err := New(test.input, WithProviders(NewDefaultProvider()))
+			assert.EqualError(t, err, test.expected)
+		})
+	}
+}
+
+type recursiveNode struct {
+	Name string `default:"node"`
+	Next *recursiveNode
+}
+
+type recursiveTree struct {
+	Name     string `default:"tree"`
+	Children map[string]recursiveTree
+}
+
+// deepKeysProvider returns the same key for every map, so map-based nesting never ends
+type deepKeysProvider struct{ defaultProvider }
+
+func (deepKeysProvider) Keys(...string) []string { return []string{"child"} }
+
+func TestConfigurator_Recursion(t *testing.T) {
+	_, err := New(&struct {
+		Root struct {
+			Node recursiveNode
+		}
+	}{}, WithProviders(NewDefaultProvider()))
+	assert.EqualError(t, err, "configurator: recursive type [configuration.recursiveNode] at [Root.Node.Next] is not supported")
+
+	var tree recursiveTree
+	c, err := New(&tree, WithProviders(deepKeysProvider{}))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds max depth")
+}
+
 func TestEmbeddedFlags(t *testing.T) {
 	type (
 		Client struct {
@@ -118,10 +234,9 @@ func TestEmbeddedFlags(t *testing.T) {
 	var cfg Config
 	c, err := New(
 		&cfg,
-		[]Provider{
-			NewFlagProvider(&cfg),
-		},
-		true, true,
+		WithProviders(NewFlagProvider(&cfg)),
+		WithLogger(log.Printf),
+		FailIfCannotSet(),
 	)
 	if err != nil {
 		t.Fatal("unexpected err: ", err)
@@ -132,6 +247,381 @@ func TestEmbeddedFlags(t *testing.T) {
 	assert.Equal(t, cfg.Client.ServerAddress, "addr_value")
 }
 
+func TestConfigurator_TextUnmarshaler(t *testing.T) {
+	cfg := struct {
+		Price    big.Rat  `default:"19.99"`
+		Discount *big.Rat `default:"0.15"`
+	}{}
+
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	assert.Equal(t, big.NewRat(1999, 100), &cfg.Price)
+	assert.Equal(t, big.NewRat(15, 100), cfg.Discount)
+}
+
+func TestConfigurator_MailAddress(t *testing.T) {
+	cfg := struct {
+		AlertsTo []mail.Address `default:"ops@example.com; Dev Team <dev@example.com>"`
+	}{}
+
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, []mail.Address{
+		{Address: "ops@example.com"},
+		{Name: "Dev Team", Address: "dev@example.com"},
+	}, cfg.AlertsTo)
+
+	invalid := struct {
+		From mail.Address `default:"broken@"`
+	}{}
+	c, err = New(&invalid, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Error(t, c.InitValues())
+}
+
+func TestConfigurator_StructMap(t *testing.T) {
+	type upstream struct {
+		Host    string
+		Port    int           `default:"443"`
+		Timeout time.Duration `default:"1s"`
+	}
+	cfg := struct {
+		Upstreams    map[string]upstream
+		UpstreamPtrs map[string]*upstream
+		Missing      map[string]upstream
+	}{}
+
+	c, err := New(&cfg, WithProviders(
+		NewFileProvider("./testdata/upstreams.yml"),
+		NewDefaultProvider(),
+	))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	assert.Equal(t, map[string]upstream{
+		"api": {Host: "api.internal", Port: 443, Timeout: 2 * time.Second},
+		"Web": {Host: "web.internal", Port: 443, Timeout: time.Second},
+	}, cfg.Upstreams)
+	assert.Equal(t, map[string]*upstream{
+		"db": {Host: "db.internal", Port: 5432, Timeout: time.Second},
+	}, cfg.UpstreamPtrs)
+	assert.Nil(t, cfg.Missing)
+}
+
+func TestConfigurator_SQLNullTypes(t *testing.T) {
+	cfg := struct {
+		Name    sql.NullString `default:"db_name"`
+		MaxConn sql.NullInt32  `default:"10"`
+		Unset   sql.NullString
+	}{}
+
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	_ = c.InitValues() // `Unset` cannot be set
+
+	assert.Equal(t, sql.NullString{String: "db_name", Valid: true}, cfg.Name)
+	assert.Equal(t, sql.NullInt32{Int32: 10, Valid: true}, cfg.MaxConn)
+	assert.Equal(t, sql.NullString{}, cfg.Unset)
+}
+
+// protoDatabase and protoConfig mimic structs generated by protoc-gen-go
+type (
+	protoDatabase struct {
+		state         struct{}
+		sizeCache     int32
+		unknownFields []byte
+
+		MaxConns int32 `protobuf:"varint,1,opt,name=max_conns,json=maxConns,proto3" json:"max_conns,omitempty"`
+	}
+	protoConfig struct {
+		state         struct{}
+		sizeCache     int32
+		unknownFields []byte
+
+		ServiceName string         `protobuf:"bytes,1,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+		Database    *protoDatabase `protobuf:"bytes,2,opt,name=database,proto3" json:"database,omitempty"`
+
+		XXX_NoUnkeyedLiteral struct{} `json:"-"`
+		XXX_unrecognized     []byte   `json:"-"`
+		XXX_sizecache        int32    `json:"-"`
+	}
+)
+
+func TestConfigurator_Protobuf(t *testing.T) {
+	var cfg protoConfig
+
+	c, err := New(&cfg, WithProviders(NewFileProvider("./testdata/proto.json")))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	assert.Equal(t, "billing", cfg.ServiceName)
+	assert.NotNil(t, cfg.Database)
+	assert.Equal(t, int32(20), cfg.Database.MaxConns)
+
+	skipped := struct {
+		Name    string `default:"name"`
+		Ignored string `json:"-" default:"ignored"`
+	}{}
+	c, err = New(&skipped, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, "name", skipped.Name)
+	assert.Empty(t, skipped.Ignored, "fields tagged json:\"-\" are skipped")
+}
+
+func TestConfigurator_Sources(t *testing.T) {
+	os.Args = []string{"smth", "-sources_name=flag_value"}
+	cfg := struct {
+		Name     string `flag:"sources_name" default:"default_name"`
+		LastName string `default:"default_last_name"`
+		Obj      struct {
+			Value int `json:"value" default:"1"`
+		}
+	}{}
+
+	c, err := New(&cfg, WithProviders(NewFlagProvider(&cfg), NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	assert.Equal(t, map[string]string{
+		"Name":      "flagProvider",
+		"LastName":  "defaultProvider",
+		"Obj.value": "defaultProvider",
+	}, c.Sources())
+}
+
+// slowProvider waits for the context (or `delay`) before setting defaults
+type slowProvider struct {
+	delay time.Duration
+}
+
+func (p slowProvider) Provide(field reflect.StructField, v reflect.Value, path ...string) bool {
+	return NewDefaultProvider().Provide(field, v, path...)
+}
+
+func (p slowProvider) ProvideContext(ctx context.Context, field reflect.StructField, v reflect.Value, path ...string) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(p.delay):
+		return p.Provide(field, v, path...)
+	}
+}
+
+func TestConfigurator_InitValuesContext(t *testing.T) {
+	cfg := struct {
+		Name string `default:"name"`
+		Obj  struct {
+			Value int `default:"1"`
+		}
+	}{}
+
+	c, err := New(&cfg, WithProviders(slowProvider{delay: time.Millisecond}))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValuesContext(context.Background()))
+	assert.Equal(t, "name", cfg.Name)
+	assert.Equal(t, 1, cfg.Obj.Value)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.EqualError(t, c.InitValuesContext(ctx), "configurator: field [Name]: context canceled")
+
+	c, err = New(&cfg, WithProviders(slowProvider{delay: time.Hour}, NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.EqualError(t, c.ReloadContext(ctx), "configurator: field [Name]: context deadline exceeded")
+}
+
+func TestConfigurator_Concurrent(t *testing.T) {
+	cfg := struct {
+		Name  string   `default:"name"`
+		Hosts []string `default:"a;b"`
+		Obj   struct {
+			Value int `default:"1"`
+		}
+	}{}
+
+	overrides := NewOverrideProvider()
+	c, err := New(&cfg, WithProviders(overrides, NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	c.KeepSnapshots(3)
+	assert.NoError(t, c.InitValues())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				switch (i + j) % 6 {
+				case 0:
+					assert.NoError(t, c.Reload())
+				case 1:
+					assert.NoError(t, c.Set("Obj.Value", strconv.Itoa(j)))
+				case 2:
+					_, ok := c.Get("hosts")
+					assert.True(t, ok)
+				case 3:
+					assert.Len(t, c.Explain(), 3)
+				case 4:
+					assert.Len(t, c.Sources(), 3)
+				case 5:
+					assert.NotEmpty(t, c.Snapshots())
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	val, ok := c.Get("obj.value")
+	assert.True(t, ok)
+	assert.Equal(t, cfg.Obj.Value, val)
+
+	_, ok = c.Get("Unknown")
+	assert.False(t, ok)
+}
+
+func TestConfigurator_ConcurrentInstances(t *testing.T) {
+	type config struct {
+		Name  string `default:"name" def:"renamed"`
+		Debug bool   `default:"1"`
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			var (
+				cfg  config
+				logs int
+				opts = []Option{WithProviders(NewDefaultProvider()), AllowUnset()}
+			)
+			if i%2 == 0 { // settings of one configurator must not leak into the others
+				opts = append(opts, WithTagName(TagDefault, "def"), WithStrictCoercion(),
+					WithLogger(func(string, ...interface{}) { logs++ }))
+			}
+			c, err := New(&cfg, opts...)
+			if err != nil {
+				t.Error("unexpected err: ", err)
+				return
+			}
+			for j := 0; j < 10; j++ {
+				assert.NoError(t, c.InitValues())
+				assert.Len(t, c.Explain(), 2)
+			}
+
+			if i%2 == 0 {
+				assert.Equal(t, config{Name: "renamed"}, cfg)
+				assert.NotZero(t, logs)
+			} else {
+				assert.Equal(t, config{Name: "name", Debug: true}, cfg)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestConfigurator_IndependentLocks(t *testing.T) {
+	hung := struct {
+		Name string `default:"name"`
+	}{}
+	slow, err := New(&hung, WithProviders(slowProvider{delay: time.Hour}))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = slow.InitValuesContext(ctx) }()
+
+	cfg := struct {
+		Name string `default:"name"`
+	}{}
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- c.InitValues() }()
+	select {
+	case err := <-done: // the hung provider of the other configurator doesn't block this one
+		assert.NoError(t, err)
+		assert.Equal(t, "name", cfg.Name)
+	case <-time.After(time.Second):
+		t.Fatal("InitValues is blocked by another configurator")
+	}
+}
+
+func TestConfigurator_Set(t *testing.T) {
+	cfg := struct {
+		Server struct {
+			Port int         `json:"port" default:"80"`
+			Mode os.FileMode `default:"0644"`
+		}
+	}{}
+	overrides := NewOverrideProvider()
+
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.EqualError(t, c.Set("Server.port", "8080"), "configurator: writable provider not found")
+
+	c, err = New(&cfg, WithProviders(overrides, NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	assert.NoError(t, c.Set("Server.port", "8080"))
+	assert.Equal(t, 8080, cfg.Server.Port)
+	assert.Equal(t, map[string]string{"Server.port": "8080"}, overrides.Values())
+	assert.Equal(t, "overrideProvider", c.Sources()["Server.port"])
+
+	assert.Error(t, c.Set("Server.NotFound", "1"))
+	assert.Error(t, c.Set("Server.Mode", "not_a_mode"))
+	assert.Equal(t, os.FileMode(0644), cfg.Server.Mode)
+
+	// the path of the field is used whatever case the caller typed
+	assert.NoError(t, c.Set("server.mode", "0600"))
+	assert.Equal(t, os.FileMode(0600), cfg.Server.Mode)
+	assert.Equal(t, map[string]string{"Server.port": "8080", "Server.Mode": "0600"}, overrides.Values())
+	assert.Equal(t, "overrideProvider", c.Sources()["Server.Mode"])
+
+	// persisted value survives the next InitValues
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, 8080, cfg.Server.Port)
+}
+
 func TestSetLogger(t *testing.T) {
 	var (
 		cfg = struct {
@@ -147,7 +637,7 @@ func TestSetLogger(t *testing.T) {
 		}
 	)
 
-	c, err := New(&cfg, []Provider{NewDefaultProvider()}, true, true)
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()), WithLogger(log.Printf), FailIfCannotSet())
 	if err != nil {
 		t.Fatal("unexpected err: ", err)
 	}
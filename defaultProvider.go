@@ -1,24 +1,162 @@
 package configuration
 
 import (
+	"context"
+	"fmt"
 	"reflect"
+	"strings"
 )
 
-// NewDefaultProvider creates new provider which sets values from `default` tag
+// NewDefaultProvider creates new provider which sets values from `default` tag.
+// Numbers and durations can be randomized to avoid synchronized refreshes of identically configured instances:
+// `default:"30s±10%"` (or `30s+-10%`) sets a random value from 27s to 33s (chosen once per configurator and field).
+// Tags of runtimes take priority over `default` in the runtime detected by DetectRuntime (see WithRuntime):
+// `default:"json" default_dev:"console"`.
 func NewDefaultProvider() defaultProvider {
 	return defaultProvider{}
 }
 
-type defaultProvider struct{}
+type defaultProvider struct {
+	defaults map[string]reflect.Value // registered with WithDefaults for `defaultFrom` tag
+	runtime  string                   // DetectRuntime() if empty
+	opts     *options                 // of the configurator (see optionsBinder)
+}
+
+func (dp defaultProvider) withOptions(o *options) Provider {
+	dp.opts = o
+	return dp
+}
+
+// WithRuntime makes provider use `default_<name>` tags instead of the ones of the detected runtime,
+// e.g. in tests or for runtimes which cannot be detected: WithRuntime("staging") -> `default_staging`
+func (dp defaultProvider) WithRuntime(name string) defaultProvider {
+	dp.runtime = name
+	return dp
+}
 
-func (defaultProvider) Provide(field reflect.StructField, v reflect.Value, _ ...string) bool {
-	valStr := getDefaultTag(field)
+// WithDefaults registers the value (usually a struct with defaults kept in Go constants) under the name,
+// so fields can refer to its fields with `defaultFrom` tag instead of duplicating them into `default` tags:
+//
+//	NewDefaultProvider().WithDefaults("Defaults", defaults) // Port int `defaultFrom:"Defaults.Server.Port"`
+//
+// Values are set as is if their types match the fields and converted from their string form otherwise.
+// `default` tag has priority over `defaultFrom`.
+func (dp defaultProvider) WithDefaults(name string, value interface{}) defaultProvider {
+	defaults := make(map[string]reflect.Value, len(dp.defaults)+1)
+	for k, v := range dp.defaults {
+		defaults[k] = v
+	}
+	defaults[name] = reflect.ValueOf(value)
+	dp.defaults = defaults
+	return dp
+}
+
+func (dp defaultProvider) Provide(field reflect.StructField, v reflect.Value, path ...string) bool {
+	ok, _ := dp.ProvideError(context.Background(), field, v, path...)
+	return ok
+}
+
+// ProvideError returns the error if the value of the tag cannot be parsed
+func (dp defaultProvider) ProvideError(_ context.Context, field reflect.StructField, v reflect.Value, path ...string) (bool, error) {
+	valStr := dp.defaultTag(field)
 	if len(valStr) == 0 {
-		logf("defaultProvider: getDefaultTag returns empty value")
-		return false
+		if ref := getDefaultFromTag(field); ref != "" {
+			return dp.provideFrom(field, v, ref)
+		}
+		dp.opts.logf("defaultProvider: getDefaultTag returns empty value")
+		return false, nil
+	}
+
+	base, ratio, jittered := parseJitter(field.Type, valStr)
+	if err := setField(field, v, base, dp.opts.strict()); err != nil {
+		dp.opts.errorf("defaultProvider: %v", err)
+		return false, parseError(err)
+	}
+	if jittered {
+		if err := applyJitter(v, dp.opts.jitterFactor(path, ratio)); err != nil {
+			dp.opts.errorf("defaultProvider: %v", err)
+			return false, parseError(err)
+		}
 	}
+	dp.opts.logf("defaultProvider: set [%v] to field [%s] with tags [%v]", logValue(field, valStr), field.Name, field.Tag)
+	return true, nil
+}
 
-	SetField(field, v, valStr)
-	logf("defaultProvider: set [%s] to field [%s] with tags [%v]", valStr, field.Name, field.Tag)
-	return true
+// defaultTag returns the value of the tag of the runtime or of `default` tag
+func (dp defaultProvider) defaultTag(field reflect.StructField) string {
+	runtime := dp.runtime
+	if runtime == "" {
+		runtime = DetectRuntime()
+	}
+	if val := getRuntimeDefaultTag(field, runtime, dp.opts.tags()); val != "" {
+		return val
+	}
+	return getDefaultTag(field, dp.opts.tags())
+}
+
+// provideFrom sets the value referred by `defaultFrom` tag: `Defaults.Server.Port`
+func (dp defaultProvider) provideFrom(field reflect.StructField, v reflect.Value, ref string) (bool, error) {
+	val, err := dp.lookupDefault(ref)
+	if err != nil {
+		dp.opts.errorf("defaultProvider: %v", err)
+		return false, err
+	}
+
+	if val.Kind() == reflect.Ptr && !val.Type().AssignableTo(field.Type) {
+		val = val.Elem()
+	}
+	if val.Type().AssignableTo(field.Type) {
+		v.Set(deepCopy(val))
+		dp.opts.logf("defaultProvider: set [%v] from [%s] to field [%s]", logValue(field, val), ref, field.Name)
+		return true, nil
+	}
+
+	valStr := fmt.Sprint(val.Interface())
+	if err := setField(field, v, valStr, dp.opts.strict()); err != nil {
+		dp.opts.errorf("defaultProvider: [%s]: %v", ref, err)
+		return false, parseError(err)
+	}
+	dp.opts.logf("defaultProvider: set [%v] from [%s] to field [%s]", logValue(field, valStr), ref, field.Name)
+	return true, nil
+}
+
+// lookupDefault finds the value by the name registered with WithDefaults and the path to the field
+// (names of fields or keys of maps, case-insensitive)
+func (dp defaultProvider) lookupDefault(ref string) (reflect.Value, error) {
+	parts := strings.Split(ref, pathSeparator)
+	val, ok := dp.defaults[parts[0]]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("defaults [%s] are not registered", parts[0])
+	}
+
+	for _, part := range parts[1:] {
+		for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+			val = val.Elem()
+		}
+
+		var next reflect.Value
+		switch val.Kind() {
+		case reflect.Struct:
+			next = val.FieldByNameFunc(func(name string) bool { return strings.EqualFold(name, part) })
+		case reflect.Map:
+			if val.Type().Key().Kind() == reflect.String {
+				next = val.MapIndex(reflect.ValueOf(part).Convert(val.Type().Key()))
+			}
+		}
+		if !next.IsValid() {
+			return reflect.Value{}, fmt.Errorf("[%s] not found in defaults [%s]", part, ref)
+		}
+		val = next
+	}
+
+	for val.Kind() == reflect.Interface && !val.IsNil() {
+		val = val.Elem()
+	}
+	switch {
+	case !val.IsValid(), (val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface) && val.IsNil():
+		return reflect.Value{}, fmt.Errorf("defaults [%s] are nil", ref)
+	case !val.CanInterface():
+		return reflect.Value{}, fmt.Errorf("defaults [%s] are not exported", ref)
+	}
+	return val, nil
 }
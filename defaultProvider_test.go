@@ -1,8 +1,10 @@
 package configuration
 
 import (
+	"context"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestDefaultProvider(t *testing.T) {
@@ -41,3 +43,66 @@ func TestDefaultProviderFailed(t *testing.T) {
 		t.Fatal("must be false")
 	}
 }
+
+type testDefaults struct {
+	Server struct {
+		Port    int
+		Timeout time.Duration
+	}
+	Hosts   []string
+	Labels  map[string]interface{}
+	private string
+}
+
+func TestDefaultProvider_WithDefaults(t *testing.T) {
+	defaults := testDefaults{Hosts: []string{"a", "b"}, Labels: map[string]interface{}{"team": "core", "replicas": 3}}
+	defaults.Server.Port = 8080
+	defaults.Server.Timeout = 5 * time.Second
+
+	cfg := struct {
+		Port     int           `defaultFrom:"Defaults.Server.Port"`
+		Port64   int64         `defaultFrom:"Defaults.server.port"`
+		PortStr  string        `defaultFrom:"Defaults.Server.Port"`
+		Timeout  time.Duration `defaultFrom:"Defaults.Server.Timeout"`
+		Hosts    []string      `defaultFrom:"Defaults.Hosts"`
+		Team     string        `defaultFrom:"Defaults.Labels.team"`
+		Replicas uint          `defaultFrom:"Defaults.Labels.replicas"`
+		Tagged   int           `default:"1" defaultFrom:"Defaults.Server.Port"`
+	}{}
+
+	c, err := New(&cfg, WithProviders(NewDefaultProvider().WithDefaults("Defaults", &defaults)))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	if err := c.InitValues(); err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+
+	if cfg.Port != 8080 || cfg.Port64 != 8080 || cfg.PortStr != "8080" || cfg.Timeout != 5*time.Second {
+		t.Fatalf("unexpected values: %+v", cfg)
+	}
+	if !reflect.DeepEqual([]string{"a", "b"}, cfg.Hosts) || cfg.Team != "core" || cfg.Replicas != 3 || cfg.Tagged != 1 {
+		t.Fatalf("unexpected values: %+v", cfg)
+	}
+
+	cfg.Hosts[0] = "changed"
+	if defaults.Hosts[0] != "a" {
+		t.Fatal("defaults must be copied")
+	}
+}
+
+func TestDefaultProvider_WithDefaultsErrors(t *testing.T) {
+	provider := NewDefaultProvider().WithDefaults("Defaults", testDefaults{})
+
+	for _, ref := range []string{"Unknown.Port", "Defaults.Server.Missing", "Defaults.private", "Defaults.Labels.team"} {
+		testObj := struct {
+			Value string
+		}{}
+		field := reflect.StructField{Name: "Value", Type: reflect.TypeOf(""), Tag: reflect.StructTag(`defaultFrom:"` + ref + `"`)}
+
+		ok, err := provider.ProvideError(context.Background(), field, reflect.ValueOf(&testObj).Elem().Field(0))
+		if ok || err == nil {
+			t.Fatalf("[%s] must fail", ref)
+		}
+	}
+}
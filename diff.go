@@ -0,0 +1,89 @@
+package configuration
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// FieldDiff describes the field which has different values in two configurations (see Diff).
+// A field which exists only on one side (e.g. an item of `map[string]SomeStruct`) has nil value on the other one.
+type FieldDiff struct {
+	Path        string      `json:"path"`
+	Left        interface{} `json:"left"`
+	Right       interface{} `json:"right"`
+	LeftSource  string      `json:"left_source,omitempty"`
+	RightSource string      `json:"right_source,omitempty"`
+	Secret      bool        `json:"-"` // `secret:"true"` or `secret:"<mask>"`
+	Mask        string      `json:"-"` // the strategy of masking the secret (see MaskFull)
+}
+
+// MaskedLeft returns the left value or its mask if the field is secret (nil values aren't masked)
+func (d FieldDiff) MaskedLeft() interface{} {
+	return d.masked(d.Left)
+}
+
+// MaskedRight returns the right value or its mask if the field is secret (nil values aren't masked)
+func (d FieldDiff) MaskedRight() interface{} {
+	return d.masked(d.Right)
+}
+
+func (d FieldDiff) masked(val interface{}) interface{} {
+	if d.Secret && val != nil {
+		return MaskSecret(d.Mask, val)
+	}
+	return val
+}
+
+// Diff resolves the configuration against two chains of providers (e.g. the staging file and the production one)
+// and returns fields which differ, sorted by path. newCfg must return a new pointer to the configuration struct
+// for every call, opts are applied to both configurators.
+func Diff(newCfg func() interface{}, left, right []Provider, opts ...Option) ([]FieldDiff, error) {
+	leftFields, err := resolveFields(newCfg(), left, opts)
+	if err != nil {
+		return nil, fmt.Errorf("left: %v", err)
+	}
+	rightFields, err := resolveFields(newCfg(), right, opts)
+	if err != nil {
+		return nil, fmt.Errorf("right: %v", err)
+	}
+	return diffFields(leftFields, rightFields), nil
+}
+
+func resolveFields(cfgPtr interface{}, providers []Provider, opts []Option) ([]FieldValue, error) {
+	c, err := New(cfgPtr, append(opts[:len(opts):len(opts)], WithProviders(providers...))...)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.InitValues(); err != nil {
+		return nil, err
+	}
+	return c.Explain(), nil
+}
+
+func diffFields(left, right []FieldValue) []FieldDiff {
+	diffs := map[string]*FieldDiff{}
+	for _, f := range left {
+		diffs[f.Path] = &FieldDiff{Path: f.Path, Left: f.Value, LeftSource: f.Source, Secret: f.Secret, Mask: f.Mask}
+	}
+	for _, f := range right {
+		d, ok := diffs[f.Path]
+		if !ok {
+			d = &FieldDiff{Path: f.Path}
+			diffs[f.Path] = d
+		}
+		d.Right, d.RightSource, d.Secret = f.Value, f.Source, d.Secret || f.Secret
+		if d.Mask == "" {
+			d.Mask = f.Mask
+		}
+	}
+
+	var result []FieldDiff
+	for _, d := range diffs {
+		if !reflect.DeepEqual(d.Left, d.Right) {
+			result = append(result, *d)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result
+}
@@ -0,0 +1,44 @@
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	type config struct {
+		Host      string `env:"HOST" default:"localhost"`
+		Port      int    `env:"PORT" default:"80"`
+		Password  string `env:"PASSWORD" default:"" secret:"true"`
+		Upstreams map[string]struct {
+			Host string `yaml:"host"`
+		} `yaml:"upstreams"`
+	}
+	newCfg := func() interface{} { return &config{} }
+
+	staging := NewEnvProvider().WithEnv(map[string]string{
+		"HOST": "staging.internal", "PASSWORD": "s3cret",
+	})
+	prod := NewEnvProvider().WithEnv(map[string]string{
+		"HOST": "prod.internal", "PASSWORD": "s3cret",
+	})
+
+	diffs, err := Diff(newCfg,
+		[]Provider{staging, NewDefaultProvider()},
+		[]Provider{prod, NewFileProvider("./testdata/upstreams.yml"), NewDefaultProvider()},
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []FieldDiff{
+		{Path: "Host", Left: "staging.internal", Right: "prod.internal", LeftSource: "envProvider", RightSource: "envProvider"},
+		{Path: "upstreams.Web.host", Right: "web.internal", RightSource: "fileProvider"},
+		{Path: "upstreams.api.host", Right: "api.internal", RightSource: "fileProvider"},
+	}, diffs)
+
+	diffs, err = Diff(newCfg, []Provider{prod, NewDefaultProvider()}, []Provider{prod, NewDefaultProvider()})
+	assert.NoError(t, err)
+	assert.Empty(t, diffs)
+
+	_, err = Diff(newCfg, []Provider{NewDefaultProvider()}, []Provider{prod})
+	assert.Error(t, err)
+}
@@ -1,8 +1,12 @@
 package configuration
 
 import (
+	"context"
+	"net/url"
 	"os"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -11,23 +15,185 @@ func NewEnvProvider() envProvider {
 	return envProvider{}
 }
 
-type envProvider struct{}
+type envProvider struct {
+	naming  NamingStrategy // derives names of variables if not nil
+	prefix  string
+	lookup  func(key string) (string, bool) // os.LookupEnv if nil
+	environ func() []string                 // lists variables for IgnoredKeys, os.Environ if lookup is nil too
+	decode  bool                            // unquote and percent-decode values (see WithDecoding)
+	opts    *options                        // of the configurator (see optionsBinder)
+}
+
+func (ep envProvider) withOptions(o *options) Provider {
+	ep.opts = o
+	return ep
+}
+
+// WithDerivedNames makes provider derive names of variables for fields without `env` tag from the path
+// to the field (keys come from `json`/`yaml` tags or names of fields): `Database.MaxConns` -> `DATABASE_MAX_CONNS`
+func (ep envProvider) WithDerivedNames() envProvider {
+	return ep.WithNaming(ScreamingSnakeCase)
+}
+
+// WithNaming is the same as WithDerivedNames but uses the given naming strategy for derived names
+func (ep envProvider) WithNaming(naming NamingStrategy) envProvider {
+	ep.naming = naming
+	return ep
+}
+
+// WithPrefix makes provider look for variables with the given prefix: WithPrefix("MYAPP") + `HOST` -> `MYAPP_HOST`
+func (ep envProvider) WithPrefix(prefix string) envProvider {
+	ep.prefix = strings.ToUpper(strings.TrimSuffix(prefix, "_"))
+	return ep
+}
+
+// WithLookup makes provider read variables with the given function instead of os.LookupEnv
+func (ep envProvider) WithLookup(lookup func(key string) (string, bool)) envProvider {
+	ep.lookup = lookup
+	return ep
+}
 
-func (envProvider) Provide(field reflect.StructField, v reflect.Value, _ ...string) bool {
-	key := getEnvTag(field)
+// WithDecoding makes provider decode values which some orchestration tools emit quoted or URL-encoded:
+// `"a b\nc"` (Go/JSON string escapes), `'a b'` and `a%20b` are set as `a b`. Other values are set as is,
+// as well as values with `%` which aren't valid escapes (e.g. `50%`).
+func (ep envProvider) WithDecoding() envProvider {
+	ep.decode = true
+	return ep
+}
+
+// WithEnv makes provider read variables from the map instead of the process environment,
+// so tests don't have to change the environment and can run in parallel:
+//
+//	NewEnvProvider().WithEnv(map[string]string{"DB_HOST": "localhost"})
+func (ep envProvider) WithEnv(env map[string]string) envProvider {
+	ep = ep.WithLookup(func(key string) (string, bool) {
+		val, ok := env[key]
+		return val, ok
+	})
+	ep.environ = func() []string {
+		vars := make([]string, 0, len(env))
+		for k, v := range env {
+			vars = append(vars, k+"="+v)
+		}
+		return vars
+	}
+	return ep
+}
+
+func (ep envProvider) Provide(field reflect.StructField, v reflect.Value, path ...string) bool {
+	ok, _ := ep.ProvideError(context.Background(), field, v, path...)
+	return ok
+}
+
+// ProvideError returns the error if the value of the variable cannot be parsed
+func (ep envProvider) ProvideError(_ context.Context, field reflect.StructField, v reflect.Value, path ...string) (bool, error) {
+	key := ep.key(field, path)
 	if len(key) == 0 {
 		// field doesn't have a proper tag
-		logf("envProvider: key is empty")
-		return false
+		ep.opts.logf("envProvider: key is empty")
+		return false, nil
 	}
 
-	valStr, ok := os.LookupEnv(strings.ToUpper(key))
+	lookup := ep.lookup
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+	valStr, ok := lookup(key)
+	for _, oldKey := range keyAliasesOf(key) {
+		if ok && len(valStr) > 0 {
+			break
+		}
+		if valStr, ok = lookup(oldKey); ok && len(valStr) > 0 {
+			ep.opts.errorf("envProvider: variable [%s] is deprecated, use [%s]", oldKey, key)
+		}
+	}
 	if !ok || len(valStr) == 0 {
-		logf("envProvider: os.LookupEnv returns empty value")
-		return false
+		ep.opts.logf("envProvider: variable [%s] is not set", key)
+		return false, nil
+	}
+
+	if ep.decode {
+		valStr = decodeEnvValue(valStr)
 	}
 
-	SetField(field, v, valStr)
-	logf("envProvider: set [%s] to field [%s] with tags [%v]", valStr, field.Name, field.Tag)
-	return true
+	if err := setField(field, v, valStr, ep.opts.strict()); err != nil {
+		ep.opts.errorf("envProvider: %v", err)
+		return false, parseError(err)
+	}
+	ep.opts.logf("envProvider: set [%v] to field [%s] with tags [%v]", logValue(field, valStr), field.Name, field.Tag)
+	return true, nil
+}
+
+// DescribeKey returns the name of the variable for the field
+func (ep envProvider) DescribeKey(field reflect.StructField, path ...string) string {
+	return ep.key(field, path)
+}
+
+// IgnoredKeys returns names of variables with the prefix (see WithPrefix) which don't match any field
+// (see WithIgnoredKeysReport). Without the prefix variables of the process can't be told from the ones of the app,
+// so nothing is reported, as well as with WithLookup.
+func (ep envProvider) IgnoredKeys(cfgType reflect.Type) (source string, keys []string) {
+	source = "env " + ep.prefix + "_*"
+	environ := ep.environ
+	if environ == nil && ep.lookup == nil {
+		environ = os.Environ
+	}
+	if ep.prefix == "" || environ == nil {
+		return source, nil
+	}
+
+	known := map[string]bool{}
+	walkTypePaths(cfgType, nil, ep.opts.tags(), func(path []string, field reflect.StructField) {
+		if key := ep.key(field, path); key != "" {
+			known[key] = true
+			for _, oldKey := range keyAliasesOf(key) {
+				known[oldKey] = true
+			}
+		}
+	})
+
+	for _, kv := range environ() {
+		key := strings.SplitN(kv, "=", 2)[0]
+		if strings.HasPrefix(key, ep.prefix+"_") && !known[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return source, keys
+}
+
+// key returns the name of the variable from `env` tag or derived from the path, with the prefix
+func (ep envProvider) key(field reflect.StructField, path []string) string {
+	key := strings.ToUpper(getEnvTag(field, ep.opts.tags()))
+	if len(key) == 0 && ep.naming != nil && len(path) > 0 {
+		key = ep.naming(path...)
+	}
+	if len(key) == 0 {
+		return ""
+	}
+	if ep.prefix != "" {
+		key = ep.prefix + "_" + key
+	}
+	return key
+}
+
+// decodeEnvValue unquotes or percent-decodes the value (see WithDecoding)
+func decodeEnvValue(val string) string {
+	if len(val) >= 2 {
+		switch first, last := val[0], val[len(val)-1]; {
+		case first == '"' && last == '"':
+			if s, err := strconv.Unquote(val); err == nil {
+				return s
+			}
+		case first == '\'' && last == '\'':
+			return val[1 : len(val)-1]
+		}
+	}
+
+	if strings.Contains(val, "%") {
+		if s, err := url.PathUnescape(val); err == nil {
+			return s
+		}
+	}
+	return val
 }
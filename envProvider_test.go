@@ -49,8 +49,188 @@ func TestEnvProviderFailed(t *testing.T) {
 	}
 }
 
+func TestEnvProvider_DerivedNames(t *testing.T) {
+	type testStruct struct {
+		MaxConns int `yaml:"max_conns"`
+		Tagged   int `env:"TAGGED_KEY"`
+	}
+	testObj := testStruct{}
+
+	var (
+		objType = reflect.TypeOf(&testObj).Elem()
+		objVal  = reflect.ValueOf(&testObj).Elem()
+	)
+
+	removeEnvKey, err := setEnv("DATABASE_MAX_CONNS", "20")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	defer removeEnvKey()
+
+	if NewEnvProvider().Provide(objType.Field(0), objVal.Field(0), "Database", "max_conns") {
+		t.Fatal("must be false without WithDerivedNames")
+	}
+
+	provider := NewEnvProvider().WithDerivedNames()
+	if !provider.Provide(objType.Field(0), objVal.Field(0), "Database", "max_conns") {
+		t.Fatal("cannot set value")
+	}
+	if provider.Provide(objType.Field(1), objVal.Field(1), "Database", "max_conns") {
+		t.Fatal("must be false: `env` tag has priority")
+	}
+
+	if testObj.MaxConns != 20 {
+		t.Fatalf("\nexpected result: [%d] \nbut got: [%d]", 20, testObj.MaxConns)
+	}
+}
+
+func TestEnvProvider_Naming(t *testing.T) {
+	type testStruct struct {
+		MaxConns int
+	}
+	testObj := testStruct{}
+
+	removeEnvKey, err := setEnv("database_max_conns", "30")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	defer removeEnvKey()
+
+	var (
+		fieldType = reflect.TypeOf(&testObj).Elem().Field(0)
+		fieldVal  = reflect.ValueOf(&testObj).Elem().Field(0)
+	)
+
+	if NewEnvProvider().WithDerivedNames().Provide(fieldType, fieldVal, "Database", "MaxConns") {
+		t.Fatal("must be false: DATABASE_MAX_CONNS is not set")
+	}
+	if !NewEnvProvider().WithNaming(SnakeCase).Provide(fieldType, fieldVal, "Database", "MaxConns") {
+		t.Fatal("cannot set value")
+	}
+	if testObj.MaxConns != 30 {
+		t.Fatalf("\nexpected result: [%d] \nbut got: [%d]", 30, testObj.MaxConns)
+	}
+}
+
 func setEnv(key, val string) (func(), error) {
 	return func() {
 		_ = os.Unsetenv(key)
 	}, os.Setenv(key, val)
 }
+
+func TestEnvProvider_WithPrefix(t *testing.T) {
+	type testStruct struct {
+		Host string `env:"HOST"`
+	}
+	testObj := testStruct{}
+
+	fieldType := reflect.TypeOf(&testObj).Elem().Field(0)
+	fieldVal := reflect.ValueOf(&testObj).Elem().Field(0)
+
+	removeEnvKey, err := setEnv("MYAPP_HOST", "example.com")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	defer removeEnvKey()
+
+	if NewEnvProvider().Provide(fieldType, fieldVal) {
+		t.Fatal("must be false without prefix")
+	}
+	if !NewEnvProvider().WithPrefix("myapp_").Provide(fieldType, fieldVal) {
+		t.Fatal("cannot set value")
+	}
+	if testObj.Host != "example.com" {
+		t.Fatalf("\nexpected result: [%s] \nbut got: [%s]", "example.com", testObj.Host)
+	}
+}
+
+func TestEnvProvider_WithEnv(t *testing.T) {
+	type testStruct struct {
+		Host string `env:"HOST"`
+		Port int
+	}
+
+	tests := map[string]struct {
+		provider envProvider
+		expected testStruct
+	}{
+		"map": {
+			provider: NewEnvProvider().WithEnv(map[string]string{"HOST": "map.example.com"}),
+			expected: testStruct{Host: "map.example.com"},
+		},
+		"map with prefix and derived names": {
+			provider: NewEnvProvider().WithPrefix("APP").WithDerivedNames().
+				WithEnv(map[string]string{"APP_HOST": "prefixed.example.com", "APP_PORT": "8080", "HOST": "ignored"}),
+			expected: testStruct{Host: "prefixed.example.com", Port: 8080},
+		},
+		"lookup": {
+			provider: NewEnvProvider().WithLookup(func(key string) (string, bool) {
+				return "lookup.example.com", key == "HOST"
+			}),
+			expected: testStruct{Host: "lookup.example.com"},
+		},
+		"empty map": {
+			provider: NewEnvProvider().WithEnv(nil),
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				testObj  testStruct
+				testType = reflect.TypeOf(&testObj).Elem()
+				testVal  = reflect.ValueOf(&testObj).Elem()
+			)
+			for i := 0; i < testType.NumField(); i++ {
+				test.provider.Provide(testType.Field(i), testVal.Field(i), testType.Field(i).Name)
+			}
+			if testObj != test.expected {
+				t.Fatalf("\nexpected result: [%+v] \nbut got: [%+v]", test.expected, testObj)
+			}
+		})
+	}
+}
+
+func TestEnvProvider_WithDecoding(t *testing.T) {
+	tests := map[string]struct {
+		value    string
+		expected string
+	}{
+		"double quoted":  {value: `"a b\nc"`, expected: "a b\nc"},
+		"single quoted":  {value: `'a "b"'`, expected: `a "b"`},
+		"percent":        {value: "a%20b%0Ac", expected: "a b\nc"},
+		"plus is kept":   {value: "a+b", expected: "a+b"},
+		"invalid escape": {value: "50%", expected: "50%"},
+		"bad quotes":     {value: `"a"b"`, expected: `"a"b"`},
+		"single quote":   {value: `"`, expected: `"`},
+		"plain":          {value: "plain", expected: "plain"},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			var testObj struct {
+				Value string `env:"VALUE"`
+			}
+			provider := NewEnvProvider().WithDecoding().WithEnv(map[string]string{"VALUE": test.value})
+			if !provider.Provide(reflect.TypeOf(&testObj).Elem().Field(0), reflect.ValueOf(&testObj).Elem().Field(0)) {
+				t.Fatal("cannot set value")
+			}
+			if testObj.Value != test.expected {
+				t.Fatalf("\nexpected result: [%q] \nbut got: [%q]", test.expected, testObj.Value)
+			}
+		})
+	}
+
+	var testObj struct {
+		Value string `env:"VALUE"`
+	}
+	NewEnvProvider().WithEnv(map[string]string{"VALUE": "a%20b"}).
+		Provide(reflect.TypeOf(&testObj).Elem().Field(0), reflect.ValueOf(&testObj).Elem().Field(0))
+	if testObj.Value != "a%20b" {
+		t.Fatalf("values must not be decoded by default, got: [%q]", testObj.Value)
+	}
+}
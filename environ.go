@@ -0,0 +1,123 @@
+package configuration
+
+import (
+	"database/sql/driver"
+	"encoding"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	sqlValuerType     = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+// Environ converts the configuration object into `KEY=VALUE` pairs (sorted by keys) for exec.Cmd.Env.
+// Variables are named the same way as the provider looks them up (`env` tags, WithNaming, WithPrefix), so
+// child processes which read them with the same provider get the effective configuration of the parent:
+//
+//	env, err := NewEnvProvider().WithPrefix("APP").WithDerivedNames().Environ(&cfg)
+//	cmd.Env = append(os.Environ(), env...)
+//
+// Values are formatted the way SetField parses them (`a;b` for slices). Fields without names, nil pointers and
+// empty values (the provider treats them as unset) are skipped. Values of secrets are included.
+func (ep envProvider) Environ(cfgPtr interface{}) ([]string, error) {
+	v := reflect.ValueOf(cfgPtr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("not a pointer to the struct")
+	}
+
+	var (
+		env []string
+		err error
+	)
+	walkFieldPaths(v, nil, ep.opts.tags(), func(path []string, field reflect.StructField, v reflect.Value) {
+		key := ep.key(field, path)
+		if err != nil || key == "" {
+			return
+		}
+
+		val, e := envValue(field, v)
+		if e != nil {
+			err = fmt.Errorf("field [%s]: %v", strings.Join(path, pathSeparator), e)
+			return
+		}
+		if val != "" {
+			env = append(env, key+"="+val)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(env)
+	return env, nil
+}
+
+// envValue formats the value of the field so SetField can parse it back
+func envValue(field reflect.StructField, v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	t := v.Type()
+
+	switch {
+	case t == mailAddressType:
+		addr := v.Interface().(mail.Address)
+		return addr.String(), nil
+
+	case t.Implements(textMarshalerType):
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+
+	case reflect.PtrTo(t).Implements(textMarshalerType):
+		ptr := reflect.New(t)
+		ptr.Elem().Set(v)
+		b, err := ptr.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+
+	case isSQLScanner(t) && t.Implements(sqlValuerType):
+		val, err := v.Interface().(driver.Valuer).Value()
+		if tm, ok := val.(time.Time); ok {
+			return tm.Format(time.RFC3339), err
+		}
+		if err != nil || val == nil {
+			return "", err
+		}
+		return fmt.Sprint(val), nil
+
+	case t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8:
+		b := make([]byte, t.Len())
+		reflect.Copy(reflect.ValueOf(b), v)
+		return hex.EncodeToString(b), nil
+
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		items := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			item, err := envValue(reflect.StructField{Type: t.Elem()}, v.Index(i))
+			if err != nil {
+				return "", err
+			}
+			items = append(items, item)
+		}
+		return strings.Join(items, sliceSeparator), nil
+
+	case t.Kind() == reflect.Map && v.Len() > 0:
+		return "", fmt.Errorf("values of type %v cannot be set from env variables", t)
+
+	case t.Kind() == reflect.Map:
+		return "", nil
+
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64 && getFormatTag(field) == FormatDuration:
+		return formatDuration(time.Duration(v.Int())), nil
+	}
+	return formatDefault(field, fmt.Sprint(v.Interface())), nil
+}
@@ -0,0 +1,127 @@
+package configuration
+
+import (
+	"database/sql"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type environConfig struct {
+	Host     string        `env:"HOST"`
+	Port     int           `env:"PORT"`
+	Timeout  time.Duration `env:"TIMEOUT"`
+	Interval int64         `format:"duration"`
+	MaxBody  uint64        `format:"bytes"`
+	Tags     []string
+	Ratio    *float64
+	Unset    *string
+	Empty    string
+	Debug    bool
+	IP       net.IP
+	Key      [4]byte
+	Admin    mail.Address
+	Since    time.Time
+	Name     sql.NullString
+	Password string `secret:"true"`
+	Database struct {
+		MaxConns int `json:"max_conns"`
+	}
+}
+
+func TestEnvProvider_Environ(t *testing.T) {
+	ratio := 0.5
+	cfg := environConfig{
+		Host:     "localhost",
+		Port:     8080,
+		Timeout:  90 * time.Second,
+		Interval: int64(5 * time.Minute),
+		MaxBody:  64 << 20,
+		Tags:     []string{"a", "b"},
+		Ratio:    &ratio,
+		Debug:    true,
+		IP:       net.ParseIP("10.0.0.1"),
+		Key:      [4]byte{0xde, 0xad, 0xbe, 0xef},
+		Admin:    mail.Address{Name: "Admin", Address: "admin@example.com"},
+		Since:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Name:     sql.NullString{String: "app", Valid: true},
+		Password: "s3cret",
+	}
+	cfg.Database.MaxConns = 10
+
+	ep := NewEnvProvider().WithPrefix("APP").WithDerivedNames()
+	env, err := ep.Environ(&cfg)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Equal(t, []string{
+		`APP_ADMIN="Admin" <admin@example.com>`,
+		"APP_DATABASE_MAX_CONNS=10",
+		"APP_DEBUG=true",
+		"APP_HOST=localhost",
+		"APP_INTERVAL=5m",
+		"APP_IP=10.0.0.1",
+		"APP_KEY=deadbeef",
+		"APP_MAX_BODY=64MiB",
+		"APP_NAME=app",
+		"APP_PASSWORD=s3cret",
+		"APP_PORT=8080",
+		"APP_RATIO=0.5",
+		"APP_SINCE=2024-01-02T03:04:05Z",
+		"APP_TAGS=a;b",
+		"APP_TIMEOUT=1m30s",
+	}, env)
+
+	// the child process reads the same configuration
+	vars := map[string]string{}
+	for _, kv := range env {
+		parts := strings.SplitN(kv, "=", 2)
+		vars[parts[0]] = parts[1]
+	}
+	var child environConfig
+	c, err := New(&child, WithProviders(ep.WithEnv(vars)), AllowUnset())
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, cfg, child)
+}
+
+func TestEnvProvider_Environ_Errors(t *testing.T) {
+	_, err := NewEnvProvider().Environ(environConfig{})
+	assert.EqualError(t, err, "not a pointer to the struct")
+
+	cfg := struct {
+		Labels map[string]string `env:"LABELS"`
+		Host   string
+	}{Labels: map[string]string{"a": "b"}, Host: "localhost"}
+	_, err = NewEnvProvider().Environ(&cfg)
+	assert.EqualError(t, err, "field [Labels]: values of type map[string]string cannot be set from env variables")
+
+	cfg.Labels = nil
+	env, err := NewEnvProvider().Environ(&cfg)
+	assert.NoError(t, err)
+	assert.Empty(t, env, "fields without names are skipped")
+}
+
+func TestEnvProvider_Environ_MapKeys(t *testing.T) {
+	cfg := struct {
+		Tenants map[string]struct {
+			Token string
+		}
+	}{}
+	cfg.Tenants = map[string]struct{ Token string }{"eu.west": {Token: "t"}}
+
+	ep := NewEnvProvider().WithNaming(func(path ...string) string {
+		return strings.ToUpper(strings.Join(path, "__"))
+	})
+	env, err := ep.Environ(&cfg)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Equal(t, []string{"TENANTS__EU.WEST__TOKEN=t"}, env, "keys with `.` are kept as is")
+}
@@ -0,0 +1,30 @@
+package configuration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Environments maps names of environments to functions which create their chains of providers (see WithEnvironment).
+// Providers are created only for the selected environment, so e.g. files of production aren't read in development.
+type Environments map[string]func() ([]Provider, error)
+
+// providers creates the chain of providers of the environment
+func (e Environments) providers(name string) ([]Provider, error) {
+	newProviders, ok := e[name]
+	if !ok {
+		names := make([]string, 0, len(e))
+		for n := range e {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown environment [%s], expected one of [%s]", name, strings.Join(names, ", "))
+	}
+
+	providers, err := newProviders()
+	if err != nil {
+		return nil, fmt.Errorf("environment [%s]: %v", name, err)
+	}
+	return providers, nil
+}
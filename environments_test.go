@@ -0,0 +1,65 @@
+package configuration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithEnvironment(t *testing.T) {
+	type config struct {
+		Host string `env:"HOST" default:"localhost"`
+	}
+
+	created := map[string]bool{}
+	environments := Environments{
+		"dev": func() ([]Provider, error) {
+			created["dev"] = true
+			return []Provider{NewDefaultProvider()}, nil
+		},
+		"prod": func() ([]Provider, error) {
+			created["prod"] = true
+			return []Provider{NewEnvProvider().WithEnv(map[string]string{"HOST": "prod.internal"}), NewDefaultProvider()}, nil
+		},
+		"broken": func() ([]Provider, error) {
+			return nil, errors.New("vault is unavailable")
+		},
+	}
+
+	tests := map[string]struct {
+		env      string
+		others   []Provider
+		expected string
+		err      string
+	}{
+		"dev":     {env: "dev", expected: "localhost"},
+		"prod":    {env: "prod", expected: "prod.internal"},
+		"before":  {env: "prod", others: []Provider{NewEnvProvider().WithEnv(map[string]string{"HOST": "override"})}, expected: "override"},
+		"unknown": {env: "stage", err: "unknown environment [stage], expected one of [broken, dev, prod]"},
+		"empty":   {env: "", err: "unknown environment [], expected one of [broken, dev, prod]"},
+		"broken":  {env: "broken", err: "environment [broken]: vault is unavailable"},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			var cfg config
+			c, err := New(&cfg, WithProviders(test.others...), WithEnvironment(test.env, environments))
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+				return
+			}
+			if err != nil {
+				t.Fatal("unexpected err: ", err)
+			}
+			assert.NoError(t, c.InitValues())
+			assert.Equal(t, test.expected, cfg.Host)
+		})
+	}
+
+	created = map[string]bool{}
+	_, err := New(&config{}, WithEnvironment("dev", environments))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"dev": true}, created, "providers of other environments must not be created")
+}
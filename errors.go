@@ -0,0 +1,111 @@
+package configuration
+
+import "errors"
+
+// Kinds of errors of fields which can be checked with errors.Is
+var (
+	// ErrNotSet means that none of the providers found the value for the field
+	ErrNotSet = errors.New("value is not set by any provider")
+	// ErrParse means that the value is found but can't be converted to the type of the field
+	ErrParse = errors.New("value cannot be parsed")
+	// ErrProviderUnavailable means that the provider can't look for the value (e.g. remote call failed or timed out)
+	ErrProviderUnavailable = errors.New("provider is unavailable")
+	// ErrRequired means that none of the providers found the value for the field tagged `required:"true"`,
+	// errors.Is(err, ErrNotSet) is true for it as well
+	ErrRequired error = kindError{kind: ErrNotSet, err: errors.New("required value is not set")}
+	// ErrInvalid means that the value is set but doesn't pass the validation (see `validate` tag)
+	ErrInvalid = errors.New("value is invalid")
+	// ErrIncompleteGroup means that only some fields of the nested struct tagged `group:"true"` are set
+	ErrIncompleteGroup = errors.New("group is incomplete")
+	// ErrUnknownKey means that the source has a key which doesn't match any field (see KeysChecker)
+	ErrUnknownKey = errors.New("unknown key")
+	// ErrLimitExceeded means that the configuration object has more fields or levels of nesting than allowed
+	// (see WithMaxFields and WithMaxDepth)
+	ErrLimitExceeded = errors.New("limit of the configuration size is exceeded")
+	// ErrFrozenMutated means that the configuration object is changed after Freeze bypassing the configurator
+	ErrFrozenMutated = errors.New("frozen configuration is mutated")
+)
+
+// FieldError describes a field which cannot be set:
+//
+//	var fe *FieldError
+//	if errors.As(err, &fe) && errors.Is(fe, ErrParse) { /* fe.Path, fe.Provider */ }
+type FieldError struct {
+	Path     string // path to the field joined with `.`, e.g. `Database.Host`
+	Tag      string // tags of the field
+	Provider string // name of the provider which failed (empty for ErrNotSet)
+	Err      error
+
+	name string // name of the field
+}
+
+func (e *FieldError) Error() string {
+	switch {
+	case e.Err == ErrNotSet:
+		return msg(MsgFieldNotSet, e.name, e.Tag)
+	case e.Err == ErrRequired:
+		return msg(MsgFieldRequired, e.Path)
+	case e.Provider == "":
+		return msg(MsgFieldError, e.Path, e.Err)
+	default:
+		return msg(MsgFieldProviderError, e.name, e.Tag, e.Provider, e.Err)
+	}
+}
+
+// Unwrap returns the cause of the error
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is FieldError of the same field (and provider if it's not empty):
+// errors.Is(err, &FieldError{Path: "Database.Host"})
+func (e *FieldError) Is(target error) bool {
+	t, ok := target.(*FieldError)
+	if !ok {
+		return false
+	}
+	return t.Path == e.Path && (t.Provider == "" || t.Provider == e.Provider)
+}
+
+// anyIs reports whether any of errs matches the target, so errors which hold several errors
+// work with errors.Is before Go 1.20
+func anyIs(errs []error, target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// anyAs is the same as anyIs but for errors.As
+func anyAs(errs []error, target interface{}) bool {
+	for _, err := range errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// kindError marks the error with one of ErrParse, ErrProviderUnavailable, keeping the message of the error
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e kindError) Error() string        { return e.err.Error() }
+func (e kindError) Unwrap() error        { return e.err }
+func (e kindError) Is(target error) bool { return target == e.kind }
+
+func parseError(err error) error {
+	return kindError{kind: ErrParse, err: err}
+}
+
+func invalidError(err error) error {
+	return kindError{kind: ErrInvalid, err: err}
+}
+
+func unavailableError(err error) error {
+	return kindError{kind: ErrProviderUnavailable, err: err}
+}
@@ -0,0 +1,104 @@
+package configuration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFieldError(t *testing.T) {
+	type config struct {
+		Mode     os.FileMode `default:"rw-everything"`
+		Database struct {
+			Host string `json:"host"`
+		}
+	}
+
+	var cfg config
+	c, err := New(&cfg, WithProviders(NewEnvProvider(), NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+
+	assert.True(t, errors.Is(err, ErrParse))
+	assert.True(t, errors.Is(err, ErrNotSet))
+	assert.False(t, errors.Is(err, ErrProviderUnavailable))
+	assert.True(t, errors.Is(err, &FieldError{Path: "Mode", Provider: "defaultProvider"}))
+	assert.True(t, errors.Is(err, &FieldError{Path: "Database.host"}))
+	assert.False(t, errors.Is(err, &FieldError{Path: "Mode", Provider: "envProvider"}))
+
+	var fe *FieldError
+	if assert.True(t, errors.As(err, &fe)) {
+		assert.Equal(t, "Mode", fe.Path)
+		assert.Equal(t, `default:"rw-everything"`, fe.Tag)
+		assert.Equal(t, "defaultProvider", fe.Provider)
+		assert.EqualError(t, fe, `configurator: field [Mode] with tags [default:"rw-everything"] cannot be set by [defaultProvider]: `+
+			`invalid file mode: "rw-everything"`)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = c.InitValuesContext(ctx)
+	assert.True(t, errors.Is(err, ErrProviderUnavailable))
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	overrides := NewOverrideProvider()
+	c, err = New(&cfg, WithProviders(overrides, NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.Set("Mode", "not a mode")
+	assert.True(t, errors.Is(err, ErrParse))
+	assert.True(t, errors.Is(err, &FieldError{Path: "Mode"}))
+}
+
+func TestConfigurator_StopOnFirstError(t *testing.T) {
+	type config struct {
+		Mode os.FileMode `default:"rw-everything"`
+		Port int         `default:"port"`
+	}
+
+	var cfg config
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()), WithStrictCoercion())
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	var errs loadErrors
+	if assert.True(t, errors.As(c.InitValues(), &errs)) {
+		assert.Len(t, errs, 2, "all fields are tried by default")
+	}
+
+	c, err = New(&cfg, WithProviders(NewDefaultProvider()), WithStrictCoercion(), StopOnFirstError())
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+	assert.True(t, errors.Is(err, &FieldError{Path: "Mode"}))
+	assert.False(t, errors.Is(err, &FieldError{Path: "Port"}))
+}
+
+func TestMultiErrors_IsAs(t *testing.T) {
+	fe := &FieldError{Path: "Port", Provider: "envProvider", Err: parseError(errors.New("bad"))}
+
+	// called directly as errors.Is and errors.As do before Go 1.20 which ignore Unwrap() []error
+	errs := loadErrors{errors.New("other"), fe}
+	assert.True(t, errs.Is(ErrParse))
+	assert.True(t, errs.Is(&FieldError{Path: "Port"}))
+	assert.False(t, errs.Is(ErrNotSet))
+	var target *FieldError
+	if assert.True(t, errs.As(&target)) {
+		assert.Equal(t, fe, target)
+	}
+
+	report := &UnsetReport{Fields: []*FieldError{{Path: "Host", Err: ErrRequired}}}
+	assert.True(t, report.Is(ErrNotSet))
+	assert.False(t, report.Is(ErrParse))
+	target = nil
+	if assert.True(t, report.As(&target)) {
+		assert.Equal(t, "Host", target.Path)
+	}
+}
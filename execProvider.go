@@ -0,0 +1,234 @@
+//go:build !js && !wasip1 && !tinygo
+// +build !js,!wasip1,!tinygo
+
+package configuration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// NewExecProvider starts the external provider binary which speaks the line-delimited JSON protocol
+// over stdin/stdout. For every field it receives a request:
+//
+//	{"path": ["Database", "Host"], "field": "Host", "tags": "json:\"host\""}
+//
+// and must answer with a single line:
+//
+//	{"found": true, "value": "localhost"}
+//
+// or {"found": false} (optionally with "error": "..."). Call Close to stop the process.
+// If the process is killed (see ProvideContext) or exits, it's started again by the next request.
+func NewExecProvider(name string, args ...string) (execProvider, error) {
+	proc := &execProcess{name: name, args: args, env: os.Environ()}
+	if err := proc.start(); err != nil {
+		return execProvider{}, err
+	}
+	return execProvider{
+		mu:   &sync.Mutex{},
+		proc: proc,
+	}, nil
+}
+
+type execProvider struct {
+	mu   *sync.Mutex // one request at a time
+	proc *execProcess
+	opts *options // of the configurator (see optionsBinder)
+}
+
+func (ep execProvider) withOptions(o *options) Provider {
+	ep.opts = o
+	return ep
+}
+
+// execProcess is the running provider binary
+type execProcess struct {
+	name string
+	args []string
+	env  []string // of the first start, restarts don't depend on later changes
+
+	mu     sync.Mutex // guards the fields below, they are replaced by restarts
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	reader *bufio.Reader
+	broken bool // the process is killed or failed to answer, it's restarted by the next request
+}
+
+// start starts the process, p.mu must be locked unless the process isn't shared yet
+func (p *execProcess) start() error {
+	cmd := exec.Command(p.name, p.args...)
+	cmd.Env = p.env
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	p.cmd, p.stdin, p.reader, p.broken = cmd, stdin, bufio.NewReader(stdout), false
+	return nil
+}
+
+// current returns pipes of the process restarting it if it's broken
+func (p *execProcess) current() (*exec.Cmd, io.Writer, *bufio.Reader, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.broken {
+		_ = p.cmd.Process.Kill()
+		_ = p.cmd.Wait()
+		if err := p.start(); err != nil {
+			return nil, nil, nil, fmt.Errorf("cannot restart: %v", err)
+		}
+	}
+	return p.cmd, p.stdin, p.reader, nil
+}
+
+// kill kills the process (the protocol can't be resumed after an unanswered request)
+func (p *execProcess) kill() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_ = p.cmd.Process.Kill()
+	p.broken = true
+}
+
+// fail marks the process as broken unless it's already restarted
+func (p *execProcess) fail(cmd *exec.Cmd) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cmd == cmd {
+		p.broken = true
+	}
+}
+
+type execRequest struct {
+	Path  []string `json:"path"`
+	Field string   `json:"field"`
+	Tags  string   `json:"tags"`
+}
+
+type execResponse struct {
+	Found bool   `json:"found"`
+	Value string `json:"value"`
+	Error string `json:"error,omitempty"`
+}
+
+func (ep execProvider) Provide(field reflect.StructField, v reflect.Value, path ...string) bool {
+	ok, _ := ep.ProvideError(context.Background(), field, v, path...)
+	return ok
+}
+
+// ProvideContext kills the process of the provider once the context is done
+// (the protocol can't be resumed after an unanswered request), the next request starts it again
+func (ep execProvider) ProvideContext(ctx context.Context, field reflect.StructField, v reflect.Value, path ...string) bool {
+	ok, _ := ep.ProvideError(ctx, field, v, path...)
+	return ok
+}
+
+// ProvideError is the same as ProvideContext but returns errors of the provider binary and of parsing
+func (ep execProvider) ProvideError(ctx context.Context, field reflect.StructField, v reflect.Value, path ...string) (bool, error) {
+	type result struct {
+		resp execResponse
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		resp, err := ep.request(execRequest{Path: path, Field: field.Name, Tags: string(field.Tag)})
+		done <- result{resp: resp, err: err}
+	}()
+
+	var resp execResponse
+	select {
+	case <-ctx.Done():
+		if ep.proc != nil {
+			ep.proc.kill()
+		}
+		ep.opts.errorf("execProvider: [%s]: %v", strings.Join(path, pathSeparator), ctx.Err())
+		return false, unavailableError(ctx.Err())
+	case r := <-done:
+		if r.err != nil {
+			ep.opts.errorf("execProvider: %v", r.err)
+			return false, unavailableError(r.err)
+		}
+		resp = r.resp
+	}
+
+	if resp.Error != "" {
+		ep.opts.errorf("execProvider: [%s]: %s", strings.Join(path, pathSeparator), resp.Error)
+		return false, unavailableError(errors.New(resp.Error))
+	}
+	if !resp.Found {
+		return false, nil
+	}
+
+	if err := setField(field, v, resp.Value, ep.opts.strict()); err != nil {
+		ep.opts.errorf("execProvider: %v", err)
+		return false, parseError(err)
+	}
+	ep.opts.logf("execProvider: set [%v] to field [%s]", logValue(field, resp.Value), strings.Join(path, pathSeparator))
+	return true, nil
+}
+
+func (ep execProvider) request(req execRequest) (execResponse, error) {
+	if ep.proc == nil {
+		return execResponse{}, errors.New("provider is not started")
+	}
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		return execResponse{}, err
+	}
+
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+
+	cmd, stdin, reader, err := ep.proc.current()
+	if err != nil {
+		return execResponse{}, err
+	}
+	if _, err := stdin.Write(append(b, '\n')); err != nil {
+		ep.proc.fail(cmd)
+		return execResponse{}, err
+	}
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		ep.proc.fail(cmd)
+		return execResponse{}, err
+	}
+
+	var resp execResponse
+	err = json.Unmarshal(line, &resp)
+	return resp, err
+}
+
+// Close closes stdin of the provider binary and waits for it to exit
+func (ep execProvider) Close() error {
+	if ep.proc == nil {
+		return nil
+	}
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	ep.proc.mu.Lock()
+	defer ep.proc.mu.Unlock()
+
+	if err := ep.proc.stdin.Close(); err != nil {
+		return err
+	}
+	return ep.proc.cmd.Wait()
+}
@@ -0,0 +1,124 @@
+//go:build !js && !wasip1 && !tinygo
+// +build !js,!wasip1,!tinygo
+
+package configuration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestExecProviderHelper isn't a real test: it's the provider binary started by TestExecProvider
+func TestExecProviderHelper(t *testing.T) {
+	if os.Getenv("CONFIGURATION_EXEC_PROVIDER") != "1" {
+		return
+	}
+
+	values := map[string]string{"Name": "exec", "Database.Port": "5432", "Database.Host": "wrong:port"}
+	scanner := bufio.NewScanner(os.Stdin)
+	enc := json.NewEncoder(os.Stdout)
+	for scanner.Scan() {
+		var req execRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			_ = enc.Encode(execResponse{Error: err.Error()})
+			continue
+		}
+		path := strings.Join(req.Path, ".")
+		if path == "Hang" {
+			select {} // never answers
+		}
+		if path == "Database.Host" {
+			_ = enc.Encode(execResponse{Error: "access denied"})
+			continue
+		}
+		val, ok := values[path]
+		_ = enc.Encode(execResponse{Found: ok, Value: val})
+	}
+	os.Exit(0)
+}
+
+func TestExecProvider(t *testing.T) {
+	type config struct {
+		Name     string
+		LogLevel string `default:"info"`
+		Database struct {
+			Host string `default:"localhost"`
+			Port int
+		}
+	}
+
+	removeEnvKey, err := setEnv("CONFIGURATION_EXEC_PROVIDER", "1")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	testBinary, err := os.Executable() // os.Args may be replaced by tests of the flag provider
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	ep, err := NewExecProvider(testBinary, "-test.run=TestExecProviderHelper")
+	removeEnvKey()
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+
+	var cfg config
+	c, err := New(&cfg, WithProviders(ep, NewDefaultProvider()))
+	assert.NoError(t, err)
+	assert.NoError(t, c.InitValues())
+	assert.NoError(t, ep.Close())
+
+	assert.Equal(t, "exec", cfg.Name)
+	assert.Equal(t, "info", cfg.LogLevel)
+	assert.Equal(t, "localhost", cfg.Database.Host)
+	assert.Equal(t, 5432, cfg.Database.Port)
+}
+
+func TestExecProvider_NotStarted(t *testing.T) {
+	_, err := NewExecProvider("./testdata/not-existing-provider")
+	assert.Error(t, err)
+
+	var (
+		ep      execProvider
+		testObj struct{ Name string }
+	)
+	assert.False(t, ep.Provide(reflect.TypeOf(testObj).Field(0), reflect.ValueOf(&testObj).Elem().Field(0), "Name"))
+	assert.NoError(t, ep.Close())
+}
+
+func TestExecProvider_Context(t *testing.T) {
+	removeEnvKey, err := setEnv("CONFIGURATION_EXEC_PROVIDER", "1")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	testBinary, err := os.Executable()
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	ep, err := NewExecProvider(testBinary, "-test.run=TestExecProviderHelper")
+	removeEnvKey()
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+
+	var cfg struct {
+		Hang string
+		Name string
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.False(t, ep.ProvideContext(ctx, reflect.TypeOf(cfg).Field(0), reflect.ValueOf(&cfg).Elem().Field(0), "Hang"))
+
+	// the killed process is started again by the next request
+	assert.True(t, ep.ProvideContext(context.Background(), reflect.TypeOf(cfg).Field(1), reflect.ValueOf(&cfg).Elem().Field(1), "Name"))
+	assert.Equal(t, "exec", cfg.Name)
+	assert.NoError(t, ep.Close())
+}
@@ -0,0 +1,11 @@
+//go:build !js && !wasip1 && !tinygo
+// +build !js,!wasip1,!tinygo
+
+package configuration
+
+import "os"
+
+// exit terminates the program if the configuration cannot be filled (see `failIfCannotSet` of New)
+func exit(string, ...interface{}) {
+	os.Exit(1)
+}
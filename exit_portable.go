@@ -0,0 +1,11 @@
+//go:build js || wasip1 || tinygo
+// +build js wasip1 tinygo
+
+package configuration
+
+import "fmt"
+
+// exit panics instead of os.Exit, which isn't available or tears down the host instance under WASM and TinyGo
+func exit(format string, args ...interface{}) {
+	panic(fmt.Sprintf(format, args...))
+}
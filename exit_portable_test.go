@@ -0,0 +1,18 @@
+//go:build js || wasip1 || tinygo
+// +build js wasip1 tinygo
+
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFatalf_Panics(t *testing.T) {
+	o := newOptions([]Option{FailIfCannotSet(), WithLogger(func(string, ...interface{}) {})})
+
+	assert.PanicsWithValue(t, "field [Name] cannot be set", func() {
+		o.fatalf("field [%s] cannot be set", "Name")
+	})
+}
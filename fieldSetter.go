@@ -1,7 +1,13 @@
 package configuration
 
 import (
+	"database/sql"
+	"encoding"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net/mail"
+	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -10,43 +16,206 @@ import (
 
 const sliceSeparator = ";"
 
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	sqlScannerType      = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	mailAddressType     = reflect.TypeOf(mail.Address{})
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+)
+
 // SetField sets field with `valStr` value (converts to the proper type beforehand)
 func SetField(field reflect.StructField, v reflect.Value, valStr string) error {
+	return setField(field, v, valStr, false)
+}
+
+// setField is the same as SetField, `strict` makes lossy or suspicious conversions of scalars return errors
+// instead of best-effort values (see WithStrictCoercion)
+func setField(field reflect.StructField, v reflect.Value, valStr string, strict bool) error {
+	if isTextUnmarshaler(field.Type) {
+		return setTextUnmarshaler(field.Type, v, valStr)
+	}
+	if isSQLScanner(field.Type) {
+		return setSQLScanner(field.Type, v, valStr)
+	}
+	if ok, err := setMailAddress(field.Type, v, valStr); ok {
+		return err
+	}
+	if ok, err := setFormatted(field, v, valStr); ok {
+		return err
+	}
+
 	if v.Kind() == reflect.Ptr {
-		if err := setPtrValue(field.Type.Elem(), v, valStr); err != nil {
+		if err := setPtrValue(field.Type.Elem(), v, valStr, strict); err != nil {
 			return err
 		}
 		return nil
 	}
-	return setValue(field.Type, v, valStr)
+	return setValue(field.Type, v, valStr, strict)
+}
+
+// isTextUnmarshaler reports whether the type (or a pointer to it) implements encoding.TextUnmarshaler,
+// e.g. decimal.Decimal, big.Float, net.IP
+func isTextUnmarshaler(t reflect.Type) bool {
+	return t.Implements(textUnmarshalerType) || reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// isLeafStruct reports whether the struct type (or a pointer to it) is set as a single value
+// instead of being traversed field by field
+func isLeafStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == mailAddressType || isTextUnmarshaler(t) || isSQLScanner(t)
+}
+
+// isSQLScanner reports whether the struct type (or a pointer to it) implements sql.Scanner,
+// e.g. sql.NullString, sql.NullInt64
+func isSQLScanner(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(sqlScannerType)
 }
 
-func setValue(t reflect.Type, v reflect.Value, val string) error {
+func setSQLScanner(t reflect.Type, v reflect.Value, val string) error {
+	elemType := t
+	if t.Kind() == reflect.Ptr {
+		elemType = t.Elem()
+	}
+
+	var src interface{} = val
+	// sql.NullTime and similar types accept only time.Time
+	if f, ok := elemType.FieldByName("Time"); ok && f.Type == timeType {
+		tm, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return err
+		}
+		src = tm
+	}
+
+	ptr := reflect.New(elemType)
+	if err := ptr.Interface().(sql.Scanner).Scan(src); err != nil {
+		return err
+	}
+
+	if t.Kind() == reflect.Ptr {
+		v.Set(ptr)
+		return nil
+	}
+	v.Set(ptr.Elem())
+	return nil
+}
+
+func setTextUnmarshaler(t reflect.Type, v reflect.Value, val string) error {
+	if t.Kind() == reflect.Ptr {
+		ptr := reflect.New(t.Elem())
+		if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val)); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+	return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+}
+
+// setMailAddress sets mail.Address, *mail.Address, []mail.Address and []*mail.Address values.
+// Returns false if the type is not one of them.
+func setMailAddress(t reflect.Type, v reflect.Value, val string) (bool, error) {
+	switch t {
+	case mailAddressType, reflect.PtrTo(mailAddressType):
+		addr, err := mail.ParseAddress(val)
+		if err != nil {
+			return true, fmt.Errorf("invalid mail address %q: %v", val, err)
+		}
+		if t.Kind() == reflect.Ptr {
+			v.Set(reflect.ValueOf(addr))
+			return true, nil
+		}
+		v.Set(reflect.ValueOf(*addr))
+
+	case reflect.SliceOf(mailAddressType), reflect.SliceOf(reflect.PtrTo(mailAddressType)):
+		items := splitSlice(val)
+		if len(items) == 0 {
+			return true, nil
+		}
+
+		slice := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			addr, err := mail.ParseAddress(item)
+			if err != nil {
+				return true, fmt.Errorf("invalid mail address %q: %v", item, err)
+			}
+			if t.Elem().Kind() == reflect.Ptr {
+				slice.Index(i).Set(reflect.ValueOf(addr))
+				continue
+			}
+			slice.Index(i).Set(reflect.ValueOf(*addr))
+		}
+		v.Set(slice)
+
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+func setValue(t reflect.Type, v reflect.Value, val string, strict bool) error {
 	switch t.Kind() {
 	case reflect.String:
 		v.SetString(val)
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
-		i, _ := strconv.ParseInt(val, 10, 64)
+		i, err := parseInt(val, 64)
+		if err == nil && v.OverflowInt(i) {
+			err = errOutOfRange
+		}
+		if err := coercionError(t, val, err, strict); err != nil {
+			return err
+		}
 		v.SetInt(i)
 
 	case reflect.Int64:
-		setInt64(v, val)
+		if err := setInt64(v, val, strict); err != nil {
+			return err
+		}
 
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		i, _ := strconv.ParseUint(val, 10, 64)
+	case reflect.Uint32:
+		if err := setUint32(v, val, strict); err != nil {
+			return err
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint64:
+		i, err := parseUint(val, 64)
+		if err == nil && v.OverflowUint(i) {
+			err = errOutOfRange
+		}
+		if err := coercionError(t, val, err, strict); err != nil {
+			return err
+		}
 		v.SetUint(i)
 
 	case reflect.Float32, reflect.Float64:
-		f, _ := strconv.ParseFloat(val, 64)
+		f, err := parseFloat(val, t.Bits())
+		if err := coercionError(t, val, err, strict); err != nil {
+			return err
+		}
 		v.SetFloat(f)
 
 	case reflect.Bool:
-		b, _ := strconv.ParseBool(val)
+		b, err := parseBool(val, strict)
+		if err := coercionError(t, val, err, strict); err != nil {
+			return err
+		}
 		v.SetBool(b)
 
 	case reflect.Slice:
-		if err := setSlice(t, v, val); err != nil {
+		if err := setSlice(t, v, val, strict); err != nil {
+			return err
+		}
+
+	case reflect.Array:
+		if err := setArray(t, v, val, strict); err != nil {
 			return err
 		}
 
@@ -56,20 +225,198 @@ func setValue(t reflect.Type, v reflect.Value, val string) error {
 	return nil
 }
 
-func setInt64(v reflect.Value, val string) {
+var errOutOfRange = errors.New("value out of range")
+
+// coercionError returns the error of the conversion only in the strict mode (see WithStrictCoercion)
+func coercionError(t reflect.Type, val string, err error, strict bool) error {
+	if err == nil || !strict {
+		return nil
+	}
+	return fmt.Errorf("cannot convert [%s] to %v: %v", val, t, err)
+}
+
+// parseBool is strconv.ParseBool which accepts only `true` or `false` in the strict mode
+func parseBool(val string, strict bool) (bool, error) {
+	b, err := strconv.ParseBool(val)
+	if err == nil && strict && !strings.EqualFold(val, "true") && !strings.EqualFold(val, "false") {
+		err = errors.New("only `true` or `false` are allowed")
+	}
+	return b, err
+}
+
+func setInt64(v reflect.Value, val string, strict bool) error {
 	// special case for parsing human readable input for time.Duration
 	if _, ok := v.Interface().(time.Duration); ok {
-		d, _ := time.ParseDuration(val)
+		d, err := parseDuration(val)
+		if err := coercionError(v.Type(), val, err, strict); err != nil {
+			return err
+		}
 		v.SetInt(int64(d))
-		return
+		return nil
 	}
 
 	// regular int64 case
-	i, _ := strconv.ParseInt(val, 10, 64)
+	i, err := parseInt(val, 64)
+	if err := coercionError(v.Type(), val, err, strict); err != nil {
+		return err
+	}
 	v.SetInt(i)
+	return nil
+}
+
+func setUint32(v reflect.Value, val string, strict bool) error {
+	// special case for parsing octal or symbolic input for os.FileMode (fs.FileMode)
+	if _, ok := v.Interface().(os.FileMode); ok {
+		mode, err := parseFileMode(val)
+		if err != nil {
+			return err
+		}
+		v.SetUint(uint64(mode))
+		return nil
+	}
+
+	// regular uint32 case
+	i, err := parseUint(val, 32)
+	if err := coercionError(v.Type(), val, err, strict); err != nil {
+		return err
+	}
+	v.SetUint(i)
+	return nil
+}
+
+// parseFileMode parses permission bits either in octal ("0640", "755") or
+// symbolic ("u=rw,g=r", "a=r,u+w") notation
+func parseFileMode(val string) (os.FileMode, error) {
+	val = strings.TrimSpace(val)
+	if isNumeric(val) {
+		m, err := strconv.ParseUint(val, 8, 32)
+		if err != nil || m > uint64(os.ModePerm) {
+			return 0, fmt.Errorf("invalid file mode: %q", val)
+		}
+		return os.FileMode(m), nil
+	}
+
+	var mode os.FileMode
+	for _, clause := range strings.Split(val, ",") {
+		opIdx := strings.IndexAny(clause, "=+-")
+		if opIdx < 0 {
+			return 0, fmt.Errorf("invalid file mode: %q", val)
+		}
+
+		var who os.FileMode
+		for _, r := range clause[:opIdx] {
+			switch r {
+			case 'u':
+				who |= 0700
+			case 'g':
+				who |= 0070
+			case 'o':
+				who |= 0007
+			case 'a':
+				who |= 0777
+			default:
+				return 0, fmt.Errorf("invalid file mode: %q", val)
+			}
+		}
+		if who == 0 {
+			who = 0777
+		}
+
+		for rest := clause[opIdx:]; len(rest) > 0; {
+			op := rest[0]
+			end := strings.IndexAny(rest[1:], "=+-")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			var perm os.FileMode
+			for _, r := range rest[1 : end+1] {
+				switch r {
+				case 'r':
+					perm |= 0444
+				case 'w':
+					perm |= 0222
+				case 'x':
+					perm |= 0111
+				default:
+					return 0, fmt.Errorf("invalid file mode: %q", val)
+				}
+			}
+			switch op {
+			case '=':
+				mode = mode&^who | perm&who
+			case '+':
+				mode |= perm & who
+			case '-':
+				mode &^= perm & who
+			}
+			rest = rest[end+1:]
+		}
+	}
+	return mode, nil
+}
+
+// parseDuration extends time.ParseDuration with `d` (day) and `w` (week) units: "2d", "1w", "30d12h"
+func parseDuration(val string) (time.Duration, error) {
+	var (
+		s     = strings.TrimSpace(val)
+		sign  = time.Duration(1)
+		total time.Duration
+		rest  strings.Builder // everything time.ParseDuration can handle by itself
+	)
+
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+		s = s[1:]
+	} else if strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+	if s == "" {
+		return 0, fmt.Errorf("invalid duration: %q", val)
+	}
+
+	for len(s) > 0 {
+		numEnd := strings.IndexFunc(s, func(r rune) bool { return r != '.' && (r < '0' || r > '9') })
+		if numEnd < 0 {
+			numEnd = len(s)
+		}
+		if numEnd == 0 { // every unit needs a number, signs are allowed only in front of the value
+			return 0, fmt.Errorf("invalid duration: %q", val)
+		}
+		unitEnd := strings.IndexFunc(s[numEnd:], func(r rune) bool { return r == '.' || (r >= '0' && r <= '9') })
+		if unitEnd < 0 {
+			unitEnd = len(s)
+		} else {
+			unitEnd += numEnd
+		}
+
+		switch unit := s[numEnd:unitEnd]; unit {
+		case "d", "w":
+			n, err := strconv.ParseFloat(s[:numEnd], 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration: %q", val)
+			}
+			day := 24 * time.Hour
+			if unit == "w" {
+				day *= 7
+			}
+			total += time.Duration(n * float64(day))
+		default:
+			rest.WriteString(s[:unitEnd])
+		}
+		s = s[unitEnd:]
+	}
+
+	if rest.Len() > 0 {
+		d, err := time.ParseDuration(rest.String())
+		if err != nil {
+			return 0, err
+		}
+		total += d
+	}
+	return sign * total, nil
 }
 
-func setSlice(t reflect.Type, v reflect.Value, val string) error {
+func splitSlice(val string) []string {
 	var items []string
 	for _, item := range strings.Split(val, sliceSeparator) {
 		item = strings.TrimSpace(item)
@@ -77,7 +424,12 @@ func setSlice(t reflect.Type, v reflect.Value, val string) error {
 			items = append(items, item)
 		}
 	}
+	return items
+}
 
+// setSlice sets `;`-separated items, items which cannot be converted are zero (or the error is returned in the strict mode)
+func setSlice(t reflect.Type, v reflect.Value, val string, strict bool) error {
+	items := splitSlice(val)
 	size := len(items)
 	if size < 1 {
 		return nil
@@ -91,22 +443,34 @@ func setSlice(t reflect.Type, v reflect.Value, val string) error {
 		}
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		for i := 0; i < size; i++ {
-			val, _ := strconv.ParseInt(items[i], 10, 64)
+			val, err := parseInt(items[i], 64)
+			if err := coercionError(t.Elem(), items[i], err, strict); err != nil {
+				return err
+			}
 			slice.Index(i).SetInt(val)
 		}
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		for i := 0; i < size; i++ {
-			val, _ := strconv.ParseUint(items[i], 10, 64)
+			val, err := parseUint(items[i], 64)
+			if err := coercionError(t.Elem(), items[i], err, strict); err != nil {
+				return err
+			}
 			slice.Index(i).SetUint(val)
 		}
 	case reflect.Float32, reflect.Float64:
 		for i := 0; i < size; i++ {
-			val, _ := strconv.ParseFloat(items[i], 64)
+			val, err := parseFloat(items[i], 64)
+			if err := coercionError(t.Elem(), items[i], err, strict); err != nil {
+				return err
+			}
 			slice.Index(i).SetFloat(val)
 		}
 	case reflect.Bool:
 		for i := 0; i < size; i++ {
-			val, _ := strconv.ParseBool(items[i])
+			val, err := parseBool(items[i], strict)
+			if err := coercionError(t.Elem(), items[i], err, strict); err != nil {
+				return err
+			}
 			slice.Index(i).SetBool(val)
 		}
 	default:
@@ -117,67 +481,122 @@ func setSlice(t reflect.Type, v reflect.Value, val string) error {
 	return nil
 }
 
-func setPtrValue(t reflect.Type, v reflect.Value, val string) error {
+// setArray sets fixed-size arrays: `[N]byte` from a hex-encoded string, others from `;`-separated items.
+// The number of items must match the length of the array.
+func setArray(t reflect.Type, v reflect.Value, val string, strict bool) error {
+	if t.Elem().Kind() == reflect.Uint8 {
+		b, err := hex.DecodeString(strings.TrimSpace(val))
+		if err != nil {
+			return fmt.Errorf("invalid hex value for %v: %v", t, err)
+		}
+		if len(b) != t.Len() {
+			return fmt.Errorf("wrong length for %v: expected %d bytes but got %d", t, t.Len(), len(b))
+		}
+		reflect.Copy(v, reflect.ValueOf(b))
+		return nil
+	}
+
+	items := splitSlice(val)
+	if len(items) != t.Len() {
+		return fmt.Errorf("wrong length for %v: expected %d items but got %d", t, t.Len(), len(items))
+	}
+
+	arr := reflect.New(t).Elem()
+	for i, item := range items {
+		if err := setValue(t.Elem(), arr.Index(i), item, strict); err != nil {
+			return err
+		}
+	}
+	v.Set(arr)
+	return nil
+}
+
+// setPtrValue leaves the pointer nil if the value cannot be converted (or returns the error in the strict mode)
+func setPtrValue(t reflect.Type, v reflect.Value, val string, strict bool) error {
 	switch t.Name() {
 	case reflect.Int.String(): // doesn't care about 32bit systems
-		if i64, err := strconv.ParseInt(val, 10, 64); err == nil {
-			i := int(i64)
-			v.Set(reflect.ValueOf(&i))
+		i64, err := parseInt(val, 64)
+		if err != nil {
+			return coercionError(t, val, err, strict)
 		}
+		i := int(i64)
+		v.Set(reflect.ValueOf(&i))
 	case reflect.Int8.String():
-		if i64, err := strconv.ParseInt(val, 10, 8); err == nil {
-			i8 := int8(i64)
-			v.Set(reflect.ValueOf(&i8))
+		i64, err := parseInt(val, 8)
+		if err != nil {
+			return coercionError(t, val, err, strict)
 		}
+		i8 := int8(i64)
+		v.Set(reflect.ValueOf(&i8))
 	case reflect.Int16.String():
-		if i64, err := strconv.ParseInt(val, 10, 16); err == nil {
-			i16 := int16(i64)
-			v.Set(reflect.ValueOf(&i16))
+		i64, err := parseInt(val, 16)
+		if err != nil {
+			return coercionError(t, val, err, strict)
 		}
+		i16 := int16(i64)
+		v.Set(reflect.ValueOf(&i16))
 	case reflect.Int32.String():
-		if i64, err := strconv.ParseInt(val, 10, 32); err == nil {
-			i32 := int32(i64)
-			v.Set(reflect.ValueOf(&i32))
+		i64, err := parseInt(val, 32)
+		if err != nil {
+			return coercionError(t, val, err, strict)
 		}
+		i32 := int32(i64)
+		v.Set(reflect.ValueOf(&i32))
 	case reflect.Int64.String():
-		if i64, err := strconv.ParseInt(val, 10, 64); err == nil {
-			v.Set(reflect.ValueOf(&i64))
+		i64, err := parseInt(val, 64)
+		if err != nil {
+			return coercionError(t, val, err, strict)
 		}
+		v.Set(reflect.ValueOf(&i64))
 
 	case reflect.Uint.String(): // doesn't care about 32bit systems
-		if ui64, err := strconv.ParseUint(val, 10, 64); err == nil {
-			ui := uint(ui64)
-			v.Set(reflect.ValueOf(&ui))
+		ui64, err := parseUint(val, 64)
+		if err != nil {
+			return coercionError(t, val, err, strict)
 		}
+		ui := uint(ui64)
+		v.Set(reflect.ValueOf(&ui))
 	case reflect.Uint8.String():
-		if ui64, err := strconv.ParseUint(val, 10, 8); err == nil {
-			ui8 := uint8(ui64)
-			v.Set(reflect.ValueOf(&ui8))
+		ui64, err := parseUint(val, 8)
+		if err != nil {
+			return coercionError(t, val, err, strict)
 		}
+		ui8 := uint8(ui64)
+		v.Set(reflect.ValueOf(&ui8))
 	case reflect.Uint16.String():
-		if ui64, err := strconv.ParseUint(val, 10, 16); err == nil {
-			ui16 := uint16(ui64)
-			v.Set(reflect.ValueOf(&ui16))
+		ui64, err := parseUint(val, 16)
+		if err != nil {
+			return coercionError(t, val, err, strict)
 		}
+		ui16 := uint16(ui64)
+		v.Set(reflect.ValueOf(&ui16))
 	case reflect.Uint32.String():
-		if ui64, err := strconv.ParseUint(val, 10, 32); err == nil {
-			ui32 := uint32(ui64)
-			v.Set(reflect.ValueOf(&ui32))
+		ui64, err := parseUint(val, 32)
+		if err != nil {
+			return coercionError(t, val, err, strict)
 		}
+		ui32 := uint32(ui64)
+		v.Set(reflect.ValueOf(&ui32))
 	case reflect.Uint64.String():
-		if ui64, err := strconv.ParseUint(val, 10, 64); err == nil {
-			v.Set(reflect.ValueOf(&ui64))
+		ui64, err := parseUint(val, 64)
+		if err != nil {
+			return coercionError(t, val, err, strict)
 		}
+		v.Set(reflect.ValueOf(&ui64))
 
 	case reflect.Float32.String():
-		if f64, err := strconv.ParseFloat(val, 32); err == nil {
-			f32 := float32(f64)
-			v.Set(reflect.ValueOf(&f32))
+		f64, err := parseFloat(val, 32)
+		if err != nil {
+			return coercionError(t, val, err, strict)
 		}
+		f32 := float32(f64)
+		v.Set(reflect.ValueOf(&f32))
 	case reflect.Float64.String():
-		if f64, err := strconv.ParseFloat(val, 64); err == nil {
-			v.Set(reflect.ValueOf(&f64))
+		f64, err := parseFloat(val, 64)
+		if err != nil {
+			return coercionError(t, val, err, strict)
 		}
+		v.Set(reflect.ValueOf(&f64))
 
 	case reflect.String.String():
 		if len(val) > 0 {
@@ -185,9 +604,11 @@ func setPtrValue(t reflect.Type, v reflect.Value, val string) error {
 		}
 
 	case reflect.Bool.String():
-		if b, err := strconv.ParseBool(val); err == nil {
-			v.Set(reflect.ValueOf(&b))
+		b, err := parseBool(val, strict)
+		if err != nil {
+			return coercionError(t, val, err, strict)
 		}
+		v.Set(reflect.ValueOf(&b))
 	default:
 		return fmt.Errorf("unsupported type: %v", t.Kind().String())
 	}
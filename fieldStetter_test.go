@@ -1,6 +1,10 @@
 package configuration
 
 import (
+	"database/sql"
+	"math/big"
+	"net/mail"
+	"os"
 	"reflect"
 	"strconv"
 	"testing"
@@ -15,7 +19,7 @@ func TestSetValue_String(t *testing.T) {
 	fieldVal := reflect.ValueOf(&testStr).Elem()
 	testValue := "test_val1"
 
-	setValue(fieldType, fieldVal, testValue)
+	setValue(fieldType, fieldVal, testValue, false)
 	if !reflect.DeepEqual(fieldVal.String(), testStr) {
 		t.Fatalf("\nexpected result: [%s] \nbut got: [%s]", testValue, testStr)
 	}
@@ -27,7 +31,7 @@ func TestSetValue_Int8(t *testing.T) {
 	fieldVal := reflect.ValueOf(&testInt8).Elem()
 	testValue := "42"
 
-	setValue(fieldType, fieldVal, testValue)
+	setValue(fieldType, fieldVal, testValue, false)
 	if fieldVal.Int() != int64(testInt8) {
 		t.Fatalf("\nexpected result: [%s] \nbut got: [%d]", testValue, testInt8)
 	}
@@ -39,7 +43,7 @@ func TestSetValue_Uint16(t *testing.T) {
 	fieldVal := reflect.ValueOf(&testUint16).Elem()
 	testValue := "42"
 
-	setValue(fieldType, fieldVal, testValue)
+	setValue(fieldType, fieldVal, testValue, false)
 	if fieldVal.Uint() != uint64(testUint16) {
 		t.Fatalf("\nexpected result: [%s] \nbut got: [%d]", testValue, testUint16)
 	}
@@ -52,7 +56,7 @@ func TestSetValue_Int64(t *testing.T) {
 		testValue = "42"
 	)
 
-	setInt64(fieldVal, testValue)
+	setInt64(fieldVal, testValue, false)
 
 	assert.Equal(t, testInt64, fieldVal.Int())
 }
@@ -65,18 +69,99 @@ func TestSetValue_Duration(t *testing.T) {
 		expectedVal, _ = time.ParseDuration(testValue)
 	)
 
-	setInt64(fieldVal, testValue)
+	setInt64(fieldVal, testValue, false)
 
 	assert.Equal(t, expectedVal, time.Duration(fieldVal.Int()))
 }
 
+func TestParseDuration(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected time.Duration
+		fail     bool
+	}{
+		"std units":     {input: "1h30m", expected: 90 * time.Minute},
+		"days":          {input: "2d", expected: 48 * time.Hour},
+		"weeks":         {input: "1w", expected: 7 * 24 * time.Hour},
+		"days & hours":  {input: "30d12h", expected: 30*24*time.Hour + 12*time.Hour},
+		"fraction":      {input: "1.5d", expected: 36 * time.Hour},
+		"negative":      {input: "-1w2d", expected: -9 * 24 * time.Hour},
+		"zero":          {input: "0", expected: 0},
+		"invalid day":   {input: "..d", fail: true},
+		"invalid units": {input: "1d2x", fail: true},
+		"empty":         {input: "", fail: true},
+		"lone sign":     {input: "-", fail: true},
+		"inner sign":    {input: "1d-5h", fail: true},
+		"no number":     {input: "d", fail: true},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			got, err := parseDuration(test.input)
+			if test.fail {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, got)
+		})
+	}
+}
+
+func TestParseFileMode(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected os.FileMode
+		fail     bool
+	}{
+		"octal":            {input: "0640", expected: 0640},
+		"octal no zero":    {input: "755", expected: 0755},
+		"symbolic":         {input: "u=rw,g=r", expected: 0640},
+		"symbolic all":     {input: "a=r,u+w", expected: 0644},
+		"symbolic no who":  {input: "=rx", expected: 0555},
+		"symbolic remove":  {input: "a=rwx,o-rwx,g-w", expected: 0750},
+		"symbolic chained": {input: "u=rwx-x", expected: 0600},
+		"not octal":        {input: "0648", fail: true},
+		"too big":          {input: "10777", fail: true},
+		"wrong who":        {input: "z=rw", fail: true},
+		"wrong perm":       {input: "u=rq", fail: true},
+		"no operator":      {input: "urw", fail: true},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			got, err := parseFileMode(test.input)
+			if test.fail {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, got)
+		})
+	}
+}
+
+func TestSetValue_FileMode(t *testing.T) {
+	var (
+		testMode  os.FileMode
+		fieldType = reflect.TypeOf(&testMode).Elem()
+		fieldVal  = reflect.ValueOf(&testMode).Elem()
+	)
+
+	assert.NoError(t, setValue(fieldType, fieldVal, "u=rw,g=r", false))
+	assert.Equal(t, os.FileMode(0640), testMode)
+	assert.Error(t, setValue(fieldType, fieldVal, "rw-r-----", false))
+}
+
 func TestSetValue_Float32(t *testing.T) {
 	var testFloat32 float32
 	fieldType := reflect.TypeOf(&testFloat32).Elem()
 	fieldVal := reflect.ValueOf(&testFloat32).Elem()
 	testValue := "42"
 
-	setValue(fieldType, fieldVal, testValue)
+	setValue(fieldType, fieldVal, testValue, false)
 	if fieldVal.Float() != float64(testFloat32) {
 		t.Fatalf("\nexpected result: [%s] \nbut got: [%f]", testValue, testFloat32)
 	}
@@ -88,7 +173,7 @@ func TestSetValue_Bool(t *testing.T) {
 	fieldVal := reflect.ValueOf(&testBool).Elem()
 	testValue := "true"
 
-	setValue(fieldType, fieldVal, testValue)
+	setValue(fieldType, fieldVal, testValue, false)
 	if fieldVal.Bool() != true {
 		t.Fatalf("\nexpected result: [%s] \nbut got: [%v]", testValue, testBool)
 	}
@@ -103,7 +188,7 @@ func TestSetPtrValue_Ints(t *testing.T) {
 		fieldType := reflect.TypeOf(&testInt).Elem().Elem()
 		fieldVal := reflect.ValueOf(&testInt).Elem()
 
-		setPtrValue(fieldType, fieldVal, testValue)
+		setPtrValue(fieldType, fieldVal, testValue, false)
 		if testValue != strconv.FormatInt(int64(*testInt), 10) {
 			t.Errorf("\nexpected result: [%s] \nbut got: [%v]", testValue, testInt)
 		}
@@ -115,7 +200,7 @@ func TestSetPtrValue_Ints(t *testing.T) {
 		fieldType := reflect.TypeOf(&testInt8).Elem().Elem()
 		fieldVal := reflect.ValueOf(&testInt8).Elem()
 
-		setPtrValue(fieldType, fieldVal, testValue)
+		setPtrValue(fieldType, fieldVal, testValue, false)
 		if testValue != strconv.FormatInt(int64(*testInt8), 10) {
 			t.Errorf("\nexpected result: [%s] \nbut got: [%v]", testValue, testInt8)
 		}
@@ -127,7 +212,7 @@ func TestSetPtrValue_Ints(t *testing.T) {
 		fieldType := reflect.TypeOf(&testInt16).Elem().Elem()
 		fieldVal := reflect.ValueOf(&testInt16).Elem()
 
-		setPtrValue(fieldType, fieldVal, testValue)
+		setPtrValue(fieldType, fieldVal, testValue, false)
 		if testValue != strconv.FormatInt(int64(*testInt16), 10) {
 			t.Errorf("\nexpected result: [%s] \nbut got: [%v]", testValue, testInt16)
 		}
@@ -139,7 +224,7 @@ func TestSetPtrValue_Ints(t *testing.T) {
 		fieldType := reflect.TypeOf(&testInt32).Elem().Elem()
 		fieldVal := reflect.ValueOf(&testInt32).Elem()
 
-		setPtrValue(fieldType, fieldVal, testValue)
+		setPtrValue(fieldType, fieldVal, testValue, false)
 		if testValue != strconv.FormatInt(int64(*testInt32), 10) {
 			t.Errorf("\nexpected result: [%s] \nbut got: [%v]", testValue, testInt32)
 		}
@@ -151,7 +236,7 @@ func TestSetPtrValue_Ints(t *testing.T) {
 		fieldType := reflect.TypeOf(&testInt64).Elem().Elem()
 		fieldVal := reflect.ValueOf(&testInt64).Elem()
 
-		setPtrValue(fieldType, fieldVal, testValue)
+		setPtrValue(fieldType, fieldVal, testValue, false)
 		if testValue != strconv.FormatInt(*testInt64, 10) {
 			t.Errorf("\nexpected result: [%s] \nbut got: [%v]", testValue, testInt64)
 		}
@@ -167,7 +252,7 @@ func TestSetPtrValue_Uints(t *testing.T) {
 		fieldType := reflect.TypeOf(&testUint).Elem().Elem()
 		fieldVal := reflect.ValueOf(&testUint).Elem()
 
-		setPtrValue(fieldType, fieldVal, testValue)
+		setPtrValue(fieldType, fieldVal, testValue, false)
 		if testValue != strconv.FormatUint(uint64(*testUint), 10) {
 			t.Errorf("\nexpected result: [%s] \nbut got: [%v]", testValue, testUint)
 		}
@@ -179,7 +264,7 @@ func TestSetPtrValue_Uints(t *testing.T) {
 		fieldType := reflect.TypeOf(&testUint8).Elem().Elem()
 		fieldVal := reflect.ValueOf(&testUint8).Elem()
 
-		setPtrValue(fieldType, fieldVal, testValue)
+		setPtrValue(fieldType, fieldVal, testValue, false)
 		if testValue != strconv.FormatUint(uint64(*testUint8), 10) {
 			t.Errorf("\nexpected result: [%s] \nbut got: [%v]", testValue, testUint8)
 		}
@@ -191,7 +276,7 @@ func TestSetPtrValue_Uints(t *testing.T) {
 		fieldType := reflect.TypeOf(&testUint16).Elem().Elem()
 		fieldVal := reflect.ValueOf(&testUint16).Elem()
 
-		setPtrValue(fieldType, fieldVal, testValue)
+		setPtrValue(fieldType, fieldVal, testValue, false)
 		if testValue != strconv.FormatUint(uint64(*testUint16), 10) {
 			t.Errorf("\nexpected result: [%s] \nbut got: [%v]", testValue, testUint16)
 		}
@@ -203,7 +288,7 @@ func TestSetPtrValue_Uints(t *testing.T) {
 		fieldType := reflect.TypeOf(&testUint32).Elem().Elem()
 		fieldVal := reflect.ValueOf(&testUint32).Elem()
 
-		setPtrValue(fieldType, fieldVal, testValue)
+		setPtrValue(fieldType, fieldVal, testValue, false)
 		if testValue != strconv.FormatUint(uint64(*testUint32), 10) {
 			t.Errorf("\nexpected result: [%s] \nbut got: [%v]", testValue, testUint32)
 		}
@@ -215,7 +300,7 @@ func TestSetPtrValue_Uints(t *testing.T) {
 		fieldType := reflect.TypeOf(&testUint64).Elem().Elem()
 		fieldVal := reflect.ValueOf(&testUint64).Elem()
 
-		setPtrValue(fieldType, fieldVal, testValue)
+		setPtrValue(fieldType, fieldVal, testValue, false)
 		if testValue != strconv.FormatUint(*testUint64, 10) {
 			t.Errorf("\nexpected result: [%s] \nbut got: [%v]", testValue, testUint64)
 		}
@@ -231,7 +316,7 @@ func TestSetPtrValue_Floats(t *testing.T) {
 		fieldType := reflect.TypeOf(&testFloat32).Elem().Elem()
 		fieldVal := reflect.ValueOf(&testFloat32).Elem()
 
-		setPtrValue(fieldType, fieldVal, testValue)
+		setPtrValue(fieldType, fieldVal, testValue, false)
 
 		gotStr := strconv.FormatFloat(float64(*testFloat32), 'f', 1, 64)
 		if testValue != gotStr {
@@ -245,7 +330,7 @@ func TestSetPtrValue_Floats(t *testing.T) {
 		fieldType := reflect.TypeOf(&testFloat32).Elem().Elem()
 		fieldVal := reflect.ValueOf(&testFloat32).Elem()
 
-		setPtrValue(fieldType, fieldVal, testValue)
+		setPtrValue(fieldType, fieldVal, testValue, false)
 
 		gotStr := strconv.FormatFloat(*testFloat32, 'f', 1, 64)
 		if testValue != gotStr {
@@ -260,7 +345,7 @@ func TestSetPtrValue_Bool(t *testing.T) {
 	fieldVal := reflect.ValueOf(&testBool).Elem()
 	testValue := "true"
 
-	setPtrValue(fieldType, fieldVal, testValue)
+	setPtrValue(fieldType, fieldVal, testValue, false)
 	if fieldVal.Elem().Bool() != true {
 		t.Fatalf("\nexpected result: [%s] \nbut got: [%v]", testValue, testBool)
 	}
@@ -273,7 +358,7 @@ func TestSetValue_StringSlice(t *testing.T) {
 	testValue := "test_val1;test_val2"
 	expected := []string{"test_val1", "test_val2"}
 
-	setValue(fieldType, fieldVal, testValue)
+	setValue(fieldType, fieldVal, testValue, false)
 	if !reflect.DeepEqual(expected, fieldVal.Interface()) {
 		t.Fatalf("\nexpected result: %+v \nbut got: %+v", expected, fieldVal.Interface())
 	}
@@ -286,7 +371,7 @@ func TestSetValue_StringSliceSingleElement(t *testing.T) {
 	testValue := "test_val1"
 	expected := []string{"test_val1"}
 
-	setValue(fieldType, fieldVal, testValue)
+	setValue(fieldType, fieldVal, testValue, false)
 	if !reflect.DeepEqual(expected, fieldVal.Interface()) {
 		t.Fatalf("\nexpected result: %+v \nbut got: %+v", expected, fieldVal.Interface())
 	}
@@ -299,7 +384,7 @@ func TestSetValue_IntSlice(t *testing.T) {
 	testValue := "1    ; 2 "
 	expected := []int{1, 2}
 
-	setValue(fieldType, fieldVal, testValue)
+	setValue(fieldType, fieldVal, testValue, false)
 	if !reflect.DeepEqual(expected, fieldVal.Interface()) {
 		t.Fatalf("\nexpected result: %+v \nbut got: %+v", expected, fieldVal.Interface())
 	}
@@ -314,7 +399,7 @@ func TestSetValue_UintSlice(t *testing.T) {
 		expected  = []uint{1, 2}
 	)
 
-	setValue(fieldType, fieldVal, testValue)
+	setValue(fieldType, fieldVal, testValue, false)
 	if !reflect.DeepEqual(expected, fieldVal.Interface()) {
 		t.Fatalf("\nexpected result: %+v \nbut got: %+v", expected, fieldVal.Interface())
 	}
@@ -327,7 +412,7 @@ func TestSetValue_FloatSlice(t *testing.T) {
 	testValue := "1;2.0"
 	expected := []float64{1, 2}
 
-	setValue(fieldType, fieldVal, testValue)
+	setValue(fieldType, fieldVal, testValue, false)
 	if !reflect.DeepEqual(expected, fieldVal.Interface()) {
 		t.Fatalf("\nexpected result: %+v \nbut got: %+v", expected, fieldVal.Interface())
 	}
@@ -340,8 +425,165 @@ func TestSetValue_BoolSlice(t *testing.T) {
 	testValue := "true; false; "
 	expected := []bool{true, false}
 
-	setValue(fieldType, fieldVal, testValue)
+	setValue(fieldType, fieldVal, testValue, false)
 	if !reflect.DeepEqual(expected, fieldVal.Interface()) {
 		t.Fatalf("\nexpected result: %+v \nbut got: %+v", expected, fieldVal.Interface())
 	}
 }
+
+func TestSetField_TextUnmarshaler(t *testing.T) {
+	cfg := struct {
+		Price    big.Float
+		PriceRat *big.Rat
+	}{}
+	var (
+		fieldVal  = reflect.ValueOf(&cfg).Elem()
+		fieldType = reflect.TypeOf(&cfg).Elem()
+	)
+
+	assert.Error(t, SetField(fieldType.Field(0), fieldVal.Field(0), "not_a_number"))
+	assert.NoError(t, SetField(fieldType.Field(0), fieldVal.Field(0), "0.1"))
+	assert.NoError(t, SetField(fieldType.Field(1), fieldVal.Field(1), "0.1"))
+
+	assert.Equal(t, "0.1", cfg.Price.String())
+	assert.NotNil(t, cfg.PriceRat)
+	assert.Equal(t, big.NewRat(1, 10), cfg.PriceRat)
+}
+
+func TestSetField_MailAddress(t *testing.T) {
+	cfg := struct {
+		From       mail.Address
+		ReplyTo    *mail.Address
+		Recipients []mail.Address
+		Cc         []*mail.Address
+	}{}
+	var (
+		fieldVal  = reflect.ValueOf(&cfg).Elem()
+		fieldType = reflect.TypeOf(&cfg).Elem()
+	)
+
+	assert.NoError(t, SetField(fieldType.Field(0), fieldVal.Field(0), "Alerts <alerts@example.com>"))
+	assert.NoError(t, SetField(fieldType.Field(1), fieldVal.Field(1), "noreply@example.com"))
+	assert.NoError(t, SetField(fieldType.Field(2), fieldVal.Field(2), `"Doe, John" <john@example.com>; ops@example.com`))
+	assert.NoError(t, SetField(fieldType.Field(3), fieldVal.Field(3), "cc@example.com"))
+
+	assert.Equal(t, mail.Address{Name: "Alerts", Address: "alerts@example.com"}, cfg.From)
+	assert.Equal(t, &mail.Address{Address: "noreply@example.com"}, cfg.ReplyTo)
+	assert.Equal(t, []mail.Address{
+		{Name: "Doe, John", Address: "john@example.com"},
+		{Address: "ops@example.com"},
+	}, cfg.Recipients)
+	assert.Equal(t, []*mail.Address{{Address: "cc@example.com"}}, cfg.Cc)
+
+	assert.Error(t, SetField(fieldType.Field(0), fieldVal.Field(0), "not an address"))
+	assert.Error(t, SetField(fieldType.Field(2), fieldVal.Field(2), "ok@example.com; broken@"))
+}
+
+func TestSetValue_Array(t *testing.T) {
+	var (
+		members   [3]string
+		ports     [2]uint16
+		key       [4]byte
+		typeOf    = func(i interface{}) reflect.Type { return reflect.TypeOf(i).Elem() }
+		valueOf   = func(i interface{}) reflect.Value { return reflect.ValueOf(i).Elem() }
+		expectErr = func(i interface{}, val string) {
+			assert.Error(t, setValue(typeOf(i), valueOf(i), val, false), "value: %q", val)
+		}
+	)
+
+	assert.NoError(t, setValue(typeOf(&members), valueOf(&members), "node1; node2; node3", false))
+	assert.NoError(t, setValue(typeOf(&ports), valueOf(&ports), "80;443", false))
+	assert.NoError(t, setValue(typeOf(&key), valueOf(&key), "deadBEEF", false))
+
+	assert.Equal(t, [3]string{"node1", "node2", "node3"}, members)
+	assert.Equal(t, [2]uint16{80, 443}, ports)
+	assert.Equal(t, [4]byte{0xde, 0xad, 0xbe, 0xef}, key)
+
+	expectErr(&members, "node1;node2")
+	expectErr(&members, "node1;node2;node3;node4")
+	expectErr(&key, "deadbe")
+	expectErr(&key, "not hex!")
+}
+
+func TestSetField_SQLNullTypes(t *testing.T) {
+	cfg := struct {
+		Str     sql.NullString
+		Int     sql.NullInt64
+		Float   sql.NullFloat64
+		Bool    sql.NullBool
+		Time    sql.NullTime
+		StrPtr  *sql.NullString
+		BadInt  sql.NullInt64
+		BadTime sql.NullTime
+	}{}
+	var (
+		fieldVal  = reflect.ValueOf(&cfg).Elem()
+		fieldType = reflect.TypeOf(&cfg).Elem()
+	)
+
+	for i, val := range []string{"str", "42", "4.2", "true", "2020-01-02T03:04:05Z", "str_ptr"} {
+		assert.NoError(t, SetField(fieldType.Field(i), fieldVal.Field(i), val), "field: %s", fieldType.Field(i).Name)
+	}
+	assert.Error(t, SetField(fieldType.Field(6), fieldVal.Field(6), "not_int"))
+	assert.Error(t, SetField(fieldType.Field(7), fieldVal.Field(7), "not_time"))
+
+	assert.Equal(t, sql.NullString{String: "str", Valid: true}, cfg.Str)
+	assert.Equal(t, sql.NullInt64{Int64: 42, Valid: true}, cfg.Int)
+	assert.Equal(t, sql.NullFloat64{Float64: 4.2, Valid: true}, cfg.Float)
+	assert.Equal(t, sql.NullBool{Bool: true, Valid: true}, cfg.Bool)
+	assert.Equal(t, sql.NullTime{Time: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), Valid: true}, cfg.Time)
+	assert.Equal(t, &sql.NullString{String: "str_ptr", Valid: true}, cfg.StrPtr)
+}
+
+func TestSetValue_StrictCoercion(t *testing.T) {
+	var (
+		i8  int8
+		i   int
+		u16 uint16
+		u32 uint32
+		f32 float32
+		b   bool
+		d   time.Duration
+		is  []int
+		fs  []float64
+		bs  []bool
+	)
+	tests := []struct {
+		ptr interface{}
+		val string
+	}{
+		{ptr: &i8, val: "300"},
+		{ptr: &i, val: "1.5"},
+		{ptr: &i, val: "ten"},
+		{ptr: &u16, val: "-1"},
+		{ptr: &u16, val: "70000"},
+		{ptr: &u32, val: "1e10"},
+		{ptr: &f32, val: "1e39"},
+		{ptr: &b, val: "1"},
+		{ptr: &b, val: "yes"},
+		{ptr: &d, val: "5 minutes"},
+		{ptr: &is, val: "1;abc"},
+		{ptr: &fs, val: "1.5;x"},
+		{ptr: &bs, val: "true;yes"},
+	}
+
+	for _, test := range tests {
+		v := reflect.ValueOf(test.ptr).Elem()
+
+		assert.NoError(t, setValue(v.Type(), v, test.val, false), "best-effort [%s] into %v", test.val, v.Type())
+		assert.Error(t, setValue(v.Type(), v, test.val, true), "strict [%s] into %v", test.val, v.Type())
+	}
+
+	for _, val := range []string{"true", "FALSE"} {
+		assert.NoError(t, setValue(reflect.TypeOf(b), reflect.ValueOf(&b).Elem(), val, true))
+	}
+	assert.NoError(t, setValue(reflect.TypeOf(i8), reflect.ValueOf(&i8).Elem(), "1e2", true))
+	assert.Equal(t, int8(100), i8)
+
+	var ptr *int
+	v := reflect.ValueOf(&ptr).Elem()
+	assert.NoError(t, setPtrValue(v.Type().Elem(), v, "abc", false))
+	assert.Nil(t, ptr)
+	assert.Error(t, setPtrValue(v.Type().Elem(), v, "abc", true))
+	assert.Nil(t, ptr)
+}
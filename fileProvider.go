@@ -1,12 +1,17 @@
 package configuration
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 
 	"gopkg.in/yaml.v2"
@@ -14,36 +19,336 @@ import (
 
 // NewFileProvider creates new provider which read values from files (json, yaml)
 func NewFileProvider(fileName string) (fp fileProvider) {
-	file, err := os.Open(fileName)
+	fp.fileName = fileName
+	data, source, err := readFile(fileName)
 	if err != nil {
+		if _, ok := err.(*os.PathError); !ok { // the file may be absent, only errors of decoding are logged
+			fp.readErr = err
+		}
 		return
 	}
-	defer file.Close()
+	if decodeFunc(fileName) == nil {
+		fp.readErr = fmt.Errorf("unsupported file type: %q", fileName)
+	}
+	fp.fileData = data
+	fp.source = source
+	return
+}
 
-	if b, err := ioutil.ReadAll(file); err == nil {
-		if fn := decodeFunc(fileName); fn != nil {
-			err := fn(b, &fp.fileData)
-			if err != nil {
-				log.Println(err)
-			}
+// readFile decodes the file (json, yaml), files of other formats are ignored.
+// The content is returned as well to locate values for errors (see Position).
+func readFile(fileName string) (interface{}, []byte, error) {
+	b, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var data interface{}
+	if fn := decodeFunc(fileName); fn != nil {
+		if err := fn(b, &data); err != nil {
+			return nil, nil, err
 		}
 	}
-	return
+	return data, b, nil
 }
 
 type fileProvider struct {
-	fileData interface{}
+	fileName   string
+	fileData   interface{}
+	readErr    error  // of NewFileProvider, logged by the configurator the provider is passed to
+	source     []byte // content of the file for Position
+	naming     NamingStrategy
+	strictKeys bool
+
+	migrations     map[int]Migration // applied again by Reopen
+	currentVersion int
+
+	opts *options // of the configurator (see optionsBinder)
+}
+
+func (fp fileProvider) withOptions(o *options) Provider {
+	fp.opts = o
+	if fp.readErr != nil {
+		fp.opts.errorf("fileProvider: %v", fp.readErr)
+	}
+	return fp
+}
+
+// WithNaming makes provider convert every part of the path with the given naming strategy
+// before looking for the key in the file, e.g. WithNaming(KebabCase): `MaxConns` -> `max-conns`
+func (fp fileProvider) WithNaming(naming NamingStrategy) fileProvider {
+	fp.naming = naming
+	return fp
+}
+
+// WatchedFiles returns the name of the file for Watch
+func (fp fileProvider) WatchedFiles() []string {
+	return []string{fp.fileName}
+}
+
+// Reopen reads the file again keeping the settings of the provider (naming, migrations, strict keys)
+func (fp fileProvider) Reopen() (Provider, error) {
+	data, source, err := readFile(fp.fileName)
+	if err != nil {
+		return nil, fmt.Errorf("fileProvider: %v", err)
+	}
+	if fp.migrations != nil {
+		if data, err = migrate(data, fp.currentVersion, fp.migrations, fp.opts); err != nil {
+			return nil, fmt.Errorf("fileProvider: %v", err)
+		}
+	}
+	fp.fileData = data
+	fp.source = source
+	fp.readErr = nil
+	return fp, nil
+}
+
+// WithStrictKeys makes InitValues fail if the file has keys which don't match any field of the configuration
+// object, suggesting the closest known key: "unknown key [timeout_secnds], did you mean [timeout_seconds]?".
+// Values of maps and slices are not checked.
+func (fp fileProvider) WithStrictKeys() fileProvider {
+	fp.strictKeys = true
+	return fp
+}
+
+// CheckKeys reports keys of the file which don't match any field (only with WithStrictKeys)
+func (fp fileProvider) CheckKeys(cfgType reflect.Type) []error {
+	if !fp.strictKeys {
+		return nil
+	}
+	return fp.checkKeys(fp.fileData, cfgType, nil)
+}
+
+// IgnoredKeys returns paths of keys of the file which don't match any field (see WithIgnoredKeysReport)
+func (fp fileProvider) IgnoredKeys(cfgType reflect.Type) (source string, keys []string) {
+	fp.walkUnknownKeys(fp.fileData, cfgType, nil, func(path []string, _ []string) {
+		keys = append(keys, strings.Join(path, pathSeparator))
+	})
+	return fp.fileName, keys
+}
+
+func (fp fileProvider) checkKeys(data interface{}, t reflect.Type, path []string) []error {
+	var errs []error
+	fp.walkUnknownKeys(data, t, path, func(path []string, known []string) {
+		text := msg(MsgUnknownKey, strings.Join(path, pathSeparator), fp.fileName)
+		if suggestion, found := closestMatch(path[len(path)-1], known); found {
+			text = msg(MsgKeySuggestion, text, suggestion)
+		}
+		errs = append(errs, kindError{kind: ErrUnknownKey, err: errors.New(text)})
+	})
+	return errs
+}
+
+// walkUnknownKeys calls fn with paths of keys which don't match any field and keys of the struct of the parent
+func (fp fileProvider) walkUnknownKeys(data interface{}, t reflect.Type, path []string, fn func(path []string, known []string)) {
+	m, ok := toStringMap(data)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !ok || t.Kind() != reflect.Struct || isLeafStruct(t) {
+		return
+	}
+
+	fields := map[string]reflect.StructField{} // normalized key -> field
+	var known []string
+	for _, f := range structFields(t, fp.opts.tags()) {
+		key := fp.keyPath([]string{getFieldKey(f.field)})[0]
+		fields[normalizeKey(key)] = f.field
+		known = append(known, key)
+	}
+
+	for _, k := range sortedKeys(m) {
+		currentPath := append(path[:len(path):len(path)], k)
+		f, ok := fields[normalizeKey(k)]
+		switch {
+		case !ok && len(path) == 0 && k == ConfigVersionKey:
+		case !ok && isOldKeyPrefix(strings.Join(currentPath, ".")): // checked by aliasedPath
+		case !ok:
+			fn(currentPath, known)
+		case isStructMap(f.Type):
+			if items, ok := toStringMap(m[k]); ok {
+				for _, item := range sortedKeys(items) {
+					fp.walkUnknownKeys(items[item], f.Type.Elem(), append(currentPath, item), fn)
+				}
+			}
+		default:
+			fp.walkUnknownKeys(m[k], f.Type, currentPath, fn)
+		}
+	}
+}
+
+// keyPath converts the path to the field according to the naming strategy
+func (fp fileProvider) keyPath(path []string) []string {
+	if fp.naming == nil {
+		return path
+	}
+
+	keys := make([]string, len(path))
+	for i := range path {
+		keys[i] = fp.naming(path[i])
+	}
+	return keys
 }
 
 func (fp fileProvider) Provide(field reflect.StructField, v reflect.Value, path ...string) bool {
-	valStr, ok := findValStrByPath(fp.fileData, path)
+	ok, _ := fp.ProvideError(context.Background(), field, v, path...)
+	return ok
+}
+
+// ProvideError returns the error if the value from the file cannot be set to the field
+func (fp fileProvider) ProvideError(_ context.Context, field reflect.StructField, v reflect.Value, path ...string) (bool, error) {
+	path = fp.aliasedPath(fp.keyPath(path))
+	if k := field.Type.Kind(); k == reflect.Map || k == reflect.Slice || k == reflect.Array {
+		return fp.provideRaw(field, v, path)
+	}
+
+	raw, ok, err := lookupPath(fp.fileData, path)
+	if err != nil {
+		fp.opts.errorf("fileProvider: %v in [%s]", err, fp.fileName)
+		return false, fmt.Errorf("%v in [%s]", err, fp.fileName)
+	}
 	if !ok {
-		return false
+		return false, nil
+	}
+	if _, ok := toStringMap(raw); ok { // a mapping is not a value of the field, e.g. of the struct tagged `payload`
+		return false, nil
 	}
 
-	SetField(field, v, valStr)
-	logf("fileProvider: set [%s] to field [%s]", valStr, strings.Join(path, "."))
-	return true
+	valStr := fmt.Sprint(raw)
+
+	if err := setField(field, v, valStr, fp.opts.strict()); err != nil {
+		err = fp.withPosition(path, err)
+		fp.opts.errorf("fileProvider: %v", err)
+		return false, parseError(err)
+	}
+	fp.resolvePaths(field, v)
+	fp.opts.logf("fileProvider: set [%v] to field [%s]", logValue(field, valStr), strings.Join(path, "."))
+	return true, nil
+}
+
+// provideRaw sets maps and slices preserving the structure decoded from the file
+func (fp fileProvider) provideRaw(field reflect.StructField, v reflect.Value, path []string) (bool, error) {
+	raw, ok, err := lookupPath(fp.fileData, path)
+	if err != nil {
+		fp.opts.errorf("fileProvider: %v in [%s]", err, fp.fileName)
+		return false, fmt.Errorf("%v in [%s]", err, fp.fileName)
+	}
+	if !ok {
+		return false, nil
+	}
+
+	if err := setRawValue(field.Type, v, raw, fp.opts.strict()); err != nil {
+		err = fp.withPosition(path, err)
+		fp.opts.errorf("fileProvider: %v", err)
+		return false, parseError(err)
+	}
+	fp.resolvePaths(field, v)
+	fp.opts.logf("fileProvider: set [%v] to field [%s]", logValue(field, raw), strings.Join(path, "."))
+	return true, nil
+}
+
+// resolvePaths makes relative paths set to fields tagged `relpath:"true"` (strings, pointers to strings and slices
+// of strings) relative to the directory of the file instead of the working directory of the process
+func (fp fileProvider) resolvePaths(field reflect.StructField, v reflect.Value) {
+	if !isRelPath(field, fp.opts.tags()) {
+		return
+	}
+	dir := filepath.Dir(fp.fileName)
+	resolve := func(v reflect.Value) {
+		if p := v.String(); p != "" && !filepath.IsAbs(p) {
+			v.SetString(filepath.Join(dir, p))
+		}
+	}
+
+	switch {
+	case v.Kind() == reflect.String:
+		resolve(v)
+	case v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.String:
+		resolve(v.Elem())
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		for i := 0; i < v.Len(); i++ {
+			resolve(v.Index(i))
+		}
+	}
+}
+
+// aliasedPath returns the old path (see SetKeyAliases) if the file has only it
+func (fp fileProvider) aliasedPath(path []string) []string {
+	oldPath, ok := fp.oldPath(path)
+	if !ok {
+		return path
+	}
+	fp.opts.errorf("fileProvider: key [%s] in [%s] is deprecated, use [%s]", strings.Join(oldPath, "."), fp.fileName, strings.Join(path, "."))
+	return oldPath
+}
+
+// oldPath returns the first old path of the key which is present in the file if the file has no new one
+func (fp fileProvider) oldPath(path []string) ([]string, bool) {
+	if _, ok := findValByPath(fp.fileData, path); ok {
+		return nil, false
+	}
+
+	for _, oldKey := range keyAliasesOf(strings.Join(path, ".")) {
+		oldPath := strings.Split(oldKey, ".")
+		if _, ok := findValByPath(fp.fileData, oldPath); ok {
+			return oldPath, true
+		}
+	}
+	return nil, false
+}
+
+// Position returns the location of the value of the field in the file, `config.yml:12:5`,
+// or an empty string if the value cannot be located
+func (fp fileProvider) Position(path ...string) string {
+	path = fp.keyPath(path)
+	if oldPath, ok := fp.oldPath(path); ok {
+		path = oldPath
+	}
+	return fp.position(path)
+}
+
+func (fp fileProvider) position(path []string) string {
+	line, col, ok := findPosition(fp.fileName, fp.source, path)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d:%d", fp.fileName, line, col)
+}
+
+// withPosition adds the position of the value located at the path (already converted by keyPath) to the error
+func (fp fileProvider) withPosition(path []string, err error) error {
+	if position := fp.position(path); position != "" {
+		return positionError{position: position, err: err}
+	}
+	return err
+}
+
+// DescribeKey returns the key of the field in the file: `database.host in config.yml`
+func (fp fileProvider) DescribeKey(_ reflect.StructField, path ...string) string {
+	return fmt.Sprintf("%s in %s", strings.Join(fp.keyPath(path), "."), fp.fileName)
+}
+
+// Keys returns keys of the map located at the path in the file
+func (fp fileProvider) Keys(path ...string) []string {
+	raw, ok := findValByPath(fp.fileData, fp.keyPath(path))
+	if !ok {
+		return nil
+	}
+
+	m, ok := toStringMap(raw)
+	if !ok {
+		return nil
+	}
+	return sortedKeys(m)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func decodeFunc(fileName string) func(data []byte, v interface{}) error {
@@ -59,40 +364,152 @@ func decodeFunc(fileName string) func(data []byte, v interface{}) error {
 		return yaml.Unmarshal
 	}
 
-	logf("fileProvider: unsupported file type: %q", fileName)
 	return nil
 }
 
 func findValStrByPath(i interface{}, path []string) (string, bool) {
-	if len(path) == 0 {
+	val, ok := findValByPath(i, path)
+	if !ok {
 		return "", false
 	}
-	firstInPath := strings.ToLower(path[0])
+	return fmt.Sprint(val), true
+}
 
-	currentFieldStr, ok := i.(map[string]interface{}) // unmarshaled from json
+// findValByPath is the same as lookupPath but ambiguous keys are treated as absent
+func findValByPath(i interface{}, path []string) (interface{}, bool) {
+	val, ok, _ := lookupPath(i, path)
+	return val, ok
+}
+
+// lookupPath finds the value by keys of the path (see lookupKey)
+func lookupPath(i interface{}, path []string) (interface{}, bool, error) {
+	if len(path) == 0 {
+		return nil, false, nil
+	}
+	currentFieldStr, ok := toStringMap(i)
 	if !ok {
-		currentFieldIface, ok := i.(map[interface{}]interface{}) // unmarshaled from yaml
-		if !ok {
-			return "", false
-		}
+		return nil, false, nil
+	}
 
-		currentFieldStr = map[string]interface{}{}
-		for k, v := range currentFieldIface {
-			currentFieldStr[fmt.Sprint(k)] = v
+	val, ok, err := lookupKey(currentFieldStr, path[0])
+	if !ok || err != nil {
+		return nil, false, err
+	}
+
+	if len(path) == 1 {
+		return val, true, nil
+	}
+
+	return lookupPath(val, path[1:])
+}
+
+// lookupKey finds the value by key ignoring case if there is no exact match: `lastname` and `LastName` are the same key.
+// It's an error if several keys match, e.g. `Host` and `HOST` for `host`.
+func lookupKey(m map[string]interface{}, key string) (interface{}, bool, error) {
+	if val, ok := m[key]; ok {
+		return val, true, nil
+	}
+
+	var matched []string
+	for _, k := range sortedKeys(m) {
+		if normalizeKey(k) == normalizeKey(key) {
+			matched = append(matched, k)
 		}
 	}
+	switch len(matched) {
+	case 0:
+		return nil, false, nil
+	case 1:
+		return m[matched[0]], true, nil
+	default:
+		return nil, false, fmt.Errorf("keys [%s] are ambiguous for [%s]", strings.Join(matched, ", "), key)
+	}
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(key)
+}
 
-	for k, v := range currentFieldStr {
-		currentFieldStr[strings.ToLower(k)] = v
+func toStringMap(i interface{}) (map[string]interface{}, bool) {
+	if m, ok := i.(map[string]interface{}); ok { // unmarshaled from json
+		return m, true
 	}
 
-	if len(path) == 1 {
-		val, ok := currentFieldStr[firstInPath]
+	currentFieldIface, ok := i.(map[interface{}]interface{}) // unmarshaled from yaml
+	if !ok {
+		return nil, false
+	}
+
+	m := make(map[string]interface{}, len(currentFieldIface))
+	for k, v := range currentFieldIface {
+		m[fmt.Sprint(k)] = v
+	}
+	return m, true
+}
+
+// setRawValue converts a value decoded from a file (nested maps, lists or scalars) into the type `t`
+func setRawValue(t reflect.Type, v reflect.Value, raw interface{}, strict bool) error {
+	switch t.Kind() {
+	case reflect.Map:
+		m, ok := toStringMap(raw)
 		if !ok {
-			return "", false
+			return fmt.Errorf("cannot set [%v] to the field of type %v", raw, t)
 		}
-		return fmt.Sprint(val), true
+
+		result := reflect.MakeMapWithSize(t, len(m))
+		for key, val := range m {
+			k := reflect.New(t.Key()).Elem()
+			if err := setValue(t.Key(), k, key, strict); err != nil {
+				return err
+			}
+			elem := reflect.New(t.Elem()).Elem()
+			if err := setRawValue(t.Elem(), elem, val, strict); err != nil {
+				return err
+			}
+			result.SetMapIndex(k, elem)
+		}
+		v.Set(result)
+		return nil
+
+	case reflect.Slice:
+		items, ok := raw.([]interface{})
+		if !ok {
+			break // e.g. `one;two` string
+		}
+
+		slice := reflect.MakeSlice(t, len(items), len(items))
+		for i, item := range items {
+			if err := setRawValue(t.Elem(), slice.Index(i), item, strict); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+
+	case reflect.Array:
+		items, ok := raw.([]interface{})
+		if !ok {
+			break // e.g. `one;two` or hex string
+		}
+		if len(items) != t.Len() {
+			return fmt.Errorf("wrong length for %v: expected %d items but got %d", t, t.Len(), len(items))
+		}
+
+		arr := reflect.New(t).Elem()
+		for i, item := range items {
+			if err := setRawValue(t.Elem(), arr.Index(i), item, strict); err != nil {
+				return err
+			}
+		}
+		v.Set(arr)
+		return nil
 	}
 
-	return findValStrByPath(currentFieldStr[firstInPath], path[1:])
+	return setField(reflect.StructField{Type: t}, v, fmt.Sprint(raw), strict)
+}
+
+// digest returns the name of the file and SHA-256 of its content for the manifest of WithTrace
+func (fp fileProvider) digest() (source, digest string) {
+	sum := sha256.Sum256(fp.source)
+	return fp.fileName, hex.EncodeToString(sum[:])
 }
@@ -1,7 +1,14 @@
 package configuration
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -68,6 +75,120 @@ func TestFileProvider_json(t *testing.T) {
 	assert.Equal(t, expected, testObj)
 }
 
+func TestFileProvider_maps(t *testing.T) {
+	type mapsStruct struct {
+		Headers   map[string]string
+		Routes    map[string]map[string]string
+		Upstreams map[string][]string
+		Ports     []int
+	}
+
+	for _, fileName := range []string{"./testdata/maps.yml", "./testdata/maps.json"} {
+		fileName := fileName
+		t.Run(fileName, func(t *testing.T) {
+			var (
+				testObj  mapsStruct
+				provider = NewFileProvider(fileName)
+				objType  = reflect.TypeOf(&testObj).Elem()
+				objVal   = reflect.ValueOf(&testObj).Elem()
+			)
+
+			for i, name := range []string{"Routes", "Upstreams", "Ports"} {
+				ok := provider.Provide(objType.Field(i+1), objVal.Field(i+1), name)
+				assert.True(t, ok, "cannot set value for %s", name)
+			}
+
+			assert.Equal(t, map[string]string{"host": "api.internal", "path": "/v1"}, testObj.Routes["api"])
+			assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, testObj.Upstreams["api"])
+			assert.Equal(t, []int{80, 443}, testObj.Ports)
+		})
+	}
+
+	var ( // yaml only
+		testObj  mapsStruct
+		provider = NewFileProvider("./testdata/maps.yml")
+		objType  = reflect.TypeOf(&testObj).Elem()
+		objVal   = reflect.ValueOf(&testObj).Elem()
+	)
+	assert.True(t, provider.Provide(objType.Field(0), objVal.Field(0), "Headers"))
+	assert.True(t, provider.Provide(objType.Field(2), objVal.Field(2), "Upstreams"))
+	assert.Equal(t, map[string]string{"X-Request-Id": "abc"}, testObj.Headers)
+	assert.Equal(t, []string{"10.0.1.1", "10.0.1.2"}, testObj.Upstreams["web"])
+}
+
+func TestFileProvider_array(t *testing.T) {
+	var (
+		testObj struct {
+			Ports    [2]int
+			WrongLen [3]int
+		}
+		provider = NewFileProvider("./testdata/maps.yml")
+		objType  = reflect.TypeOf(&testObj).Elem()
+		objVal   = reflect.ValueOf(&testObj).Elem()
+	)
+
+	assert.True(t, provider.Provide(objType.Field(0), objVal.Field(0), "Ports"))
+	assert.False(t, provider.Provide(objType.Field(1), objVal.Field(1), "Ports"))
+	assert.Equal(t, [2]int{80, 443}, testObj.Ports)
+}
+
+func TestFileProvider_mapsWrongType(t *testing.T) {
+	var (
+		testObj struct {
+			Ports map[string]int
+		}
+		provider = NewFileProvider("./testdata/maps.yml")
+	)
+
+	ok := provider.Provide(
+		reflect.TypeOf(&testObj).Elem().Field(0),
+		reflect.ValueOf(&testObj).Elem().Field(0),
+		"Ports",
+	)
+	assert.False(t, ok)
+}
+
+func TestFileProvider_Keys(t *testing.T) {
+	provider := NewFileProvider("./testdata/upstreams.yml")
+
+	assert.Equal(t, []string{"Web", "api"}, provider.Keys("Upstreams"))
+	assert.Nil(t, provider.Keys("Upstreams", "api", "host"))
+	assert.Nil(t, provider.Keys("not_found"))
+}
+
+func TestFileProvider_AmbiguousKeys(t *testing.T) {
+	var cfg struct {
+		Host string
+		Port int
+	}
+	c, err := New(&cfg, WithProviders(NewFileProvider("./testdata/ambiguous.yml")))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+	assert.True(t, errors.Is(err, &FieldError{Path: "Host", Provider: "fileProvider"}))
+	assert.Contains(t, err.Error(), "keys [HOST, host] are ambiguous for [Host] in [./testdata/ambiguous.yml]")
+	assert.Empty(t, cfg.Host)
+	assert.Equal(t, 5432, cfg.Port, "the only key matching ignoring case")
+}
+
+func TestFileProvider_naming(t *testing.T) {
+	var (
+		testObj struct {
+			MaxConns int
+		}
+		fieldType = reflect.TypeOf(&testObj).Elem().Field(0)
+		fieldVal  = reflect.ValueOf(&testObj).Elem().Field(0)
+		path      = []string{"HTTPServer", "MaxConns"}
+	)
+
+	assert.True(t, NewFileProvider("./testdata/naming.yml").WithNaming(KebabCase).Provide(fieldType, fieldVal, path...))
+	assert.Equal(t, 2, testObj.MaxConns)
+
+	assert.True(t, NewFileProvider("./testdata/naming.yml").WithNaming(SnakeCase).Provide(fieldType, fieldVal, path...))
+	assert.Equal(t, 1, testObj.MaxConns)
+}
+
 func TestFindValStrByPath(t *testing.T) {
 	var testObjFromYAML interface{}
 	data, _ := yaml.Marshal(testStruct{
@@ -166,3 +287,109 @@ func TestDecodeFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestFileProvider_StrictKeys(t *testing.T) {
+	type upstream struct {
+		URL     string `yaml:"url"`
+		Retries int    `yaml:"retries"`
+	}
+	type config struct {
+		Name           string `yaml:"name"`
+		TimeoutSeconds int    `yaml:"timeout_seconds"`
+		Database       struct {
+			Host    string            `yaml:"host"`
+			Port    int               `yaml:"port"`
+			Options map[string]string `yaml:"options"`
+		} `yaml:"database"`
+		Upstreams map[string]upstream `yaml:"upstreams"`
+	}
+
+	var cfg config
+	c, err := New(&cfg, WithProviders(NewFileProvider("./testdata/strict.yml").WithStrictKeys()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+	assert.True(t, errors.Is(err, ErrUnknownKey))
+	for _, msg := range []string{
+		"unknown key [database.hots] in [./testdata/strict.yml], did you mean [host]?",
+		"unknown key [timeout_secnds] in [./testdata/strict.yml], did you mean [timeout_seconds]?",
+		"unknown key [upstreams.api.retires] in [./testdata/strict.yml], did you mean [retries]?",
+	} {
+		assert.Contains(t, err.Error(), msg)
+	}
+	assert.NotContains(t, err.Error(), "config_version")
+	assert.NotContains(t, err.Error(), "anything")
+	assert.Equal(t, 5432, cfg.Database.Port, "other fields are set unless StopOnFirstError")
+
+	assert.Empty(t, NewFileProvider("./testdata/strict.yml").CheckKeys(reflect.TypeOf(cfg)), "keys are checked only in strict mode")
+}
+
+func TestFileProvider_RelativePaths(t *testing.T) {
+	cfg := struct {
+		TLS struct {
+			Cert string `yaml:"cert" relpath:"true"`
+			Key  string `yaml:"key" relpath:"true"`
+		} `yaml:"tls"`
+		Templates []string `yaml:"templates" relpath:"true"`
+		CA        *string  `yaml:"ca" relpath:"true"`
+		DataDir   string   `yaml:"data_dir"`
+	}{}
+	c, err := New(&cfg, WithProviders(NewFileProvider("./testdata/relpath/config.yml")))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	assert.Equal(t, filepath.Join("testdata", "relpath", "certs", "tls.pem"), cfg.TLS.Cert)
+	assert.Equal(t, "/etc/app/tls.key", cfg.TLS.Key, "absolute paths are kept")
+	assert.Equal(t, []string{
+		filepath.Join("testdata", "relpath", "templates", "mail"),
+		filepath.Join("testdata", "relpath", "templates", "sms"),
+	}, cfg.Templates)
+	if assert.NotNil(t, cfg.CA) {
+		assert.Equal(t, filepath.Join("testdata", "ca.pem"), *cfg.CA)
+	}
+	assert.Equal(t, "data", cfg.DataDir, "fields without the tag are kept")
+}
+
+func TestFileProvider_ReadErrorsAreLoggedByConfigurator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fileProvider")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	defer os.RemoveAll(dir)
+
+	unsupported := filepath.Join(dir, "config.txt")
+	if err := ioutil.WriteFile(unsupported, []byte("name: test"), 0600); err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	broken := filepath.Join(dir, "config.yml")
+	if err := ioutil.WriteFile(broken, []byte("name: [test"), 0600); err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+
+	var stdLog bytes.Buffer
+	log.SetOutput(&stdLog)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := struct{ Name string }{}
+	_, err = New(&cfg, WithProviders(NewFileProvider(unsupported), NewFileProvider(broken)))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Empty(t, stdLog.String(), "logging is disabled")
+
+	var logs []string
+	_, err = New(&cfg, WithProviders(NewFileProvider(unsupported), NewFileProvider(broken)), WithLogger(func(format string, v ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, v...))
+	}))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	if assert.Len(t, logs, 2) {
+		assert.Equal(t, fmt.Sprintf("fileProvider: unsupported file type: %q", unsupported), logs[0])
+		assert.Contains(t, logs[1], "fileProvider: yaml:")
+	}
+	assert.Empty(t, stdLog.String())
+}
@@ -0,0 +1,47 @@
+package configuration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Fingerprint returns the stable hash (hex of SHA-256) of the effective values of all fields, e.g. to expose it in /healthz,
+// so operators can tell whether instances run identical configuration. Values of fields tagged with `secret:"true"`
+// are included only if includeSecrets is true (beware that hashes of weak secrets can be brute-forced).
+// Sources of values don't affect the fingerprint.
+func (c configurator) Fingerprint(includeSecrets bool) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	values := map[string]string{}
+	walkFields(reflect.ValueOf(c.config), nil, c.opts.tags(), func(path string, field reflect.StructField, v reflect.Value) {
+		if isSecret(field) && !includeSecrets {
+			return
+		}
+		values[path] = fingerprintValue(v)
+	})
+
+	paths := make([]string, 0, len(values))
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	for _, path := range paths {
+		fmt.Fprintf(h, "%q=%q\n", path, values[path])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fingerprintValue encodes the value in the stable form: JSON sorts keys of maps
+func fingerprintValue(v reflect.Value) string {
+	if b, err := json.Marshal(v.Interface()); err == nil {
+		return string(b)
+	}
+	return fmt.Sprintf("%#v", v.Interface())
+}
@@ -0,0 +1,39 @@
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigurator_Fingerprint(t *testing.T) {
+	type config struct {
+		Host     string   `env:"HOST" default:"localhost"`
+		Replicas []string `default:"a;b"`
+		Password string   `env:"PASSWORD" default:"" secret:"true"`
+	}
+	newConfigurator := func(env map[string]string) configurator {
+		var cfg config
+		c, err := New(&cfg, WithProviders(NewEnvProvider().WithEnv(env), NewDefaultProvider()))
+		if err != nil {
+			t.Fatal("unexpected err: ", err)
+		}
+		if err := c.InitValues(); err != nil {
+			t.Fatal("unexpected err: ", err)
+		}
+		return c
+	}
+
+	var (
+		base      = newConfigurator(map[string]string{"PASSWORD": "one"})
+		sameHost  = newConfigurator(map[string]string{"HOST": "localhost", "PASSWORD": "two"})
+		otherHost = newConfigurator(map[string]string{"HOST": "remote", "PASSWORD": "one"})
+	)
+
+	assert.Len(t, base.Fingerprint(false), 64)
+	assert.Equal(t, base.Fingerprint(false), base.Fingerprint(false), "fingerprints must be stable")
+	assert.Equal(t, base.Fingerprint(false), sameHost.Fingerprint(false), "sources and secrets don't matter")
+	assert.NotEqual(t, base.Fingerprint(true), sameHost.Fingerprint(true))
+	assert.NotEqual(t, base.Fingerprint(false), otherHost.Fingerprint(false))
+	assert.NotEqual(t, base.Fingerprint(false), base.Fingerprint(true))
+}
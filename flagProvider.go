@@ -1,31 +1,211 @@
 package configuration
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 )
 
-const flagSeparator = "|"
+const (
+	flagSeparator     = "|"
+	negatedFlagPrefix = "no-"
+)
+
+// NewFlagProvider creates a new provider to fetch data from flags like: --flag_name some_value.
+// Flags are parsed right away, errors of parsing are handled like in the flag package (usage is printed
+// and the program exits with code 2) unless it's changed with options:
+//
+//	NewFlagProvider(&cfg, WithFlagSuggestions(), WithFlagErrorHandler(func(err error) { ... }))
+//
+// Flags are registered by tags of fields when the provider is created, so tags renamed with WithTagName
+// of New must be renamed with WithFlagTagName as well (New fails otherwise).
+func NewFlagProvider(ptrToCfg interface{}, opts ...FlagOption) flagProvider {
+	o := flagOptions{exitCode: 2, tags: baseTagNames()}
+	for _, opt := range opts {
+		opt(&o)
+	}
 
-// NewFlagProvider creates a new provider to fetch data from flags like: --flag_name some_value
-func NewFlagProvider(ptrToCfg interface{}) flagProvider {
 	fp := flagProvider{
-		flagsValues: map[string]func() *string{},
+		flagsValues: map[string]func(o *options) *string{},
 		flags:       map[string]*flagData{},
+		tags:        o.tags,
+	}
+	if err := checkTypeCycles(reflect.TypeOf(ptrToCfg), defaultMaxDepth); err != nil {
+		log.Println(err) // New returns the same error
+		return fp
 	}
 	if err := fp.initFlagProvider(ptrToCfg); err != nil {
-		fatalf(err.Error())
+		log.Println(err)
 	}
-
-	flag.Parse()
+	parseFlags(o)
 	return fp
 }
 
+// FlagOption customizes handling of errors of parsing flags by NewFlagProvider
+type FlagOption func(*flagOptions)
+
+type flagOptions struct {
+	usage    func()
+	suggest  bool
+	handler  func(err error)
+	exitCode int
+	tags     tagNames
+}
+
+// WithFlagTagName makes the provider read the tag `name` instead of `tag` like WithTagName of New
+// (TagFlag for names of flags, TagEnv and TagDefault for usages, TagCfg)
+func WithFlagTagName(tag, name string) FlagOption {
+	return func(o *flagOptions) {
+		if _, ok := o.tags[tag]; ok && name != "" {
+			o.tags[tag] = name
+		}
+	}
+}
+
+// WithFlagUsage sets the function which prints the usage (flag.CommandLine.Usage) on errors and `-h`
+func WithFlagUsage(usage func()) FlagOption {
+	return func(o *flagOptions) {
+		o.usage = usage
+	}
+}
+
+// WithFlagSuggestions makes errors about unknown flags suggest the closest defined flag:
+// "flag provided but not defined: -nmae, did you mean -name?"
+func WithFlagSuggestions() FlagOption {
+	return func(o *flagOptions) {
+		o.suggest = true
+	}
+}
+
+// WithFlagErrorHandler makes NewFlagProvider call the handler instead of exiting when flags cannot be parsed
+// (flag.ErrHelp for `-h`), values of flags after the wrong one are not parsed
+func WithFlagErrorHandler(handler func(err error)) FlagOption {
+	return func(o *flagOptions) {
+		o.handler = handler
+	}
+}
+
+// WithFlagExitCode sets the code of exit on errors of parsing (2 by default, `-h` always exits with 0)
+func WithFlagExitCode(code int) FlagOption {
+	return func(o *flagOptions) {
+		o.exitCode = code
+	}
+}
+
+// flagExit terminates the program on errors of parsing flags (replaced in tests)
+var flagExit = os.Exit
+
+// parseFlags parses flag.CommandLine handling errors as set in options instead of the flag package
+func parseFlags(o flagOptions) {
+	fs := flag.CommandLine
+	if o.usage != nil {
+		fs.Usage = o.usage
+	}
+	var (
+		output        = fs.Output()
+		usage         = fs.Usage
+		errorHandling = fs.ErrorHandling()
+	)
+	// the flag package prints errors and exits by itself, the output is muted to print the suggestion first
+	fs.Init(fs.Name(), flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Usage = func() {}
+	err := fs.Parse(os.Args[1:])
+	fs.Init(fs.Name(), errorHandling)
+	fs.SetOutput(output)
+	fs.Usage = usage
+	if err == nil {
+		return
+	}
+
+	if err != flag.ErrHelp {
+		if o.suggest {
+			err = withFlagSuggestion(fs, err)
+		}
+		fmt.Fprintln(output, err)
+	}
+	if usage != nil {
+		usage()
+	} else {
+		fs.PrintDefaults()
+	}
+
+	switch {
+	case o.handler != nil:
+		o.handler(err)
+	case err == flag.ErrHelp:
+		flagExit(0)
+	default:
+		flagExit(o.exitCode)
+	}
+}
+
+// withFlagSuggestion adds the name of the closest defined flag to the error about an unknown flag
+func withFlagSuggestion(fs *flag.FlagSet, err error) error {
+	const notDefined = "flag provided but not defined: -"
+	if !strings.HasPrefix(err.Error(), notDefined) {
+		return err
+	}
+
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	if name, ok := closestMatch(strings.TrimPrefix(err.Error(), notDefined), names); ok {
+		return errors.New(msg(MsgFlagSuggestion, err, name))
+	}
+	return err
+}
+
 type flagProvider struct {
-	flagsValues map[string]func() *string
+	flagsValues map[string]func(o *options) *string // the options log deprecated flags
 	flags       map[string]*flagData
+	tags        tagNames // flags are registered by the tags when the provider is created
+	opts        *options // of the configurator (see optionsBinder)
+}
+
+func (fp flagProvider) withOptions(o *options) Provider {
+	fp.opts = o
+	return fp
+}
+
+// checkFlagTags returns an error if flag providers registered flags by other tags than the configurator reads
+func checkFlagTags(providers []Provider, names tagNames) error {
+	tags := make([]string, 0, len(names))
+	for tag := range names {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	for _, p := range providers {
+		fp, ok := p.(flagProvider)
+		if !ok {
+			continue
+		}
+		for _, tag := range tags {
+			if fp.tags.name(tag) != names.name(tag) {
+				return fmt.Errorf("configurator: flagProvider reads tag [%s] instead of [%s] (see WithFlagTagName)",
+					fp.tags.name(tag), names.name(tag))
+			}
+		}
+	}
+	return nil
+}
+
+// DescribeKey returns the name of the flag for the field: `-db-host`
+func (fp flagProvider) DescribeKey(field reflect.StructField, _ ...string) string {
+	if fd := getFlagData(field, fp.tags); fd != nil {
+		return "-" + fd.key
+	}
+	return ""
 }
 
 type flagData struct {
@@ -46,18 +226,22 @@ func (fp flagProvider) initFlagProvider(i interface{}) error {
 		return fmt.Errorf("not a pointer to a struct: %v", t)
 	}
 
-	for i := 0; i < t.NumField(); i++ {
-		tField := t.Field(i)
-		if tField.Type.Kind() == reflect.Struct {
-			if err := fp.initFlagProvider(v.Field(i).Addr().Interface()); err != nil {
+	for _, f := range structFields(t, fp.tags) {
+		var (
+			tField = f.field
+			vField = v.FieldByIndex(f.index)
+		)
+
+		if tField.Type.Kind() == reflect.Struct && !isLeafStruct(tField.Type) {
+			if err := fp.initFlagProvider(vField.Addr().Interface()); err != nil {
 				return err
 			}
 			continue
 		}
 
-		if tField.Type.Kind() == reflect.Ptr && tField.Type.Elem().Kind() == reflect.Struct {
-			v.Field(i).Set(reflect.New(tField.Type.Elem()))
-			if err := fp.initFlagProvider(v.Field(i).Interface()); err != nil {
+		if tField.Type.Kind() == reflect.Ptr && tField.Type.Elem().Kind() == reflect.Struct && !isLeafStruct(tField.Type) {
+			vField.Set(reflect.New(tField.Type.Elem()))
+			if err := fp.initFlagProvider(vField.Interface()); err != nil {
 				return err
 			}
 			continue
@@ -69,53 +253,189 @@ func (fp flagProvider) initFlagProvider(i interface{}) error {
 }
 
 func (fp flagProvider) setFlagCallbacks(field reflect.StructField) {
-	fd := getFlagData(field)
+	fd := getFlagData(field, fp.tags)
 	if fd == nil {
+		if key := getFlagTag(field, fp.tags); key != "" {
+			fp.opts.errorf("flagProvider: wrong flag definition [%s]", key)
+		}
 		return
 	}
 
 	if _, ok := fp.flagsValues[fd.key]; ok {
-		logf("flagProvider: flag for the key [%s] is already set", fd.key)
+		fp.opts.logf("flagProvider: flag for the key [%s] is already set", fd.key)
 		return
 	}
 	fp.flags[fd.key] = fd
 
-	valStr := flag.String(fd.key, fd.defaultVal, fd.usage)
-	fp.flagsValues[fd.key] = func() *string {
+	valStr := fp.registerString(fd.key, fd.defaultVal, usageWithEffectiveDefault(field, fd, fp.tags))
+	fp.flagsValues[fd.key] = func(*options) *string {
+		return valStr()
+	}
+
+	if isNegatable(field, fd, fp.tags) {
+		fp.setNegatedFlag(fd, valStr)
+	}
+	for _, oldKey := range keyAliasesOf(fd.key) {
+		fp.setAliasFlag(fd, oldKey)
+	}
+}
+
+// setAliasFlag registers the old name of the flag (see SetKeyAliases) which is used if the new flag isn't passed
+func (fp flagProvider) setAliasFlag(fd *flagData, oldKey string) {
+	var (
+		oldVal = fp.registerString(oldKey, "", msg(MsgUsageAlias, fd.key))
+		newVal = fp.flagsValues[fd.key]
+	)
+	fp.flagsValues[fd.key] = func(o *options) *string {
+		if val := oldVal(); *val != "" && !isFlagPassed(fd.key) {
+			o.errorf("flagProvider: flag [-%s] is deprecated, use [-%s]", oldKey, fd.key)
+			return val
+		}
+		return newVal(o)
+	}
+}
+
+// isFlagPassed reports whether the flag is passed in the command line
+func isFlagPassed(name string) bool {
+	passed := false
+	flag.Visit(func(f *flag.Flag) {
+		passed = passed || f.Name == name
+	})
+	return passed
+}
+
+// registerString defines the string flag or, if it's already defined in flag.CommandLine
+// (e.g. by a previously created flag provider), reuses it to avoid the "flag redefined" panic
+func (fp flagProvider) registerString(name, value, usage string) func() *string {
+	if f := flag.Lookup(name); f != nil {
+		fp.opts.logf("flagProvider: flag [%s] is already registered, reusing it", name)
+		return func() *string {
+			val := f.Value.String()
+			return &val
+		}
+	}
+
+	valStr := flag.String(name, value, usage)
+	return func() *string {
 		return valStr
 	}
 }
 
+// usageWithEffectiveDefault adds to the usage the value which will be set if the flag is omitted
+// and its source: env variable (if it's set) or `default` tag. The default value of the flag itself
+// is printed by the flag package as is, its human-friendly form is added to the usage if it differs.
+// Durations and sizes are printed in the human-friendly form (see formatDefault).
+func usageWithEffectiveDefault(field reflect.StructField, fd *flagData, names tagNames) string {
+	usage := withFormatHint(field, fd.usage)
+	if fd.defaultVal != "" {
+		if formatted := formatDefault(field, fd.defaultVal); formatted != fd.defaultVal {
+			return strings.TrimSpace(msg(MsgUsageFormatted, usage, formatted))
+		}
+		return usage
+	}
+
+	var val, source string
+	if key := strings.ToUpper(getEnvTag(field, names)); key != "" {
+		if envVal, ok := os.LookupEnv(key); ok && envVal != "" {
+			val, source = envVal, msg(MsgUsageSourceEnv, key)
+		}
+	}
+	if val == "" {
+		if val = getDefaultTag(field, names); val != "" {
+			source = msg(MsgUsageSourceTag)
+		}
+	}
+
+	if val == "" {
+		return usage
+	}
+	return strings.TrimSpace(msg(MsgUsageDefault, usage, formatDefault(field, val), source))
+}
+
+// setNegatedFlag registers `-no-<flag>` flag which sets `false` to the boolean field
+func (fp flagProvider) setNegatedFlag(fd *flagData, valStr func() *string) {
+	var (
+		disabled = fp.registerBool(negatedFlagPrefix+fd.key, msg(MsgUsageNegated, fd.key))
+		falseStr = "false"
+	)
+	fp.flagsValues[fd.key] = func(*options) *string {
+		if disabled() {
+			return &falseStr
+		}
+		return valStr()
+	}
+}
+
+// registerBool is the same as registerString but for boolean flags
+func (fp flagProvider) registerBool(name, usage string) func() bool {
+	if f := flag.Lookup(name); f != nil {
+		fp.opts.logf("flagProvider: flag [%s] is already registered, reusing it", name)
+		return func() bool {
+			b, _ := strconv.ParseBool(f.Value.String())
+			return b
+		}
+	}
+
+	b := flag.Bool(name, false, usage)
+	return func() bool {
+		return *b
+	}
+}
+
+// isNegatable reports whether the field is boolean and its default value (from `flag` or `default` tag) is true
+func isNegatable(field reflect.StructField, fd *flagData, names tagNames) bool {
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Bool {
+		return false
+	}
+
+	def := fd.defaultVal
+	if def == "" {
+		def = getDefaultTag(field, names)
+	}
+	b, err := strconv.ParseBool(def)
+	return err == nil && b
+}
+
 func (fp flagProvider) Provide(field reflect.StructField, v reflect.Value, _ ...string) bool {
-	fd := getFlagData(field)
+	ok, _ := fp.ProvideError(context.Background(), field, v)
+	return ok
+}
+
+// ProvideError returns the error if the value of the flag cannot be parsed
+func (fp flagProvider) ProvideError(_ context.Context, field reflect.StructField, v reflect.Value, _ ...string) (bool, error) {
+	fd := getFlagData(field, fp.tags)
 	if fd == nil {
-		return false
+		return false, nil
 	}
 
 	if len(fp.flagsValues) == 0 {
-		logf("flagProvider: map of flagsValues is empty, nothing to fetch")
-		return false
+		fp.opts.logf("flagProvider: map of flagsValues is empty, nothing to fetch")
+		return false, nil
 	}
 
 	fn, ok := fp.flagsValues[fd.key]
 	if !ok {
-		logf("flagProvider: callback for key [%s] is not found", fd.key)
-		return false
+		fp.opts.logf("flagProvider: callback for key [%s] is not found", fd.key)
+		return false, nil
 	}
 
-	val := fn()
-	if err := SetField(field, v, *val); err != nil {
-		logf(err.Error())
-		return false
+	val := fn(fp.opts)
+	if err := setField(field, v, *val, fp.opts.strict()); err != nil {
+		fp.opts.errorf("flagProvider: %v", err)
+		return false, parseError(err)
 	}
-	logf("flagProvider: set [%s] to field [%s] with tags [%v]", *val, field.Name, field.Tag)
-	return len(*val) > 0
+	fp.opts.logf("flagProvider: set [%v] to field [%s] with tags [%v]", logValue(field, *val), field.Name, field.Tag)
+	return len(*val) > 0, nil
 }
 
-func getFlagData(field reflect.StructField) *flagData {
-	key := getFlagTag(field)
+// getFlagData parses the flag tag of the field, nil means the field has no valid tag
+func getFlagData(field reflect.StructField, names tagNames) *flagData {
+	key := getFlagTag(field, names)
 	if len(key) == 0 {
-		logf("flagProvider: getFlagTag returns empty value")
 		return nil
 	}
 
@@ -137,7 +457,6 @@ func getFlagData(field reflect.StructField) *flagData {
 			key: strings.TrimSpace(flagInfo[0]),
 		}
 	default:
-		logf("flagProvider: wrong flag definition [%s]", key)
 		return nil
 	}
 }
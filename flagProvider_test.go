@@ -1,9 +1,14 @@
 package configuration
 
 import (
+	"bytes"
+	"flag"
+	"fmt"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -28,6 +33,126 @@ func TestFlagProvider(t *testing.T) {
 	assert.Equal(t, testValue, testObj.Name)
 }
 
+func TestFlagProvider_Negated(t *testing.T) {
+	type testStruct struct {
+		Cache   bool  `flag:"cache|true|enable cache"`
+		Metrics *bool `flag:"metrics" default:"true"`
+		Debug   bool  `flag:"debug|false"`
+	}
+	testObj := testStruct{}
+	os.Args = []string{"smth", "-no-cache", "-no-metrics=false"}
+
+	provider := NewFlagProvider(&testObj)
+	for i := 0; i < 2; i++ {
+		fieldType := reflect.TypeOf(&testObj).Elem().Field(i)
+		fieldVal := reflect.ValueOf(&testObj).Elem().Field(i)
+		provider.Provide(fieldType, fieldVal)
+	}
+
+	assert.Equal(t, false, testObj.Cache)
+	assert.Nil(t, testObj.Metrics) // `-no-metrics=false` doesn't set anything, so the value is left for the next providers
+	assert.NotNil(t, flag.Lookup("no-cache"))
+	assert.NotNil(t, flag.Lookup("no-metrics"))
+	assert.Nil(t, flag.Lookup("no-debug"))
+}
+
+func TestUsageWithEffectiveDefault(t *testing.T) {
+	type testStruct struct {
+		FlagDefault string        `flag:"f1|flag_val|usage"`
+		FromEnv     string        `flag:"f2||usage" env:"USAGE_TEST_ENV" default:"default_val"`
+		FromDefault string        `flag:"f3||usage" env:"USAGE_TEST_NOT_SET" default:"default_val"`
+		NoUsage     string        `flag:"f4" default:"default_val"`
+		NoDefault   string        `flag:"f5||usage"`
+		WithFormat  time.Duration `flag:"f6||timeout" format:"duration" default:"5s"`
+		Duration    time.Duration `flag:"f7||interval" default:"90s"`
+		Size        uint64        `flag:"f8||buffer" format:"bytes" default:"67108864"`
+	}
+	expected := []string{
+		"usage",
+		`usage (default "env_val" from env USAGE_TEST_ENV)`,
+		`usage (default "default_val" from default tag)`,
+		`(default "default_val" from default tag)`,
+		"usage",
+		`timeout (expects e.g. 30s, 5m, 2d) (default "5s" from default tag)`,
+		`interval (default "1m30s" from default tag)`,
+		`buffer (expects e.g. 512, 64KB, 10MiB) (default "64MiB" from default tag)`,
+	}
+
+	removeEnvKey, err := setEnv("USAGE_TEST_ENV", "env_val")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	defer removeEnvKey()
+
+	typ := reflect.TypeOf(testStruct{})
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		assert.Equal(t, expected[i], usageWithEffectiveDefault(field, getFlagData(field, nil), nil), field.Name)
+	}
+}
+
+func TestFlagProvider_FormattedDefaults(t *testing.T) {
+	type testStruct struct {
+		Timeout time.Duration `flag:"formatted_timeout|300s"`
+		TTL     int64         `flag:"formatted_ttl|48h" format:"duration"`
+		Buffer  int           `flag:"formatted_buffer|65536" format:"bytes"`
+		Port    int           `flag:"formatted_port|8080"`
+	}
+	testObj := testStruct{}
+	os.Args = []string{"smth"}
+
+	provider := NewFlagProvider(&testObj)
+	for i := 0; i < 4; i++ {
+		fieldType := reflect.TypeOf(&testObj).Elem().Field(i)
+		fieldVal := reflect.ValueOf(&testObj).Elem().Field(i)
+		assert.True(t, provider.Provide(fieldType, fieldVal), fieldType.Name)
+	}
+
+	for name, expected := range map[string][2]string{
+		"formatted_timeout": {"300s", "(5m)"},
+		"formatted_ttl":     {"48h", "expects e.g. 30s, 5m, 2d (2d)"},
+		"formatted_buffer":  {"65536", "expects e.g. 512, 64KB, 10MiB (64KiB)"},
+		"formatted_port":    {"8080", ""},
+	} {
+		f := flag.Lookup(name)
+		assert.Equal(t, expected[0], f.DefValue, "the default of the flag is the value of the tag")
+		assert.Equal(t, expected[1], f.Usage, "the human-friendly form is added to the usage")
+	}
+	assert.Equal(t, testStruct{Timeout: 5 * time.Minute, TTL: int64(48 * time.Hour), Buffer: 65536, Port: 8080}, testObj,
+		"formatted defaults are parsed to the same values")
+}
+
+func TestFlagProvider_Recursive(t *testing.T) {
+	var node recursiveNode
+	os.Args = []string{"smth"}
+
+	provider := NewFlagProvider(&node) // must not hang
+	assert.Empty(t, provider.flags)
+	assert.Nil(t, node.Next)
+}
+
+func TestFlagProvider_Idempotent(t *testing.T) {
+	type testStruct struct {
+		Name    string `flag:"idempotent_name"`
+		Enabled bool   `flag:"idempotent_enabled|true"`
+	}
+	testObj := testStruct{}
+	os.Args = []string{"smth", "-idempotent_name=flag_value", "-no-idempotent_enabled"}
+
+	var (
+		fieldType = reflect.TypeOf(&testObj).Elem()
+		fieldVal  = reflect.ValueOf(&testObj).Elem()
+	)
+	for i := 0; i < 2; i++ {
+		testObj = testStruct{Enabled: true}
+		provider := NewFlagProvider(&testObj) // must not panic on the second call
+
+		assert.True(t, provider.Provide(fieldType.Field(0), fieldVal.Field(0)))
+		provider.Provide(fieldType.Field(1), fieldVal.Field(1))
+		assert.Equal(t, testStruct{Name: "flag_value", Enabled: false}, testObj)
+	}
+}
+
 func TestGetFlagData(t *testing.T) {
 	tests := map[string]struct {
 		input    interface{}
@@ -82,9 +207,74 @@ func TestGetFlagData(t *testing.T) {
 		test := test
 		t.Run(name, func(t *testing.T) {
 			field := reflect.TypeOf(test.input).Field(0)
-			gotFlagData := getFlagData(field)
+			gotFlagData := getFlagData(field, nil)
 
 			assert.Equal(t, test.expected, gotFlagData)
 		})
 	}
 }
+
+func TestFlagProvider_ParseErrors(t *testing.T) {
+	type testStruct struct {
+		Name string `flag:"parse_err_name||name of the service"`
+	}
+
+	var exitCode int
+	flagExit = func(code int) { exitCode = code }
+	defer func() { flagExit = os.Exit }()
+
+	output := flag.CommandLine.Output()
+	defer flag.CommandLine.SetOutput(output)
+
+	tests := map[string]struct {
+		args     []string
+		opts     []FlagOption
+		exitCode int
+		handled  string
+		output   string
+	}{
+		"default": {
+			args:     []string{"-parse_err_nmae=x"},
+			exitCode: 2,
+			output:   "flag provided but not defined: -parse_err_nmae\n",
+		},
+		"suggestion and exit code": {
+			args:     []string{"-parse_err_nmae=x"},
+			opts:     []FlagOption{WithFlagSuggestions(), WithFlagExitCode(64), WithFlagUsage(func() {})},
+			exitCode: 64,
+			output:   "flag provided but not defined: -parse_err_nmae, did you mean -parse_err_name?\n",
+		},
+		"handler": {
+			args:    []string{"-unknown_flag_xyz"},
+			opts:    []FlagOption{WithFlagSuggestions(), WithFlagErrorHandler(func(err error) { panic(err) })},
+			handled: "flag provided but not defined: -unknown_flag_xyz",
+		},
+		"help": {
+			args:   []string{"-h"},
+			opts:   []FlagOption{WithFlagUsage(func() { fmt.Fprint(flag.CommandLine.Output(), "custom usage") })},
+			output: "custom usage",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			flag.CommandLine.SetOutput(&buf)
+			usage := flag.CommandLine.Usage
+			defer func() { flag.CommandLine.Usage = usage }()
+
+			exitCode = -1
+			os.Args = append([]string{"smth"}, test.args...)
+			var testObj testStruct
+			if test.handled != "" {
+				assert.PanicsWithError(t, test.handled, func() { NewFlagProvider(&testObj, test.opts...) })
+				return
+			}
+			NewFlagProvider(&testObj, test.opts...)
+
+			assert.Equal(t, test.exitCode, exitCode)
+			assert.True(t, strings.HasPrefix(buf.String(), test.output), buf.String())
+		})
+	}
+	os.Args = []string{"smth"}
+}
@@ -0,0 +1,201 @@
+package configuration
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Values of `format` tag which describe the expected input of the field:
+//
+//	Timeout int64  `format:"duration"` // "30s", "5m", "2d" (nanoseconds for integer fields)
+//	MaxSize uint64 `format:"bytes"`    // "512", "64KB", "10MiB"
+//
+// The hint is added to the usage of flags, generated schemas and docs, and to errors of parsing.
+// Values of such fields are always validated: the error is returned even without WithStrictCoercion.
+const (
+	FormatDuration = "duration"
+	FormatBytes    = "bytes"
+)
+
+// IDs of messages with hints for formats
+var formatHints = map[string]string{
+	FormatDuration: MsgFormatDuration,
+	FormatBytes:    MsgFormatBytes,
+}
+
+// formatHint returns the hint for the value of `format` tag of the field or an empty string
+func formatHint(field reflect.StructField) string {
+	if id, ok := formatHints[getFormatTag(field)]; ok {
+		return msg(id)
+	}
+	return ""
+}
+
+// withFormatHint adds the hint of `format` tag to the usage: "request timeout (expects e.g. 30s, 5m, 2d)"
+func withFormatHint(field reflect.StructField, usage string) string {
+	hint := formatHint(field)
+	switch {
+	case hint == "":
+		return usage
+	case usage == "":
+		return hint
+	}
+	return fmt.Sprintf("%s (%s)", usage, hint)
+}
+
+// setFormatted sets integer fields (or pointers to them) with `format` tag.
+// Returns false if the field has no known format or it's not an integer.
+func setFormatted(field reflect.StructField, v reflect.Value, val string) (bool, error) {
+	format := getFormatTag(field)
+	hint := formatHint(field)
+	if hint == "" {
+		return false, nil
+	}
+
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	isInt := t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64
+	isUint := t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64
+	if !isInt && !isUint {
+		return false, nil
+	}
+
+	var n float64
+	switch format {
+	case FormatDuration:
+		d, err := parseDuration(val)
+		if err != nil {
+			return true, errors.New(msg(MsgInvalidFormat, format, val, hint))
+		}
+		n = float64(d)
+	case FormatBytes:
+		b, err := parseBytes(val)
+		if err != nil {
+			return true, errors.New(msg(MsgInvalidFormat, format, val, hint))
+		}
+		n = b
+	}
+
+	elem := reflect.New(t).Elem()
+	switch {
+	case isInt && n >= math.MinInt64 && n < math.MaxInt64 && !elem.OverflowInt(int64(n)):
+		elem.SetInt(int64(n))
+	case isUint && n >= 0 && n < math.MaxUint64 && !elem.OverflowUint(uint64(n)):
+		elem.SetUint(uint64(n))
+	default:
+		return true, fmt.Errorf("cannot convert [%s] to %v: %v", val, t, errOutOfRange)
+	}
+
+	if field.Type.Kind() == reflect.Ptr {
+		v.Set(elem.Addr())
+		return true, nil
+	}
+	v.Set(elem)
+	return true, nil
+}
+
+// formatDefault converts the default value of the field to the human-friendly form for usage of flags:
+// `90s` -> `1m30s` for durations, `67108864` -> `64MiB` for `format:"bytes"`. The value is parsed the same way
+// as by SetField, values which cannot be parsed and values of other fields are returned as is.
+func formatDefault(field reflect.StructField, val string) string {
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	isInt := t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64
+
+	switch {
+	case val == "":
+	case t == durationType || isInt && getFormatTag(field) == FormatDuration:
+		if d, err := parseDuration(val); err == nil {
+			return formatDuration(d)
+		}
+	case isInt && getFormatTag(field) == FormatBytes:
+		if n, err := parseBytes(val); err == nil {
+			return formatBytes(n)
+		}
+	}
+	return val
+}
+
+// formatDuration formats the duration without zero units: `5m` instead of `5m0s`, whole days as `2d`
+func formatDuration(d time.Duration) string {
+	const day = 24 * time.Hour
+	if d >= day && d%day == 0 {
+		return fmt.Sprintf("%dd", d/day)
+	}
+
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+// formatBytes formats the size with the largest unit which divides it: `64MiB`, `10KB`, `1500`
+func formatBytes(n float64) string {
+	for _, unit := range []string{"PiB", "TiB", "GiB", "MiB", "KiB", "PB", "TB", "GB", "MB", "KB"} {
+		mult := byteUnits[strings.ToLower(unit)]
+		if n >= mult && math.Mod(n, mult) == 0 {
+			return strconv.FormatFloat(n/mult, 'f', -1, 64) + unit
+		}
+	}
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+var byteUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"m":   1e6,
+	"mb":  1e6,
+	"g":   1e9,
+	"gb":  1e9,
+	"t":   1e12,
+	"tb":  1e12,
+	"p":   1e15,
+	"pb":  1e15,
+	"ki":  1 << 10,
+	"kib": 1 << 10,
+	"mi":  1 << 20,
+	"mib": 1 << 20,
+	"gi":  1 << 30,
+	"gib": 1 << 30,
+	"ti":  1 << 40,
+	"tib": 1 << 40,
+	"pi":  1 << 50,
+	"pib": 1 << 50,
+}
+
+// parseBytes parses sizes with decimal (KB = 1000) or binary (KiB = 1024) units: "512", "64KB", "1.5GiB".
+// Units are case-insensitive, the result is rounded down to whole bytes.
+func parseBytes(val string) (float64, error) {
+	s := strings.TrimSpace(val)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.' && r != '_'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+
+	mult, ok := byteUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok || i == 0 {
+		return 0, fmt.Errorf("invalid size: %q", val)
+	}
+	n, err := parseFloat(s[:i], 64)
+	if err != nil {
+		return 0, err
+	}
+	return math.Floor(n * mult), nil
+}
@@ -0,0 +1,147 @@
+package configuration
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBytes(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected float64
+		fail     bool
+	}{
+		"plain":      {input: "512", expected: 512},
+		"bytes":      {input: "512B", expected: 512},
+		"decimal":    {input: "64KB", expected: 64000},
+		"binary":     {input: "10MiB", expected: 10 << 20},
+		"short":      {input: "2Gi", expected: 2 << 30},
+		"fraction":   {input: "1.5 kib", expected: 1536},
+		"separators": {input: "1_000 MB", expected: 1e9},
+		"no number":  {input: "KB", fail: true},
+		"negative":   {input: "-1KB", fail: true},
+		"bad unit":   {input: "10XB", fail: true},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			got, err := parseBytes(test.input)
+			if test.fail {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, got)
+		})
+	}
+}
+
+func TestSetField_Format(t *testing.T) {
+	cfg := struct {
+		Timeout time.Duration `default:"1m30s" format:"duration"`
+		TTL     int64         `default:"2d" format:"duration"`
+		MaxSize uint64        `default:"64KB" format:"bytes"`
+		Buffer  *int          `default:"4KiB" format:"bytes"`
+		Plain   string        `default:"1MB" format:"bytes"` // not an integer, the format is ignored
+		Unknown int           `default:"7" format:"whatever"`
+	}{}
+
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, 90*time.Second, cfg.Timeout)
+	assert.Equal(t, int64(48*time.Hour), cfg.TTL)
+	assert.Equal(t, uint64(64000), cfg.MaxSize)
+	if assert.NotNil(t, cfg.Buffer) {
+		assert.Equal(t, 4096, *cfg.Buffer)
+	}
+	assert.Equal(t, "1MB", cfg.Plain)
+	assert.Equal(t, 7, cfg.Unknown)
+}
+
+func TestSetField_FormatErrors(t *testing.T) {
+	tests := map[string]struct {
+		cfg      interface{}
+		expected string
+	}{
+		"duration": {
+			cfg: &struct {
+				Timeout time.Duration `default:"5 minutes" format:"duration"`
+			}{},
+			expected: "invalid duration [5 minutes], expects e.g. 30s, 5m, 2d",
+		},
+		"bytes": {
+			cfg: &struct {
+				MaxSize uint64 `default:"lots" format:"bytes"`
+			}{},
+			expected: "invalid bytes [lots], expects e.g. 512, 64KB, 10MiB",
+		},
+		"out of range": {
+			cfg: &struct {
+				MaxSize uint8 `default:"1KB" format:"bytes"`
+			}{},
+			expected: "cannot convert [1KB] to uint8: value out of range",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			c, err := New(test.cfg, WithProviders(NewDefaultProvider()))
+			if err != nil {
+				t.Fatal("unexpected err: ", err)
+			}
+			err = c.InitValues()
+			assert.True(t, errors.Is(err, ErrParse), "the value must be validated without WithStrictCoercion")
+			assert.Contains(t, err.Error(), test.expected)
+		})
+	}
+}
+
+func TestFormatDefault(t *testing.T) {
+	type config struct {
+		Duration time.Duration `format:"duration"`
+		Ptr      *time.Duration
+		TTL      int64  `format:"duration"`
+		Size     uint64 `format:"bytes"`
+		Plain    int64
+		Text     string `format:"bytes"`
+	}
+	typ := reflect.TypeOf(config{})
+
+	tests := map[string]struct {
+		field    string
+		input    string
+		expected string
+	}{
+		"duration":         {field: "Duration", input: "90s", expected: "1m30s"},
+		"whole minutes":    {field: "Duration", input: "300s", expected: "5m"},
+		"whole hours":      {field: "Ptr", input: "120m", expected: "2h"},
+		"days":             {field: "TTL", input: "48h", expected: "2d"},
+		"hours and days":   {field: "TTL", input: "36h", expected: "36h"},
+		"invalid duration": {field: "TTL", input: "100", expected: "100"},
+		"binary size":      {field: "Size", input: "67108864", expected: "64MiB"},
+		"decimal size":     {field: "Size", input: "10000", expected: "10KB"},
+		"odd size":         {field: "Size", input: "1500", expected: "1500"},
+		"zero size":        {field: "Size", input: "0", expected: "0"},
+		"invalid size":     {field: "Size", input: "lots", expected: "lots"},
+		"no format":        {field: "Plain", input: "65536", expected: "65536"},
+		"not an integer":   {field: "Text", input: "65536", expected: "65536"},
+		"empty":            {field: "Duration", input: "", expected: ""},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			field, _ := typ.FieldByName(test.field)
+			assert.Equal(t, test.expected, formatDefault(field, test.input))
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package configuration
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type frozenState struct {
+	config reflect.Value // deep copy of the configuration object made by Freeze, invalid if it's not frozen
+}
+
+// Freeze returns a deep copy of the configuration object (a pointer of the same type as cfgPtr of New)
+// which can be passed to the rest of the program instead of the original object: changes of the copy
+// don't affect the configurator and vice versa.
+//
+// In debug builds (`-tags configuration_debug`) the configurator also remembers the copy to detect
+// mutations of the original object, see CheckFrozen. Reload, Set and InitValues update the remembered copy.
+func (c configurator) Freeze() interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.frozen.config = deepCopy(reflect.ValueOf(c.config).Elem())
+	return deepCopy(c.frozen.config).Addr().Interface()
+}
+
+// CheckFrozen returns the error (ErrFrozenMutated) listing fields of the original configuration object which are
+// changed after Freeze bypassing the configurator (Reload, Set). It always returns nil in regular builds
+// and if Freeze isn't called.
+func (c configurator) CheckFrozen() error {
+	if !debugBuild {
+		return nil
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.checkFrozen()
+}
+
+func (c configurator) checkFrozen() error {
+	if !debugBuild || !c.frozen.config.IsValid() {
+		return nil
+	}
+
+	frozen := map[string]reflect.Value{}
+	walkFields(c.frozen.config, nil, c.opts.tags(), func(path string, _ reflect.StructField, v reflect.Value) {
+		frozen[path] = v
+	})
+
+	var mutated []string
+	walkFields(reflect.ValueOf(c.config), nil, c.opts.tags(), func(path string, _ reflect.StructField, v reflect.Value) {
+		fv, ok := frozen[path]
+		delete(frozen, path)
+		if !ok || !reflect.DeepEqual(fv.Interface(), v.Interface()) {
+			mutated = append(mutated, path)
+		}
+	})
+	for path := range frozen { // removed from the maps of structs
+		mutated = append(mutated, path)
+	}
+	if len(mutated) == 0 {
+		return nil
+	}
+
+	sort.Strings(mutated)
+	return kindError{
+		kind: ErrFrozenMutated,
+		err:  fmt.Errorf("configurator: frozen configuration is mutated: [%s]", strings.Join(mutated, ", ")),
+	}
+}
+
+// beginChange is called under the lock by methods which change the configuration object: it logs mutations
+// of the frozen configuration made so far, the returned function updates the frozen copy.
+func (c configurator) beginChange() func() {
+	if !c.frozen.config.IsValid() {
+		return func() {}
+	}
+	if err := c.checkFrozen(); err != nil {
+		c.opts.errorf("%v", err)
+	}
+	return func() {
+		c.frozen.config = deepCopy(reflect.ValueOf(c.config).Elem())
+	}
+}
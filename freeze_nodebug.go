@@ -0,0 +1,7 @@
+//go:build !configuration_debug
+// +build !configuration_debug
+
+package configuration
+
+// debugBuild enables detection of mutations of frozen configurations (see CheckFrozen)
+const debugBuild = false
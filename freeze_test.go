@@ -0,0 +1,52 @@
+package configuration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreeze(t *testing.T) {
+	type config struct {
+		Name  string   `default:"app"`
+		Hosts []string `default:"a;b"`
+		DB    struct {
+			Port *int `default:"5432"`
+		}
+	}
+
+	var cfg config
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.NoError(t, c.CheckFrozen(), "not frozen yet")
+
+	frozen, ok := c.Freeze().(*config)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, cfg, *frozen)
+
+	frozen.Hosts[0] = "changed"
+	*frozen.DB.Port = 1
+	assert.Equal(t, "a", cfg.Hosts[0], "the copy is deep")
+	assert.Equal(t, 5432, *cfg.DB.Port)
+	assert.NoError(t, c.CheckFrozen(), "changes of the copy are allowed")
+
+	cfg.Name = "mutated"
+	cfg.Hosts[1] = "mutated"
+	err = c.CheckFrozen()
+	if debugBuild {
+		assert.True(t, errors.Is(err, ErrFrozenMutated))
+		assert.EqualError(t, err, "configurator: frozen configuration is mutated: [Hosts, Name]")
+	} else {
+		assert.NoError(t, err, "mutations are detected only in debug builds")
+	}
+
+	assert.NoError(t, c.Reload())
+	assert.NoError(t, c.CheckFrozen(), "Reload updates the frozen copy")
+	assert.Equal(t, "app", cfg.Name)
+}
@@ -0,0 +1,394 @@
+package configuration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// fieldInfo describes a field of the configuration object for generators
+type fieldInfo struct {
+	path       []string
+	envName    string // which the env provider looks up (see envProvider.DescribeKey), empty if it doesn't read the field
+	flagName   string
+	defaultVal string
+	usage      string
+	secret     bool // `secret:"true"`
+	field      reflect.StructField
+}
+
+// describeFields returns descriptions of all fields of the configuration object in order of definition,
+// names of ENV variables are the ones which `env` looks up
+func describeFields(cfgPtr interface{}, env envProvider) ([]fieldInfo, error) {
+	t := reflect.TypeOf(cfgPtr)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("not a pointer to the struct")
+	}
+	if err := checkTypeCycles(t.Elem(), defaultMaxDepth); err != nil {
+		return nil, err
+	}
+
+	var fields []fieldInfo
+	walkTypePaths(t.Elem(), nil, env.opts.tags(), func(path []string, field reflect.StructField) {
+		info := fieldInfo{
+			path:       path,
+			defaultVal: defaultWithoutJitter(field),
+			field:      field,
+		}
+		info.envName = env.DescribeKey(field, info.path...)
+		info.secret = isSecret(field)
+		if fd := getFlagData(field, nil); fd != nil {
+			info.flagName = fd.key
+			info.usage = fd.usage
+			if info.defaultVal == "" {
+				info.defaultVal = fd.defaultVal
+			}
+		}
+		info.usage = withFormatHint(field, info.usage)
+		fields = append(fields, info)
+	})
+	return fields, nil
+}
+
+// defaultWithoutJitter returns the value of `default` tag without the jitter (`30s±10%` -> `30s`),
+// so generated manifests and schemas contain values which other providers can parse
+func defaultWithoutJitter(field reflect.StructField) string {
+	base, _, _ := parseJitter(field.Type, getDefaultTag(field, nil))
+	return base
+}
+
+// GenerateK8sManifests generates ConfigMap and Secret manifests (YAML) with the given name for the configuration object.
+// Keys are names of ENV variables which `env` looks up (with its prefix and naming, fields which it doesn't read are skipped),
+// values are taken from `default` tags. Fields tagged with `secret:"true"` go to the Secret.
+func GenerateK8sManifests(cfgPtr interface{}, name string, env envProvider) ([]byte, error) {
+	fields, err := describeFields(cfgPtr, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var data, secrets yaml.MapSlice
+	for _, f := range fields {
+		if f.envName == "" {
+			continue
+		}
+		item := yaml.MapItem{Key: f.envName, Value: f.defaultVal}
+		if f.secret {
+			secrets = append(secrets, item)
+			continue
+		}
+		data = append(data, item)
+	}
+
+	var buf bytes.Buffer
+	for i, manifest := range []yaml.MapSlice{
+		{
+			{Key: "apiVersion", Value: "v1"},
+			{Key: "kind", Value: "ConfigMap"},
+			{Key: "metadata", Value: yaml.MapSlice{{Key: "name", Value: name}}},
+			{Key: "data", Value: data},
+		},
+		{
+			{Key: "apiVersion", Value: "v1"},
+			{Key: "kind", Value: "Secret"},
+			{Key: "metadata", Value: yaml.MapSlice{{Key: "name", Value: name}}},
+			{Key: "type", Value: "Opaque"},
+			{Key: "stringData", Value: secrets},
+		},
+	} {
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		b, err := yaml.Marshal(manifest)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
+
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Type                 interface{}            `json:"type,omitempty"` // name of the type or a list of names
+	Description          string                 `json:"description,omitempty"`
+	Default              interface{}            `json:"default,omitempty"`
+	Examples             []string               `json:"examples,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+}
+
+// GenerateHelmSchema generates `values.schema.json` (JSON Schema) for Helm charts from the configuration object.
+// Property names are the same keys as used by the file provider (from `json`/`yaml` tags or names of fields),
+// values of maps of structs are described by `additionalProperties`.
+func GenerateHelmSchema(cfgPtr interface{}) ([]byte, error) {
+	fields, err := describeFields(cfgPtr, NewEnvProvider())
+	if err != nil {
+		return nil, err
+	}
+
+	root := objectSchema(reflect.TypeOf(cfgPtr).Elem(), fields, map[reflect.Type]bool{})
+	root.Schema = "http://json-schema.org/draft-07/schema#"
+	return json.MarshalIndent(root, "", "  ")
+}
+
+// objectSchema returns the schema of the struct described by the fields, maps of structs are followed
+// unless the type of values is already on the path
+func objectSchema(t reflect.Type, fields []fieldInfo, visited map[reflect.Type]bool) *jsonSchema {
+	root := &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}}
+	add := func(path []string, prop *jsonSchema) {
+		node := root
+		for _, key := range path[:len(path)-1] {
+			child, ok := node.Properties[key]
+			if !ok {
+				child = &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}}
+				node.Properties[key] = child
+			}
+			node = child
+		}
+		node.Properties[path[len(path)-1]] = prop
+	}
+
+	for _, f := range fields {
+		prop := schemaForField(f.field)
+		prop.Description = f.usage
+		if f.defaultVal != "" {
+			prop.Default = typedDefault(prop, f.defaultVal)
+		}
+		add(f.path, prop)
+	}
+
+	visited[t] = true
+	defer delete(visited, t)
+	walkStructMapPaths(t, nil, baseTagNames(), func(path []string, field reflect.StructField) {
+		elem := field.Type.Elem()
+		if elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		values := &jsonSchema{Type: "object"}
+		if !visited[elem] {
+			elemFields, _ := describeFields(reflect.New(elem).Interface(), NewEnvProvider())
+			values = objectSchema(elem, elemFields, visited)
+		}
+		add(path, &jsonSchema{Type: "object", AdditionalProperties: values})
+	})
+	return root
+}
+
+// schemaForField is the same as schemaForType but takes into account `format` tag:
+// such values are strings (sizes also can be integers)
+func schemaForField(field reflect.StructField) *jsonSchema {
+	switch getFormatTag(field) {
+	case FormatDuration:
+		return &jsonSchema{Type: "string", Examples: []string{"30s", "5m", "2d"}}
+	case FormatBytes:
+		return &jsonSchema{Type: []string{"integer", "string"}, Examples: []string{"512", "64KB", "10MiB"}}
+	}
+	return schemaForType(field.Type)
+}
+
+func schemaForType(t reflect.Type) *jsonSchema {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isLeafStruct(t) || t == reflect.TypeOf(time.Duration(0)) || t == reflect.TypeOf(os.FileMode(0)) {
+		return &jsonSchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: schemaForType(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: schemaForType(t.Elem())}
+	default:
+		return &jsonSchema{Type: "string"}
+	}
+}
+
+// typedDefault converts the value of `default` tag to the type of the schema
+func typedDefault(schema *jsonSchema, val string) interface{} {
+	switch schema.Type {
+	case "boolean":
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	case "integer":
+		if i, err := parseInt(val, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := parseFloat(val, 64); err == nil {
+			return f
+		}
+	case "array":
+		items := []interface{}{}
+		for _, item := range splitSlice(val) {
+			items = append(items, typedDefault(schema.Items, item))
+		}
+		return items
+	}
+	return val
+}
+
+// GenerateTerraformVariables generates Terraform variable declarations for the configuration object.
+// Names of variables are names of ENV variables which `env` looks up in lower case (`DATABASE_HOST` -> `database_host`),
+// fields which it doesn't read are skipped, fields tagged with `secret:"true"` are marked as sensitive.
+func GenerateTerraformVariables(cfgPtr interface{}, env envProvider) ([]byte, error) {
+	fields, err := describeFields(cfgPtr, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for _, f := range fields {
+		if f.envName == "" {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteString("\n")
+		}
+		schema := schemaForField(f.field)
+		fmt.Fprintf(&buf, "variable %q {\n", strings.ToLower(f.envName))
+		fmt.Fprintf(&buf, "  type        = %s\n", terraformType(schema))
+		if f.defaultVal != "" {
+			def, err := json.Marshal(typedDefault(schema, f.defaultVal))
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&buf, "  default     = %s\n", def)
+		}
+		if f.usage != "" {
+			fmt.Fprintf(&buf, "  description = %q\n", f.usage)
+		}
+		if f.secret {
+			buf.WriteString("  sensitive   = true\n")
+		}
+		buf.WriteString("}\n")
+	}
+	return buf.Bytes(), nil
+}
+
+func terraformType(schema *jsonSchema) string {
+	switch schema.Type {
+	case "boolean":
+		return "bool"
+	case "integer", "number":
+		return "number"
+	case "array":
+		return "list(" + terraformType(schema.Items) + ")"
+	case "object":
+		return "map(" + terraformType(schema.AdditionalProperties) + ")"
+	default:
+		return "string"
+	}
+}
+
+// GenerateSystemdEnvironmentFile generates a file for `EnvironmentFile=` directive of systemd units
+// listing all ENV variables which `env` looks up with their default values.
+// Values of fields tagged with `secret:"true"` are left empty.
+func GenerateSystemdEnvironmentFile(cfgPtr interface{}, env envProvider) ([]byte, error) {
+	fields, err := describeFields(cfgPtr, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for _, f := range fields {
+		if f.envName == "" {
+			continue
+		}
+		if f.usage != "" {
+			fmt.Fprintf(&buf, "# %s\n", f.usage)
+		}
+		fmt.Fprintf(&buf, "%s=%s\n", f.envName, systemdValue(f))
+	}
+	return buf.Bytes(), nil
+}
+
+// GenerateSystemdDropIn generates a drop-in snippet (e.g. `/etc/systemd/system/app.service.d/env.conf`)
+// with `Environment=` directives for all ENV variables which `env` looks up.
+// `%` is escaped as `%%` since systemd expands specifiers in unit files.
+func GenerateSystemdDropIn(cfgPtr interface{}, env envProvider) ([]byte, error) {
+	fields, err := describeFields(cfgPtr, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("[Service]\n")
+	for _, f := range fields {
+		if f.envName == "" {
+			continue
+		}
+		val := f.defaultVal
+		if f.secret {
+			val = ""
+		}
+		fmt.Fprintf(&buf, "Environment=%s\n", strings.Replace(strconv.Quote(f.envName+"="+val), "%", "%%", -1))
+	}
+	return buf.Bytes(), nil
+}
+
+func systemdValue(f fieldInfo) string {
+	if f.secret {
+		return ""
+	}
+	if strings.ContainsAny(f.defaultVal, " \t\"'\\#") {
+		return strconv.Quote(f.defaultVal)
+	}
+	return f.defaultVal
+}
+
+// GenerateMarkdownDocs generates the Markdown table describing all fields of the configuration object:
+// paths (keys in files), ENV variables which `env` looks up, flags, default values (masked for secrets)
+// and descriptions (usage of flags)
+func GenerateMarkdownDocs(cfgPtr interface{}, env envProvider) ([]byte, error) {
+	fields, err := describeFields(cfgPtr, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(msg(MsgDocsHeader) + "\n")
+	buf.WriteString("|-----|------|-----|------|---------|-------------|\n")
+	for _, f := range fields {
+		flagName := ""
+		if f.flagName != "" {
+			flagName = "`-" + f.flagName + "`"
+		}
+		defaultVal := f.defaultVal
+		if defaultVal != "" && f.secret {
+			defaultVal = MaskSecret(getMask(f.field), defaultVal)
+		}
+		if defaultVal != "" {
+			defaultVal = "`" + defaultVal + "`"
+		}
+		envName := ""
+		if f.envName != "" {
+			envName = "`" + f.envName + "`"
+		}
+		description := f.usage
+		if f.secret {
+			description = strings.TrimSpace(description + " " + msg(MsgDocsSecret))
+		}
+		fmt.Fprintf(&buf, "| `%s` | %s | %s | %s | %s | %s |\n",
+			strings.Join(f.path, pathSeparator), f.field.Type, envName, flagName, defaultVal,
+			strings.Replace(description, "|", "\\|", -1))
+	}
+	return buf.Bytes(), nil
+}
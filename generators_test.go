@@ -0,0 +1,193 @@
+package configuration
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type generatorsConfig struct {
+	Name     string        `default:"app" flag:"name||name of the service"`
+	LogLevel string        `env:"LOG_LEVEL" default:"info"`
+	Timeout  time.Duration `default:"5s"`
+	Replicas int           `flag:"replicas|3|number of replicas"`
+	Hosts    []string      `default:"a;b"`
+	MaxSize  uint64        `format:"bytes" default:"64KB"`
+	Database struct {
+		Host     string `json:"host" default:"localhost"`
+		Password string `secret:"true"`
+	}
+}
+
+func TestDescribeFields(t *testing.T) {
+	fields, err := describeFields(&generatorsConfig{}, NewEnvProvider().WithDerivedNames())
+	assert.NoError(t, err)
+
+	var envNames, defaults []string
+	for _, f := range fields {
+		envNames = append(envNames, f.envName)
+		defaults = append(defaults, f.defaultVal)
+	}
+	assert.Equal(t, []string{"NAME", "LOG_LEVEL", "TIMEOUT", "REPLICAS", "HOSTS", "MAX_SIZE", "DATABASE_HOST", "DATABASE_PASSWORD"}, envNames)
+	assert.Equal(t, []string{"app", "info", "5s", "3", "a;b", "64KB", "localhost", ""}, defaults)
+	assert.True(t, fields[7].secret)
+	assert.Equal(t, "name of the service", fields[0].usage)
+	assert.Equal(t, "expects e.g. 512, 64KB, 10MiB", fields[5].usage)
+
+	_, err = describeFields(generatorsConfig{}, NewEnvProvider())
+	assert.Error(t, err)
+
+	fields, err = describeFields(&generatorsConfig{}, NewEnvProvider().WithPrefix("APP"))
+	assert.NoError(t, err)
+	envNames = nil
+	for _, f := range fields {
+		envNames = append(envNames, f.envName)
+	}
+	assert.Equal(t, []string{"", "APP_LOG_LEVEL", "", "", "", "", "", ""}, envNames, "only names which the provider looks up")
+}
+
+func TestGenerateK8sManifests(t *testing.T) {
+	got, err := GenerateK8sManifests(&generatorsConfig{}, "my-app", NewEnvProvider().WithDerivedNames())
+	assert.NoError(t, err)
+
+	expected, err := ioutil.ReadFile("./testdata/generators/k8s.yaml")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Equal(t, string(expected), string(got))
+
+	cfg := struct {
+		Host string `env:"HOST" default:"localhost"`
+		Name string `default:"app"` // not read by the env provider without derived names
+	}{}
+	got, err = GenerateK8sManifests(&cfg, "my-app", NewEnvProvider().WithPrefix("APP"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(got), "data:\n  APP_HOST: localhost\n---")
+	assert.NotContains(t, string(got), "NAME")
+}
+
+func TestGenerateHelmSchema(t *testing.T) {
+	got, err := GenerateHelmSchema(&generatorsConfig{})
+	assert.NoError(t, err)
+
+	expected, err := ioutil.ReadFile("./testdata/generators/values.schema.json")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.JSONEq(t, string(expected), string(got))
+}
+
+func TestGenerateTerraformVariables(t *testing.T) {
+	got, err := GenerateTerraformVariables(&generatorsConfig{}, NewEnvProvider().WithDerivedNames())
+	assert.NoError(t, err)
+
+	expected, err := ioutil.ReadFile("./testdata/generators/variables.tf")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Equal(t, string(expected), string(got))
+
+	cfg := struct {
+		Name string `default:"app"` // not read by the env provider without derived names
+		Host string `env:"HOST" default:"localhost"`
+	}{}
+	got, err = GenerateTerraformVariables(&cfg, NewEnvProvider().WithPrefix("APP"))
+	assert.NoError(t, err)
+	assert.Equal(t, "variable \"app_host\" {\n  type        = string\n  default     = \"localhost\"\n}\n", string(got))
+}
+
+func TestGenerateSystemd(t *testing.T) {
+	tests := []struct {
+		name     string
+		generate func(interface{}, envProvider) ([]byte, error)
+		expected string
+	}{
+		{name: "EnvironmentFile", generate: GenerateSystemdEnvironmentFile, expected: "./testdata/generators/app.env"},
+		{name: "DropIn", generate: GenerateSystemdDropIn, expected: "./testdata/generators/env.conf"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.generate(&generatorsConfig{}, NewEnvProvider().WithDerivedNames())
+			assert.NoError(t, err)
+
+			expected, err := ioutil.ReadFile(test.expected)
+			if err != nil {
+				t.Fatal("unexpected err: ", err)
+			}
+			assert.Equal(t, string(expected), string(got))
+		})
+	}
+}
+
+func TestGenerateSystemd_Escaping(t *testing.T) {
+	cfg := struct {
+		Format string `env:"LOG_FORMAT" default:"%h %s"`
+		Name   string `default:"app"` // not read by the env provider without derived names
+	}{}
+
+	got, err := GenerateSystemdDropIn(&cfg, NewEnvProvider().WithPrefix("APP"))
+	assert.NoError(t, err)
+	assert.Equal(t, "[Service]\nEnvironment=\"APP_LOG_FORMAT=%%h %%s\"\n", string(got))
+
+	got, err = GenerateSystemdEnvironmentFile(&cfg, NewEnvProvider().WithPrefix("APP"))
+	assert.NoError(t, err)
+	assert.Equal(t, "APP_LOG_FORMAT=\"%h %s\"\n", string(got), "specifiers aren't expanded in environment files")
+}
+
+func TestGenerateMarkdownDocs(t *testing.T) {
+	got, err := GenerateMarkdownDocs(&generatorsConfig{}, NewEnvProvider().WithDerivedNames())
+	assert.NoError(t, err)
+
+	expected, err := ioutil.ReadFile("./testdata/generators/docs.md")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Equal(t, string(expected), string(got))
+}
+
+func TestGenerateHelmSchema_StructMaps(t *testing.T) {
+	type upstream struct {
+		URL     string        `json:"url" default:"http://localhost"`
+		Timeout time.Duration `json:"timeout"`
+	}
+	cfg := struct {
+		Upstreams map[string]upstream `json:"upstreams"`
+		Proxy     struct {
+			Routes map[string]*upstream `json:"routes"`
+		} `json:"proxy"`
+	}{}
+
+	got, err := GenerateHelmSchema(&cfg)
+	assert.NoError(t, err)
+
+	values := `{"type": "object", "properties": {
+		"url": {"type": "string", "default": "http://localhost"},
+		"timeout": {"type": "string"}
+	}}`
+	assert.JSONEq(t, `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"properties": {
+			"upstreams": {"type": "object", "additionalProperties": `+values+`},
+			"proxy": {"type": "object", "properties": {
+				"routes": {"type": "object", "additionalProperties": `+values+`}
+			}}
+		}
+	}`, string(got))
+}
+
+func TestGenerateMarkdownDocs_Secrets(t *testing.T) {
+	cfg := struct {
+		Token  string `default:"s3cret-token" secret:"true"`
+		APIKey string `default:"key-12345678" secret:"last4"`
+	}{}
+
+	got, err := GenerateMarkdownDocs(&cfg, NewEnvProvider().WithDerivedNames())
+	assert.NoError(t, err)
+	assert.NotContains(t, string(got), "s3cret-token")
+	assert.Contains(t, string(got), "| `"+MaskSecret(MaskFull, "")+"` |")
+	assert.Contains(t, string(got), "`"+MaskSecret(MaskLast4, "key-12345678")+"`")
+}
@@ -0,0 +1,71 @@
+package configuration
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// fillUpGroup fills the nested struct tagged `group:"true"` (e.g. settings of an optional integration like SMTP):
+// either all its fields are set or none of them are set by providers other than the default one.
+// If the group is absent, fields which are not set don't fail and the pointer to the struct is left nil.
+// If it's incomplete, the single error lists set and missing fields.
+func (c configurator) fillUpGroup(ctx context.Context, field reflect.StructField, v reflect.Value, currentPath []string) error {
+	var (
+		path     = strings.Join(currentPath, pathSeparator)
+		prefix   = path + pathSeparator
+		failures []error
+		group    = c
+	)
+	for p := range c.sources { // sources of the previous call
+		if strings.HasPrefix(p, prefix) {
+			delete(c.sources, p)
+		}
+	}
+
+	group.failures, group.unset, group.group = &failures, nil, true
+	target := v.Addr()
+	if field.Type.Kind() == reflect.Ptr {
+		v.Set(reflect.New(field.Type.Elem()))
+		target = v
+	}
+	if err := group.fillUp(ctx, target.Interface(), currentPath...); err != nil {
+		return err
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+
+	var missing []string
+	for _, err := range failures {
+		var fe *FieldError
+		if !errors.As(err, &fe) || !errors.Is(fe, ErrNotSet) {
+			return err // e.g. the value cannot be parsed
+		}
+		missing = append(missing, fe.Path)
+	}
+
+	var set []string
+	for p, source := range c.sources {
+		if strings.HasPrefix(p, prefix) && source != providerName(defaultProvider{}) {
+			set = append(set, p)
+		}
+	}
+	if len(set) == 0 {
+		c.opts.logf("configurator: group [%s] is not set", path)
+		if field.Type.Kind() == reflect.Ptr {
+			v.Set(reflect.Zero(field.Type))
+		}
+		return nil
+	}
+
+	sort.Strings(set)
+	return &FieldError{
+		Path: path,
+		Tag:  string(field.Tag),
+		Err:  kindError{kind: ErrIncompleteGroup, err: errors.New(msg(MsgIncompleteGroup, strings.Join(set, ", "), strings.Join(missing, ", ")))},
+		name: field.Name,
+	}
+}
@@ -0,0 +1,86 @@
+package configuration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type smtpConfig struct {
+	Host string `env:"SMTP_HOST"`
+	Port int    `env:"SMTP_PORT" default:"25"`
+	User string `env:"SMTP_USER"`
+}
+
+type groupsConfig struct {
+	Name   string     `default:"app"`
+	SMTP   smtpConfig `group:"true"`
+	Export *struct {
+		Bucket string `env:"EXPORT_BUCKET"`
+	} `group:"true"`
+}
+
+func TestConfigurator_Groups(t *testing.T) {
+	tests := map[string]struct {
+		env      map[string]string
+		check    func(t *testing.T, cfg groupsConfig)
+		expected string
+	}{
+		"absent": {
+			env: map[string]string{},
+			check: func(t *testing.T, cfg groupsConfig) {
+				assert.Equal(t, smtpConfig{Port: 25}, cfg.SMTP, "defaults are kept")
+				assert.Nil(t, cfg.Export)
+			},
+		},
+		"complete": {
+			env: map[string]string{"SMTP_HOST": "smtp.internal", "SMTP_USER": "robot", "EXPORT_BUCKET": "s3://reports"},
+			check: func(t *testing.T, cfg groupsConfig) {
+				assert.Equal(t, smtpConfig{Host: "smtp.internal", Port: 25, User: "robot"}, cfg.SMTP)
+				if assert.NotNil(t, cfg.Export) {
+					assert.Equal(t, "s3://reports", cfg.Export.Bucket)
+				}
+			},
+		},
+		"incomplete": {
+			env:      map[string]string{"SMTP_PORT": "587"},
+			expected: "configurator: field [SMTP]: either all fields of the group or none of them must be set: set [SMTP.Port], missing [SMTP.Host, SMTP.User]",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			var cfg groupsConfig
+			c, err := New(&cfg, WithProviders(NewEnvProvider().WithEnv(test.env), NewDefaultProvider()))
+			if err != nil {
+				t.Fatal("unexpected err: ", err)
+			}
+			err = c.InitValues()
+			if test.expected != "" {
+				assert.True(t, errors.Is(err, ErrIncompleteGroup))
+				assert.EqualError(t, err, test.expected)
+				return
+			}
+			assert.NoError(t, err)
+			test.check(t, cfg)
+		})
+	}
+}
+
+func TestConfigurator_GroupsContinueOnError(t *testing.T) {
+	cfg := struct {
+		Name string
+		SMTP smtpConfig `group:"true"`
+	}{}
+
+	c, err := New(&cfg, WithProviders(NewEnvProvider().WithEnv(map[string]string{"SMTP_HOST": "smtp.internal"})))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+	assert.True(t, errors.Is(err, ErrIncompleteGroup))
+	assert.True(t, errors.Is(err, &FieldError{Path: "Name"}))
+	assert.Contains(t, err.Error(), "set [SMTP.Host], missing [SMTP.Port, SMTP.User]")
+}
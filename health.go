@@ -0,0 +1,61 @@
+package configuration
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ProviderHealth is the result of the health check of the provider (see CheckProviders)
+type ProviderHealth struct {
+	Provider string        `json:"provider"`
+	Checked  bool          `json:"checked"` // false for providers which don't implement HealthChecker (e.g. files)
+	Healthy  bool          `json:"healthy"`
+	Error    string        `json:"error,omitempty"`
+	Latency  time.Duration `json:"latency"`
+}
+
+// CheckProviders pings backends of all providers which implement HealthChecker concurrently without setting values,
+// so readiness probes can verify dependencies of the configuration before traffic is accepted. Results are
+// in the order of providers, other providers are reported as healthy. Checks respect the deadline of the context.
+func (c configurator) CheckProviders(ctx context.Context) []ProviderHealth {
+	c.mu.RLock()
+	providers := append([]Provider(nil), c.providers...)
+	c.mu.RUnlock()
+
+	var (
+		results = make([]ProviderHealth, len(providers))
+		wg      sync.WaitGroup
+	)
+	for i, provider := range providers {
+		results[i] = ProviderHealth{Provider: providerName(provider), Healthy: true}
+		hc, ok := provider.(HealthChecker)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *ProviderHealth, hc HealthChecker) {
+			defer wg.Done()
+			started := time.Now()
+			err := hc.CheckHealth(ctx)
+			result.Checked, result.Latency = true, time.Since(started)
+			if err != nil {
+				result.Healthy, result.Error = false, err.Error()
+				c.opts.errorf("configurator: health check of [%s] failed: %v", result.Provider, err)
+			}
+		}(&results[i], hc)
+	}
+	wg.Wait()
+	return results
+}
+
+// Healthy reports whether all providers are healthy
+func Healthy(results []ProviderHealth) bool {
+	for _, r := range results {
+		if !r.Healthy {
+			return false
+		}
+	}
+	return true
+}
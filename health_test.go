@@ -0,0 +1,58 @@
+package configuration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckProviders(t *testing.T) {
+	cfg := struct {
+		Host string `default:"localhost"`
+	}{}
+	var fetched int
+	healthy := NewDocumentProvider("healthy.yml", func(context.Context) ([]byte, error) {
+		fetched++
+		return []byte("host: remote"), nil
+	})
+	down := NewDocumentProvider("down.json", func(ctx context.Context) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	c, err := New(&cfg, WithProviders(healthy, down, NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	results := c.CheckProviders(ctx)
+	if !assert.Len(t, results, 3) {
+		return
+	}
+
+	assert.Equal(t, ProviderHealth{Provider: "documentProvider", Checked: true, Healthy: true, Latency: results[0].Latency}, results[0])
+	assert.Equal(t, ProviderHealth{Provider: "documentProvider", Checked: true, Healthy: false,
+		Error: context.DeadlineExceeded.Error(), Latency: results[1].Latency}, results[1])
+	assert.Equal(t, ProviderHealth{Provider: "defaultProvider", Healthy: true}, results[2])
+
+	assert.False(t, Healthy(results))
+	assert.True(t, Healthy(results[:1]))
+	assert.Equal(t, 1, fetched)
+	assert.Equal(t, "", cfg.Host, "values aren't set")
+}
+
+func TestDocumentProvider_CheckHealth(t *testing.T) {
+	p := NewDocumentProvider("config.json", func(context.Context) ([]byte, error) {
+		return []byte("host: remote"), nil
+	})
+	assert.Error(t, p.CheckHealth(context.Background()), "the document must be parsed")
+
+	p = NewDocumentProvider("config.json", func(context.Context) ([]byte, error) {
+		return nil, errors.New("connection refused")
+	})
+	assert.EqualError(t, p.CheckHealth(context.Background()), "connection refused")
+}
@@ -1,25 +1,277 @@
 package configuration
 
-import "os"
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+)
 
 type Logger func(format string, v ...interface{})
 
-var (
-	gLoggingEnabled  bool
-	gFailIfCannotSet bool
+// LogLevel is the verbosity of logs of a provider (see WithLogLevel)
+type LogLevel int
 
-	gLogger Logger
+const (
+	// LogOff disables logs
+	LogOff LogLevel = iota
+	// LogErrors logs only failures: values which cannot be parsed, fields which cannot be set, etc.
+	LogErrors
+	// LogTrace logs every step of the resolution of fields
+	LogTrace
 )
 
-func logf(format string, args ...interface{}) {
-	if gLoggingEnabled {
-		gLogger(format, args...)
+// logf logs the trace of the resolution, the source is the prefix of the message before `:` (e.g. `envProvider`).
+// Providers which aren't bound to a configurator (nil options) don't log.
+func (o *options) logf(format string, args ...interface{}) {
+	if o.shouldLog(LogTrace, format, args) {
+		o.logger(format, args...)
 	}
 }
 
-func fatalf(format string, args ...interface{}) {
-	if gFailIfCannotSet {
-		gLogger(format, args...)
-		os.Exit(1)
+// errorf is the same as logf but for failures
+func (o *options) errorf(format string, args ...interface{}) {
+	if o.shouldLog(LogErrors, format, args) {
+		o.logger(format, args...)
+	}
+}
+
+func (o *options) shouldLog(level LogLevel, format string, args []interface{}) bool {
+	if o == nil || !o.loggingEnabled {
+		return false
+	}
+
+	sourceLevel := o.logLevel
+	if len(o.logLevels) > 0 {
+		msg := fmt.Sprintf(format, args...)
+		if i := strings.Index(msg, ":"); i > 0 {
+			if l, ok := o.logLevels[msg[:i]]; ok {
+				sourceLevel = l
+			}
+		}
+	}
+	if level > sourceLevel {
+		return false
+	}
+
+	currentPath := o.currentPath()
+	if len(o.logPaths) == 0 || currentPath == "" {
+		return true
 	}
+	for _, pattern := range o.logPaths {
+		if ok, _ := path.Match(pattern, currentPath); ok || strings.EqualFold(pattern, currentPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// fatalf exits if the configurator is created with FailIfCannotSet
+func (o *options) fatalf(format string, args ...interface{}) {
+	if o != nil && o.failIfCannotSet {
+		o.logger(format, args...)
+		exit(format, args...)
+	}
+}
+
+// walkFields walks the configuration object the same way as the configurator does
+// and calls fn for every field which can be set by providers
+func walkFields(v reflect.Value, path []string, names tagNames, fn func(path string, field reflect.StructField, v reflect.Value)) {
+	walkFieldPaths(v, path, names, func(path []string, field reflect.StructField, v reflect.Value) {
+		fn(strings.Join(path, pathSeparator), field, v)
+	})
+}
+
+// walkFieldPaths is the same as walkFields but passes keys of the path as is,
+// so keys which contain `.` (e.g. of maps) aren't lost by joining them
+func walkFieldPaths(v reflect.Value, path []string, names tagNames, fn func(path []string, field reflect.StructField, v reflect.Value)) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	for _, f := range structFields(v.Type(), names) {
+		var (
+			tField      = f.field
+			vField      = v.FieldByIndex(f.index)
+			currentPath = append(path[:len(path):len(path)], getFieldKey(tField))
+		)
+
+		switch {
+		case tField.Type.Kind() == reflect.Struct && !isLeafStruct(tField.Type),
+			tField.Type.Kind() == reflect.Ptr && tField.Type.Elem().Kind() == reflect.Struct && !isLeafStruct(tField.Type):
+			walkFieldPaths(vField, currentPath, names, fn)
+
+		case isStructMap(tField.Type):
+			for _, key := range vField.MapKeys() {
+				walkFieldPaths(vField.MapIndex(key), append(currentPath, key.String()), names, fn)
+			}
+
+		default:
+			fn(currentPath, tField, vField)
+		}
+	}
+}
+
+// walkTypes walks the type of the configuration object and calls fn for every field which can be set
+// by providers. Pointers to structs are followed unless the type is already on the path.
+func walkTypes(t reflect.Type, path []string, names tagNames, fn func(path string, field reflect.StructField)) {
+	walkTypePaths(t, path, names, func(path []string, field reflect.StructField) {
+		fn(strings.Join(path, pathSeparator), field)
+	})
+}
+
+// walkTypePaths is the same as walkTypes but passes keys of the path as is (see walkFieldPaths)
+func walkTypePaths(t reflect.Type, path []string, names tagNames, fn func(path []string, field reflect.StructField)) {
+	walkTypesVisited(t, path, names, map[reflect.Type]bool{}, fn)
+}
+
+func walkTypesVisited(t reflect.Type, path []string, names tagNames, visited map[reflect.Type]bool, fn func(path []string, field reflect.StructField)) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	for _, f := range structFields(t, names) {
+		var (
+			tField      = f.field
+			currentPath = append(path[:len(path):len(path)], getFieldKey(tField))
+		)
+		if isStructMap(tField.Type) {
+			continue
+		}
+
+		switch {
+		case tField.Type.Kind() == reflect.Struct && !isLeafStruct(tField.Type),
+			tField.Type.Kind() == reflect.Ptr && tField.Type.Elem().Kind() == reflect.Struct && !isLeafStruct(tField.Type):
+			walkTypesVisited(tField.Type, currentPath, names, visited, fn)
+
+		default:
+			fn(currentPath, tField)
+		}
+	}
+}
+
+// walkStructMapPaths calls fn for every map of structs (see isStructMap) which walkTypePaths skips
+func walkStructMapPaths(t reflect.Type, path []string, names tagNames, fn func(path []string, field reflect.StructField)) {
+	walkStructMapsVisited(t, path, names, map[reflect.Type]bool{}, fn)
+}
+
+func walkStructMapsVisited(t reflect.Type, path []string, names tagNames, visited map[reflect.Type]bool, fn func(path []string, field reflect.StructField)) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	for _, f := range structFields(t, names) {
+		var (
+			tField      = f.field
+			currentPath = append(path[:len(path):len(path)], getFieldKey(tField))
+		)
+
+		switch {
+		case isStructMap(tField.Type):
+			fn(currentPath, tField)
+
+		case tField.Type.Kind() == reflect.Struct && !isLeafStruct(tField.Type),
+			tField.Type.Kind() == reflect.Ptr && tField.Type.Elem().Kind() == reflect.Struct && !isLeafStruct(tField.Type):
+			walkStructMapsVisited(tField.Type, currentPath, names, visited, fn)
+		}
+	}
+}
+
+// defaultMaxDepth limits nesting of structs in the configuration object (see WithMaxDepth)
+const defaultMaxDepth = 32
+
+// checkTypeCycles returns an error if the type of the configuration object refers to itself through
+// pointers to structs (e.g. `type Node struct { Next *Node }`) or is nested deeper than maxDepth
+func checkTypeCycles(t reflect.Type, maxDepth int) error {
+	return checkTypeCyclesVisited(t, nil, map[reflect.Type]bool{}, maxDepth)
+}
+
+func checkTypeCyclesVisited(t reflect.Type, path []string, visited map[reflect.Type]bool, maxDepth int) error {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	if len(path) > maxDepth {
+		return depthError(path, maxDepth)
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		tField := t.Field(i)
+		if isInternalField(tField) {
+			continue
+		}
+
+		ft := tField.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() != reflect.Struct || isLeafStruct(ft) {
+			continue
+		}
+
+		currentPath := append(path[:len(path):len(path)], getFieldKey(tField))
+		if visited[ft] {
+			return fmt.Errorf("configurator: recursive type [%v] at [%s] is not supported", ft, strings.Join(currentPath, pathSeparator))
+		}
+		if err := checkTypeCyclesVisited(ft, currentPath, visited, maxDepth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func depthError(path []string, maxDepth int) error {
+	return kindError{
+		kind: ErrLimitExceeded,
+		err:  fmt.Errorf("configurator: struct nesting at [%s] exceeds max depth %d (see WithMaxDepth)", strings.Join(path, pathSeparator), maxDepth),
+	}
+}
+
+// checkConflicts returns an error if two fields use the same ENV variable or flag name
+func checkConflicts(t reflect.Type, names tagNames) error {
+	var (
+		envs  = map[string]string{} // name -> path to the field
+		flags = map[string]string{}
+		err   error
+	)
+
+	walkTypes(t, nil, names, func(path string, field reflect.StructField) {
+		if err != nil {
+			return
+		}
+
+		if env := strings.ToUpper(getEnvTag(field, names)); env != "" {
+			if other, ok := envs[env]; ok {
+				err = fmt.Errorf("configurator: fields [%s] and [%s] use the same env variable [%s]", other, path, env)
+				return
+			}
+			envs[env] = path
+		}
+
+		if fd := getFlagData(field, names); fd != nil {
+			if other, ok := flags[fd.key]; ok {
+				err = fmt.Errorf("configurator: fields [%s] and [%s] use the same flag [%s]", other, path, fd.key)
+				return
+			}
+			flags[fd.key] = path
+		}
+	})
+	return err
 }
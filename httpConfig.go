@@ -0,0 +1,168 @@
+//go:build !tinygo
+// +build !tinygo
+
+package configuration
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// HTTPServer is the reusable section of the configuration for net/http servers, values of missing keys
+// are taken from `default` tags:
+//
+//	type Config struct {
+//		API configuration.HTTPServer `json:"api"` // api.addr, api.read_timeout, api.tls.cert_file...
+//	}
+//
+//	srv, err := cfg.API.Server(handler)
+type HTTPServer struct {
+	Addr              string        `default:":8080"`
+	ReadTimeout       time.Duration `default:"30s"`
+	ReadHeaderTimeout time.Duration `default:"10s"`
+	WriteTimeout      time.Duration `default:"30s"`
+	IdleTimeout       time.Duration `default:"2m"`
+	MaxHeaderBytes    int           `default:"1MiB" format:"bytes"`
+	TLS               TLS
+}
+
+// Server returns the server with the handler, TLS is enabled if the certificate is set
+// (start it with ListenAndServeTLS("", ""))
+func (s HTTPServer) Server(handler http.Handler) (*http.Server, error) {
+	srv := &http.Server{
+		Addr:              s.Addr,
+		Handler:           handler,
+		ReadTimeout:       s.ReadTimeout,
+		ReadHeaderTimeout: s.ReadHeaderTimeout,
+		WriteTimeout:      s.WriteTimeout,
+		IdleTimeout:       s.IdleTimeout,
+		MaxHeaderBytes:    s.MaxHeaderBytes,
+	}
+	if s.TLS.CertFile == "" {
+		return srv, nil
+	}
+
+	tlsConfig, err := s.TLS.Config()
+	if err != nil {
+		return nil, err
+	}
+	if s.TLS.CAFile != "" { // clients must present certificates signed by the CA
+		tlsConfig.ClientCAs, tlsConfig.RootCAs = tlsConfig.RootCAs, nil
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	srv.TLSConfig = tlsConfig
+	return srv, nil
+}
+
+// HTTPClient is the reusable section of the configuration for net/http clients (see HTTPServer)
+type HTTPClient struct {
+	Timeout               time.Duration `default:"30s"`
+	DialTimeout           time.Duration `default:"10s"`
+	KeepAlive             time.Duration `default:"30s"`
+	TLSHandshakeTimeout   time.Duration `default:"10s"`
+	ResponseHeaderTimeout time.Duration `default:"0s"`
+	IdleConnTimeout       time.Duration `default:"90s"`
+	MaxIdleConns          int           `default:"100"`
+	MaxIdleConnsPerHost   int           `default:"10"`
+	MaxConnsPerHost       int           `default:"0"`
+	ProxyURL              string        `severity:"warn"` // HTTP_PROXY, HTTPS_PROXY and NO_PROXY are used if it's empty
+	TLS                   TLS
+}
+
+// Client returns the client with the transport built by Transport
+func (c HTTPClient) Client() (*http.Client, error) {
+	transport, err := c.Transport()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport, Timeout: c.Timeout}, nil
+}
+
+// Transport returns the transport with timeouts, limits of connections, the proxy and TLS of the section
+func (c HTTPClient) Transport() (*http.Transport, error) {
+	proxy := http.ProxyFromEnvironment
+	if c.ProxyURL != "" {
+		u, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %v", err)
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	tlsConfig, err := c.TLS.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: c.DialTimeout, KeepAlive: c.KeepAlive}
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   c.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: c.ResponseHeaderTimeout,
+		IdleConnTimeout:       c.IdleConnTimeout,
+		MaxIdleConns:          c.MaxIdleConns,
+		MaxIdleConnsPerHost:   c.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       c.MaxConnsPerHost,
+		ForceAttemptHTTP2:     true,
+	}, nil
+}
+
+// TLS is the reusable section of TLS settings of HTTPServer, HTTPClient and Database, all files are optional
+type TLS struct {
+	CertFile           string `severity:"warn"`
+	KeyFile            string `severity:"warn"`
+	CAFile             string `severity:"warn"`
+	ServerName         string `severity:"warn"`
+	InsecureSkipVerify bool   `default:"false"`
+	MinVersion         string `default:"1.2" validate:"oneof=1.0|1.1|1.2|1.3"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Config loads the certificate (if CertFile is set) and the CA (if CAFile is set, used as root CAs)
+func (t TLS) Config() (*tls.Config, error) {
+	version, ok := tlsVersions[t.MinVersion]
+	if !ok && t.MinVersion != "" {
+		return nil, fmt.Errorf("unknown TLS version [%s]", t.MinVersion)
+	}
+	config := &tls.Config{
+		MinVersion:         version,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load TLS certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	if t.CAFile != "" {
+		b, err := ioutil.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load TLS CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, errors.New("cannot load TLS CA: no certificates in " + t.CAFile)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
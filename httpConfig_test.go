@@ -0,0 +1,138 @@
+//go:build !tinygo
+// +build !tinygo
+
+package configuration
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPSections(t *testing.T) {
+	var cfg struct {
+		API      HTTPServer
+		Upstream HTTPClient
+	}
+	c, err := New(&cfg, WithProviders(NewFileProvider("./testdata/http.yml").WithNaming(SnakeCase), NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues(), "optional fields can be omitted")
+
+	srv, err := cfg.API.Server(http.NotFoundHandler())
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Equal(t, ":9090", srv.Addr)
+	assert.Equal(t, 5*time.Second, srv.ReadTimeout)
+	assert.Equal(t, 10*time.Second, srv.ReadHeaderTimeout)
+	assert.Equal(t, 2*time.Minute, srv.IdleTimeout)
+	assert.Equal(t, 64<<10, srv.MaxHeaderBytes)
+	assert.Nil(t, srv.TLSConfig, "TLS is disabled without the certificate")
+
+	client, err := cfg.Upstream.Client()
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Equal(t, 3*time.Second, client.Timeout)
+	transport := client.Transport.(*http.Transport)
+	assert.Equal(t, 100, transport.MaxIdleConns)
+	assert.Equal(t, 10, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 90*time.Second, transport.IdleConnTimeout)
+	assert.Equal(t, "upstream.internal", transport.TLSClientConfig.ServerName)
+	assert.Equal(t, uint16(tls.VersionTLS13), transport.TLSClientConfig.MinVersion)
+
+	proxy, err := transport.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "upstream.internal"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "http://proxy.internal:3128", proxy.String())
+}
+
+func TestHTTPSections_TLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := writeTestCert(t, dir)
+
+	srv, err := HTTPServer{TLS: TLS{CertFile: certFile, KeyFile: keyFile, CAFile: certFile, MinVersion: "1.2"}}.Server(nil)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Len(t, srv.TLSConfig.Certificates, 1)
+	assert.NotNil(t, srv.TLSConfig.ClientCAs, "the CA verifies certificates of clients")
+	assert.Nil(t, srv.TLSConfig.RootCAs)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, srv.TLSConfig.ClientAuth)
+
+	transport, err := HTTPClient{TLS: TLS{CAFile: certFile}}.Transport()
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NotNil(t, transport.TLSClientConfig.RootCAs)
+	assert.Empty(t, transport.TLSClientConfig.Certificates)
+
+	for name, section := range map[string]TLS{
+		"missing key":  {CertFile: certFile},
+		"missing CA":   {CAFile: filepath.Join(dir, "absent.pem")},
+		"not a CA":     {CAFile: keyFile},
+		"bad version":  {MinVersion: "2.0"},
+		"bad key pair": {CertFile: keyFile, KeyFile: certFile},
+	} {
+		_, err := section.Config()
+		assert.Error(t, err, name)
+	}
+
+	_, err = HTTPClient{ProxyURL: "://proxy"}.Client()
+	assert.Error(t, err)
+}
+
+// writeTestCert writes the self-signed certificate and its key, returns names of the files
+func writeTestCert(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	for fileName, block := range map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: der},
+		keyFile:  {Type: "EC PRIVATE KEY", Bytes: keyDER},
+	} {
+		if err := ioutil.WriteFile(fileName, pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatal("unexpected err: ", err)
+		}
+	}
+	return certFile, keyFile
+}
@@ -0,0 +1,46 @@
+package configuration
+
+import (
+	"reflect"
+	"sort"
+)
+
+// IgnoredKeys maps names of sources (e.g. `config.yml`, `env APP_*`) to sorted keys which don't match
+// any field of the configuration object (see WithIgnoredKeysReport)
+type IgnoredKeys map[string][]string
+
+// Count returns the number of ignored keys of all sources
+func (ik IgnoredKeys) Count() int {
+	n := 0
+	for _, keys := range ik {
+		n += len(keys)
+	}
+	return n
+}
+
+// ignoredKeys collects keys of providers implementing IgnoredKeysLister, sources without ignored keys are omitted
+func ignoredKeys(providers []Provider, cfgType reflect.Type) IgnoredKeys {
+	result := IgnoredKeys{}
+	for _, p := range providers {
+		lister, ok := p.(IgnoredKeysLister)
+		if !ok {
+			continue
+		}
+		source, keys := lister.IgnoredKeys(cfgType)
+		if len(keys) == 0 {
+			continue
+		}
+		seen := map[string]bool{}
+		for _, key := range result[source] {
+			seen[key] = true
+		}
+		for _, key := range keys {
+			if !seen[key] {
+				seen[key] = true
+				result[source] = append(result[source], key)
+			}
+		}
+		sort.Strings(result[source])
+	}
+	return result
+}
@@ -0,0 +1,67 @@
+package configuration
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithIgnoredKeysReport(t *testing.T) {
+	type upstream struct {
+		URL string `yaml:"url"`
+	}
+	cfg := struct {
+		Host     string `yaml:"host" env:"HOST"`
+		Database struct {
+			Host string `yaml:"host"`
+		} `yaml:"database"`
+		Upstreams map[string]upstream `yaml:"upstreams"`
+		Port      int                 `default:"80"`
+	}{}
+
+	var reports []IgnoredKeys
+	env := map[string]string{"APP_HOST": "env", "APP_DATABASE_HOST": "db", "APP_TIMEOUT": "5s", "APP_DB_PASS": "x", "HOME": "/root"}
+	c, err := New(&cfg,
+		WithProviders(
+			NewEnvProvider().WithPrefix("APP").WithDerivedNames().WithEnv(env),
+			NewEnvProvider().WithEnv(env), // no prefix: nothing is reported
+			NewFileProvider("./testdata/ignored.yml"),
+			NewDefaultProvider(),
+		),
+		WithIgnoredKeysReport(func(keys IgnoredKeys) { reports = append(reports, keys) }),
+	)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues(), "ignored keys aren't errors")
+
+	if assert.Len(t, reports, 1) {
+		assert.Equal(t, IgnoredKeys{
+			"env APP_*":              {"APP_DB_PASS", "APP_TIMEOUT"},
+			"./testdata/ignored.yml": {"database.pool_size", "timeout", "upstreams.api.retries"},
+		}, reports[0])
+		assert.Equal(t, 5, reports[0].Count())
+	}
+	assert.Equal(t, "env", cfg.Host)
+}
+
+func TestEnvProvider_IgnoredKeys_Lookup(t *testing.T) {
+	cfg := struct {
+		Host string `env:"HOST"`
+	}{}
+	_, keys := NewEnvProvider().WithPrefix("APP").WithLookup(func(string) (string, bool) { return "", false }).IgnoredKeys(reflect.TypeOf(cfg))
+	assert.Empty(t, keys, "variables cannot be listed")
+}
+
+func TestEnvProvider_IgnoredKeys_DottedKeys(t *testing.T) {
+	cfg := struct {
+		Host string `json:"db.host"`
+	}{}
+	ep := NewEnvProvider().WithPrefix("APP").WithNaming(func(path ...string) string {
+		return strings.ToUpper(strings.Join(path, "__"))
+	})
+	_, keys := ep.WithEnv(map[string]string{"APP_DB.HOST": "db", "APP_DB__HOST": "x"}).IgnoredKeys(reflect.TypeOf(cfg))
+	assert.Equal(t, []string{"APP_DB__HOST"}, keys, "the key which the provider reads isn't reported")
+}
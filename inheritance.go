@@ -0,0 +1,84 @@
+package configuration
+
+import "reflect"
+
+// inheritedField is the field of the struct or of one of its bases: struct fields tagged `inherit:"true"`,
+// e.g. the platform config embedded into the config of the service:
+//
+//	type Config struct {
+//		platform.Config `inherit:"true"` // fields are looked up without the `Config.` prefix
+//		LogLevel string `default:"debug"` // overrides platform.Config.LogLevel
+//	}
+type inheritedField struct {
+	field   reflect.StructField
+	index   []int   // the index sequence of the field for reflect.Value.FieldByIndex
+	shadows [][]int // index sequences of the overridden fields of bases with the same key
+}
+
+// structFields returns fields of the struct with fields of its bases promoted to it. Bases are merged in
+// the order of declaration: a field overrides fields with the same key of bases declared before it,
+// fields of the struct itself override fields of all bases.
+func structFields(t reflect.Type, names tagNames) []inheritedField {
+	var (
+		result []inheritedField
+		byKey  = map[string]int{} // key -> index in result
+		own    = map[string]bool{}
+	)
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); !isInternalField(f) && !isInherited(f, names) {
+			own[getFieldKey(f)] = true
+		}
+	}
+
+	add := func(f inheritedField, fromBase bool) {
+		key := getFieldKey(f.field)
+		pos, ok := byKey[key]
+		switch {
+		case !ok:
+			byKey[key] = len(result)
+			result = append(result, f)
+		case fromBase && own[key]:
+			result[pos].shadows = append(result[pos].shadows, append(f.shadows, f.index)...)
+		default:
+			prev := result[pos]
+			f.shadows = append(f.shadows, append(prev.shadows, prev.index)...)
+			result[pos] = f
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		switch {
+		case isInherited(f, names): // embedded bases can be unexported
+			for _, bf := range structFields(f.Type, names) {
+				bf.index = append([]int{i}, bf.index...)
+				shadows := make([][]int, 0, len(bf.shadows))
+				for _, s := range bf.shadows {
+					shadows = append(shadows, append([]int{i}, s...))
+				}
+				bf.shadows = shadows
+				add(bf, true)
+			}
+		case isInternalField(f):
+		default:
+			add(inheritedField{field: f, index: []int{i}}, false)
+		}
+	}
+	return result
+}
+
+// copyShadowed sets overridden fields of bases to values of fields which override them,
+// so methods of bases see effective values. Fields of other types are left as they are.
+func (c configurator) copyShadowed(v reflect.Value, fields []inheritedField) {
+	for _, f := range fields {
+		src := v.FieldByIndex(f.index)
+		for _, index := range f.shadows {
+			dst := v.FieldByIndex(index)
+			if src.Kind() != dst.Kind() || !src.Type().ConvertibleTo(dst.Type()) {
+				c.opts.logf("configurator: overridden field [%s] of type [%v] is not set to the value of type [%v]", f.field.Name, dst.Type(), src.Type())
+				continue
+			}
+			dst.Set(src.Convert(dst.Type()))
+		}
+	}
+}
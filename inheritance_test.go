@@ -0,0 +1,84 @@
+package configuration
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type platformConfig struct {
+	LogLevel string `yaml:"log_level" default:"info"`
+	Port     int    `yaml:"port" default:"8080"`
+	Region   string `yaml:"region" default:"us-east-1"`
+}
+
+func (pc platformConfig) Addr() string {
+	return pc.LogLevel + "@" + pc.Region
+}
+
+type cloudConfig struct {
+	Region string `yaml:"region" default:"eu-central-1"`
+	Zone   string `yaml:"zone" default:"a"`
+}
+
+func TestInheritance(t *testing.T) {
+	cfg := struct {
+		platformConfig `inherit:"true"`
+		cloudConfig    `inherit:"true"` // overrides Region of platformConfig
+		LogLevel       string           `yaml:"log_level" default:"debug"`
+		Queue          string           `yaml:"queue"`
+	}{}
+	c, err := New(&cfg, WithProviders(NewFileProvider("./testdata/inheritance.yml").WithStrictKeys(), NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, "orders", cfg.Queue)
+	assert.Equal(t, 9090, cfg.Port)
+	assert.Equal(t, "eu-west-1", cfg.cloudConfig.Region)
+	assert.Equal(t, "a", cfg.Zone)
+	assert.Equal(t, "debug@eu-west-1", cfg.platformConfig.Addr(), "overridden fields of bases are set too")
+
+	sources := c.Sources()
+	assert.Equal(t, "fileProvider", sources["port"])
+	assert.Equal(t, "defaultProvider", sources["log_level"])
+	assert.NotContains(t, sources, "platformConfig.port")
+}
+
+func TestStructFields(t *testing.T) {
+	type service struct {
+		Name           string `inherit:"true"` // not a struct
+		platformConfig `inherit:"true"`
+		Region         int
+	}
+
+	var keys []string
+	for _, f := range structFields(reflect.TypeOf(service{}), nil) {
+		keys = append(keys, getFieldKey(f.field))
+	}
+	assert.Equal(t, []string{"Name", "log_level", "port", "region", "Region"}, keys)
+}
+
+func TestInheritance_Flags(t *testing.T) {
+	defer func(args []string) { os.Args = args }(os.Args)
+	os.Args = []string{"app", "-log-level=warn"}
+
+	type base struct {
+		LogLevel string `flag:"log-level|info|log level"`
+	}
+	cfg := struct {
+		base     `inherit:"true"`
+		LogLevel string `flag:"log-level|error|log level"`
+	}{}
+	c, err := New(&cfg, WithProviders(NewFlagProvider(&cfg)))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, "warn", cfg.LogLevel)
+	assert.Equal(t, "warn", cfg.base.LogLevel)
+}
@@ -1,8 +1,80 @@
 package configuration
 
-import "reflect"
+import (
+	"context"
+	"reflect"
+)
 
 // Provider defines interface for existing and future custom providers
 type Provider interface {
 	Provide(field reflect.StructField, v reflect.Value, pathToField ...string) bool
 }
+
+// KeysProvider is an optional interface for providers which are able to list keys of a map
+// located at the given path (e.g. to populate `map[string]SomeStruct` fields)
+type KeysProvider interface {
+	Keys(pathToField ...string) []string
+}
+
+// KeysChecker is an optional interface for providers which validate their keys against the type of the configuration
+// object (e.g. to report typos in files, see fileProvider.WithStrictKeys). CheckKeys is called by InitValues (and Reload)
+// before any field is set, the errors are returned from InitValues.
+type KeysChecker interface {
+	CheckKeys(cfgType reflect.Type) []error
+}
+
+// IgnoredKeysLister is an optional interface for providers which can list keys of their source which don't match
+// any field of the configuration object, with the name of the source (see WithIgnoredKeysReport)
+type IgnoredKeysLister interface {
+	IgnoredKeys(cfgType reflect.Type) (source string, keys []string)
+}
+
+// KeyDescriber is an optional interface for providers which are able to tell where they look for the value
+// of the field (e.g. `DB_HOST` for envProvider, `-db-host` for flagProvider). An empty string means that
+// the provider doesn't look for the field. It's used by the report of WithUnsetReport.
+type KeyDescriber interface {
+	DescribeKey(field reflect.StructField, path ...string) string
+}
+
+// PositionProvider is an optional interface for providers which know where the value of the field is located
+// in the source (e.g. `config.yml:12:5` for fileProvider). The position is added to errors of normalization
+// and validation of the value, an empty string means that the position is unknown.
+type PositionProvider interface {
+	Position(pathToField ...string) string
+}
+
+// VersionedProvider is an optional interface for providers which report their version (e.g. of the plugin
+// or the remote backend) to the manifest of WithTrace. The version of the module which defines the type
+// of the provider is recorded otherwise.
+type VersionedProvider interface {
+	Version() string
+}
+
+// HealthChecker is an optional interface for providers of remote backends (Vault, Consul, HTTP) which can be pinged
+// without setting values, e.g. by readiness probes (see CheckProviders). nil means the backend is reachable.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// WritableProvider is an optional interface for providers which are able to persist values
+// (see configurator.Set)
+type WritableProvider interface {
+	Provider
+	Set(pathToField, value string) error
+}
+
+// ContextProvider is an optional interface for providers which make remote lookups: ProvideContext is called
+// instead of Provide with the context of InitValuesContext (or ReloadContext) to respect deadlines and cancellation
+type ContextProvider interface {
+	Provider
+	ProvideContext(ctx context.Context, field reflect.StructField, v reflect.Value, pathToField ...string) bool
+}
+
+// ErrorProvider is an optional interface for providers which tell why the value isn't set:
+// (false, nil) means the value isn't found, (false, err) means the value is found but can't be set
+// or the source is unavailable. The error is returned from InitValues as FieldError if no other provider sets the field.
+// ProvideError is called instead of Provide (and ProvideContext).
+type ErrorProvider interface {
+	Provider
+	ProvideError(ctx context.Context, field reflect.StructField, v reflect.Value, pathToField ...string) (bool, error)
+}
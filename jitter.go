@@ -0,0 +1,95 @@
+package configuration
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gRand is the source of jitter for `default` tags, rand.Rand isn't safe for concurrent use
+var (
+	gRand   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	gRandMu sync.Mutex
+)
+
+// parseJitter splits the value of `default` tag like `30s±10%` (or `30s+-10%`) into the base value and the ratio (0.1).
+// Values of fields which aren't numbers (or pointers to them) are returned as is.
+func parseJitter(t reflect.Type, val string) (string, float64, bool) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if k := t.Kind(); k < reflect.Int || k > reflect.Float64 || k == reflect.Uintptr {
+		return val, 0, false
+	}
+
+	sep := "±"
+	i := strings.LastIndex(val, sep)
+	if i < 0 {
+		sep = "+-"
+		if i = strings.LastIndex(val, sep); i < 0 {
+			return val, 0, false
+		}
+	}
+
+	percent := strings.TrimSpace(val[i+len(sep):])
+	if !strings.HasSuffix(percent, "%") {
+		return val, 0, false
+	}
+	n, err := parseFloat(strings.TrimSuffix(percent, "%"), 64)
+	if err != nil || n < 0 || n > 100 {
+		return val, 0, false
+	}
+	return strings.TrimSpace(val[:i]), n / 100, true
+}
+
+// jitterFactor returns the factor which changes the value by a random value within ±ratio of it.
+// The random part is chosen once per configurator and field, so Reload and Watch keep the value
+// (no OnChange callbacks and changes of Fingerprint); without the configurator it's chosen on every call.
+func (o *options) jitterFactor(path []string, ratio float64) float64 {
+	if o == nil {
+		return 1 + ratio*randomUnit()
+	}
+	u, _ := o.jitters.LoadOrStore(strings.Join(path, pathSeparator), randomUnit())
+	return 1 + ratio*u.(float64)
+}
+
+// randomUnit returns a random number in [-1, 1)
+func randomUnit() float64 {
+	gRandMu.Lock()
+	defer gRandMu.Unlock()
+	return 2*gRand.Float64() - 1
+}
+
+// applyJitter multiplies the number (or the number the pointer points to) by the factor (see jitterFactor)
+func applyJitter(v reflect.Value, factor float64) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := math.Round(float64(v.Int()) * factor)
+		if n < math.MinInt64 || n >= math.MaxInt64 || v.OverflowInt(int64(n)) {
+			return fmt.Errorf("jittered value of %v: %v", v.Interface(), errOutOfRange)
+		}
+		v.SetInt(int64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n := math.Round(float64(v.Uint()) * factor)
+		if n < 0 || n >= math.MaxUint64 || v.OverflowUint(uint64(n)) {
+			return fmt.Errorf("jittered value of %v: %v", v.Interface(), errOutOfRange)
+		}
+		v.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(v.Float() * factor)
+	default:
+		return fmt.Errorf("jitter is not supported for %v", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package configuration
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJitter(t *testing.T) {
+	tests := map[string]struct {
+		sample   interface{}
+		value    string
+		base     string
+		ratio    float64
+		jittered bool
+	}{
+		"duration":   {sample: time.Duration(0), value: "30s±10%", base: "30s", ratio: 0.1, jittered: true},
+		"ascii":      {sample: 0, value: "100 +- 5%", base: "100", ratio: 0.05, jittered: true},
+		"pointer":    {sample: new(float64), value: "1.5±50%", base: "1.5", ratio: 0.5, jittered: true},
+		"no jitter":  {sample: 0, value: "100", base: "100"},
+		"no percent": {sample: 0, value: "100±5", base: "100±5"},
+		"too much":   {sample: 0, value: "100±200%", base: "100±200%"},
+		"string":     {sample: "", value: "a±10%", base: "a±10%"},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			base, ratio, ok := parseJitter(reflect.TypeOf(test.sample), test.value)
+			assert.Equal(t, test.base, base)
+			assert.Equal(t, test.ratio, ratio)
+			assert.Equal(t, test.jittered, ok)
+		})
+	}
+}
+
+func TestDefaultProvider_Jitter(t *testing.T) {
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 20; i++ {
+		cfg := struct {
+			Interval time.Duration `default:"30s±10%"`
+			Retries  uint8         `default:"10±50%"`
+			Ratio    *float64      `default:"1+-20%"`
+			Name     string        `default:"±10%"`
+		}{}
+		c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+		if err != nil {
+			t.Fatal("unexpected err: ", err)
+		}
+		assert.NoError(t, c.InitValues())
+
+		assert.True(t, cfg.Interval >= 27*time.Second && cfg.Interval <= 33*time.Second, "unexpected value: %v", cfg.Interval)
+		assert.True(t, cfg.Retries >= 5 && cfg.Retries <= 15, "unexpected value: %v", cfg.Retries)
+		if assert.NotNil(t, cfg.Ratio) {
+			assert.True(t, *cfg.Ratio >= 0.8 && *cfg.Ratio <= 1.2, "unexpected value: %v", *cfg.Ratio)
+		}
+		assert.Equal(t, "±10%", cfg.Name)
+		seen[cfg.Interval] = true
+	}
+	assert.True(t, len(seen) > 1, "values must be randomized")
+
+	for i := 0; i < 20; i++ {
+		cfg := struct {
+			Small int8 `default:"127±50%"`
+		}{}
+		c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+		if err != nil {
+			t.Fatal("unexpected err: ", err)
+		}
+		if err := c.InitValues(); err != nil {
+			assert.Contains(t, err.Error(), "value out of range")
+			return
+		}
+		assert.True(t, cfg.Small >= 63)
+	}
+}
+
+func TestDefaultProvider_JitterIsStable(t *testing.T) {
+	cfg := struct {
+		Interval time.Duration `default:"30s±10%"`
+		Backoff  time.Duration `default:"30s±10%"`
+	}{}
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	interval, backoff, fingerprint := cfg.Interval, cfg.Backoff, c.Fingerprint(false)
+
+	changed := false
+	c.OnChange(func(_, _ interface{}) { changed = true })
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, c.Reload())
+	}
+	assert.Equal(t, interval, cfg.Interval, "the jitter is chosen once per configurator and field")
+	assert.Equal(t, backoff, cfg.Backoff)
+	assert.Equal(t, fingerprint, c.Fingerprint(false))
+	assert.False(t, changed)
+}
@@ -0,0 +1,77 @@
+package configuration
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// defaultMaxFields limits the number of fields of the configuration object (see WithMaxFields)
+const defaultMaxFields = 10000
+
+// checkTypeSize returns an error if the type of the configuration object has more than maxFields fields,
+// e.g. a generated struct which repeats the same nested struct in every field. Types of nested structs
+// are counted once, so the check doesn't walk all the fields. Fields of maps are checked by fillUp.
+func checkTypeSize(t reflect.Type, maxFields int) error {
+	if maxFields <= 0 {
+		return nil
+	}
+	if n := countFields(t, maxFields, map[reflect.Type]int{}); n > maxFields {
+		return kindError{
+			kind: ErrLimitExceeded,
+			err:  fmt.Errorf("configurator: type [%v] has more than %d fields (see WithMaxFields)", t, maxFields),
+		}
+	}
+	return nil
+}
+
+// countFields returns the number of fields set by providers, counting stops once it's greater than the limit
+func countFields(t reflect.Type, limit int, counted map[reflect.Type]int) int {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if n, ok := counted[t]; ok {
+		return n
+	}
+
+	n := 0
+	for i := 0; i < t.NumField() && n <= limit; i++ {
+		tField := t.Field(i)
+		if isInternalField(tField) {
+			continue
+		}
+
+		ft := tField.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && !isLeafStruct(ft) {
+			n += countFields(ft, limit, counted)
+			continue
+		}
+		n++
+	}
+	counted[t] = n
+	return n
+}
+
+// countField counts the field processed by InitValues (see FieldsProcessed),
+// it returns an error once the number of fields exceeds the limit of WithMaxFields (e.g. because of maps)
+func (c configurator) countField(currentPath []string) error {
+	c.stats.fieldsProcessed++
+	if max := c.opts.maxFields; max > 0 && c.stats.fieldsProcessed > max {
+		return kindError{
+			kind: ErrLimitExceeded,
+			err:  fmt.Errorf("configurator: more than %d fields are processed at [%s] (see WithMaxFields)", max, strings.Join(currentPath, pathSeparator)),
+		}
+	}
+	return nil
+}
+
+// FieldsProcessed returns the number of fields processed by the last InitValues (or Reload) call,
+// including fields of all items of maps
+func (c configurator) FieldsProcessed() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.stats.fieldsProcessed
+}
@@ -0,0 +1,84 @@
+package configuration
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// every level multiplies the number of fields by 8: 8^5 = 32768 fields
+type (
+	wideLevel4 struct{ A, B, C, D, E, F, G, H int }
+	wideLevel3 struct{ A, B, C, D, E, F, G, H wideLevel4 }
+	wideLevel2 struct{ A, B, C, D, E, F, G, H wideLevel3 }
+	wideLevel1 struct{ A, B, C, D, E, F, G, H *wideLevel2 }
+	wideLevel0 struct{ A, B, C, D, E, F, G, H wideLevel1 }
+)
+
+// wideKeysProvider returns 10 keys for every map
+type wideKeysProvider struct{ defaultProvider }
+
+func (wideKeysProvider) Keys(...string) []string {
+	return []string{"k0", "k1", "k2", "k3", "k4", "k5", "k6", "k7", "k8", "k9"}
+}
+
+func TestCountFields(t *testing.T) {
+	type config struct {
+		Name    string
+		Inner   wideLevel4
+		Ptr     *wideLevel4
+		Map     map[string]wideLevel4
+		private int
+	}
+	assert.Equal(t, 18, countFields(reflect.TypeOf(config{}), 100, map[reflect.Type]int{}))
+	assert.Equal(t, 32768, countFields(reflect.TypeOf(wideLevel0{}), 1<<20, map[reflect.Type]int{}))
+	assert.True(t, countFields(reflect.TypeOf(wideLevel0{}), 100, map[reflect.Type]int{}) > 100)
+}
+
+func TestConfigurator_Limits(t *testing.T) {
+	_, err := New(&wideLevel0{}, WithProviders(NewDefaultProvider()))
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+	assert.EqualError(t, err, "configurator: type [configuration.wideLevel0] has more than 10000 fields (see WithMaxFields)")
+
+	_, err = New(&wideLevel0{}, WithProviders(NewDefaultProvider()), WithMaxFields(0))
+	assert.NoError(t, err, "the limit is disabled")
+
+	_, err = New(&wideLevel2{}, WithProviders(NewDefaultProvider()), WithMaxDepth(1))
+	assert.True(t, errors.Is(err, ErrLimitExceeded))
+	assert.EqualError(t, err, "configurator: struct nesting at [A.A] exceeds max depth 1 (see WithMaxDepth)")
+
+	var maps struct {
+		Regions map[string]struct {
+			Zones map[string]wideLevel4
+		}
+	}
+	c, err := New(&maps, WithProviders(wideKeysProvider{}), WithMaxFields(500), AllowUnset())
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+	assert.True(t, errors.Is(err, ErrLimitExceeded), "800 fields of maps are processed")
+	assert.EqualError(t, err, "configurator: more than 500 fields are processed at [Regions.k6.Zones.k2.E] (see WithMaxFields)")
+	assert.Equal(t, 501, c.FieldsProcessed())
+}
+
+func TestConfigurator_FieldsProcessed(t *testing.T) {
+	var cfg struct {
+		Name   string `default:"app"`
+		Routes map[string]struct {
+			Host string `default:"localhost"`
+			Port int    `default:"80"`
+		}
+	}
+	c, err := New(&cfg, WithProviders(wideKeysProvider{}))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, 21, c.FieldsProcessed(), "fields of all items of maps are counted")
+
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, 21, c.FieldsProcessed(), "only the last call is counted")
+}
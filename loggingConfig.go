@@ -0,0 +1,96 @@
+//go:build go1.21
+// +build go1.21
+
+package configuration
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+)
+
+// Logging is the reusable section of the configuration for log/slog loggers:
+//
+//	type Config struct {
+//		Log configuration.Logging `json:"log"` // log.level, log.format, log.output
+//	}
+//
+//	logger, err := cfg.Log.Logger()
+//	cfg.Log.ReloadOn(c) // Reload and Watch change the level of the logger
+type Logging struct {
+	Level     string `default:"info" validate:"oneof=debug|info|warn|error"`
+	Format    string `default:"text" validate:"oneof=text|json"`
+	Output    string `default:"stderr"` // `stdout`, `stderr` or the name of the file (appended)
+	AddSource bool   `default:"false"`
+
+	level *slog.LevelVar // unexported fields are shared by copies of the configuration made by reloads
+}
+
+// Logger returns the logger with the handler of Format writing to Output, its level is LevelVar.
+// The file of Output is opened by every call.
+func (l *Logging) Logger() (*slog.Logger, error) {
+	level, err := l.LevelVar()
+	if err != nil {
+		return nil, err
+	}
+
+	var w io.Writer
+	switch l.Output {
+	case "", "stderr":
+		w = os.Stderr
+	case "stdout":
+		w = os.Stdout
+	default:
+		f, err := os.OpenFile(l.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open the log output: %v", err)
+		}
+		w = f
+	}
+
+	opts := &slog.HandlerOptions{Level: level, AddSource: l.AddSource}
+	switch l.Format {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+	return nil, fmt.Errorf("unknown log format [%s]", l.Format)
+}
+
+// LevelVar returns the level shared by all loggers of the section, Apply sets Level to it
+func (l *Logging) LevelVar() (*slog.LevelVar, error) {
+	if l.level == nil {
+		l.level = &slog.LevelVar{}
+	}
+	return l.level, l.Apply()
+}
+
+// Apply sets Level to the LevelVar of loggers
+func (l *Logging) Apply() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(l.Level)); err != nil {
+		return err
+	}
+	if l.level != nil {
+		l.level.Set(level)
+	}
+	return nil
+}
+
+// ReloadOn makes Reload and Watch of the configurator apply the new level (see OnChange),
+// the section must be a part of its configuration object. Invalid levels are logged.
+func (l *Logging) ReloadOn(c interface {
+	OnChange(fn func(oldCfg, newCfg interface{}))
+}) {
+	var o *options
+	if cfg, ok := c.(configurator); ok {
+		o = cfg.opts
+	}
+	c.OnChange(func(_, _ interface{}) {
+		if err := l.Apply(); err != nil {
+			o.errorf("configurator: cannot apply the log level: %v", err)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+//go:build go1.21
+// +build go1.21
+
+package configuration
+
+import (
+	"context"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogging_ReloadLevel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logging")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "app.log")
+
+	env := map[string]string{
+		"LOGGING_TEST_LOG_FORMAT": "json",
+		"LOGGING_TEST_LOG_OUTPUT": output,
+	}
+	var cfg struct {
+		Log Logging
+	}
+	c, err := New(&cfg, WithProviders(NewEnvProvider().WithEnv(env).WithPrefix("LOGGING_TEST").WithDerivedNames(), NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	if err := c.InitValues(); err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Equal(t, "info", cfg.Log.Level)
+
+	logger, err := cfg.Log.Logger()
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	cfg.Log.ReloadOn(c)
+
+	logger.Debug("hidden")
+	logger.Info("shown")
+	env["LOGGING_TEST_LOG_LEVEL"] = "debug"
+	assert.NoError(t, c.Reload())
+	logger.Debug("debug after reload")
+
+	b, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NotContains(t, string(b), "hidden")
+	assert.Contains(t, string(b), `"msg":"shown"`)
+	assert.Contains(t, string(b), `"msg":"debug after reload"`)
+
+	level, err := cfg.Log.LevelVar()
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, level.Level())
+}
+
+func TestLogging_Errors(t *testing.T) {
+	_, err := (&Logging{Level: "loud"}).Logger()
+	assert.Error(t, err)
+	_, err = (&Logging{Level: "info", Format: "xml"}).Logger()
+	assert.EqualError(t, err, "unknown log format [xml]")
+	_, err = (&Logging{Level: "info", Output: "/absent/dir/app.log"}).Logger()
+	assert.Error(t, err)
+
+	logger, err := (&Logging{Level: "warn", Output: "stdout"}).Logger()
+	assert.NoError(t, err)
+	assert.False(t, logger.Enabled(context.Background(), slog.LevelInfo))
+}
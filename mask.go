@@ -0,0 +1,112 @@
+package configuration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"unicode/utf8"
+)
+
+// Names of strategies of masking values of fields tagged `secret` in dumps (Explain, Diff, configctl,
+// the admin handler) and logs of providers. The strategy of the field is set by the tag: `secret:"last4"`,
+// `secret:"true"` uses the global one (see SetSecretMask).
+const (
+	MaskFull  = "full"  // `******`, nothing is shown (the default)
+	MaskLast4 = "last4" // `******1234`, values shorter than 8 characters are masked fully
+	MaskHash  = "hash"  // `sha256:9f86d081`, equal values have equal masks (beware that weak secrets can be brute-forced)
+)
+
+const secretMask = "******"
+
+// MaskFunc returns the masked representation of the value of the secret
+type MaskFunc func(value string) string
+
+var gMasks = map[string]MaskFunc{
+	MaskFull:  maskFull,
+	MaskLast4: maskLast4,
+	MaskHash:  maskHash,
+}
+
+var gSecretMask = MaskFull
+
+// SetSecretMask sets the strategy of masking fields tagged `secret:"true"`:
+// one of MaskFull, MaskLast4, MaskHash or the name registered with RegisterMask.
+func SetSecretMask(name string) error {
+	if _, ok := gMasks[name]; !ok {
+		return fmt.Errorf("unknown mask [%s]", name)
+	}
+	gSecretMask = name
+	return nil
+}
+
+// RegisterMask adds the strategy which can be used by SetSecretMask and in tags: `secret:"<name>"`.
+// Names which can be parsed by strconv.ParseBool are reserved.
+func RegisterMask(name string, fn MaskFunc) error {
+	if _, err := strconv.ParseBool(name); err == nil || name == "" || fn == nil {
+		return fmt.Errorf("invalid mask [%s]", name)
+	}
+	gMasks[name] = fn
+	return nil
+}
+
+// MaskSecret masks the value with the strategy (the global one if it's empty), unknown strategies mask fully
+func MaskSecret(mask string, value interface{}) string {
+	if mask == "" {
+		mask = gSecretMask
+	}
+	fn, ok := gMasks[mask]
+	if !ok {
+		fn = maskFull
+	}
+	return fn(fmt.Sprint(value))
+}
+
+// isSecret reports whether the field is tagged `secret:"true"` or with the name of the mask
+func isSecret(f reflect.StructField) bool {
+	return getMask(f) != ""
+}
+
+// getMask returns the name of the mask of the field or an empty string if it isn't secret
+func getMask(f reflect.StructField) string {
+	tag := getSecretTag(f)
+	if secret, err := strconv.ParseBool(tag); err == nil {
+		if secret {
+			return gSecretMask
+		}
+		return ""
+	}
+	if _, ok := gMasks[tag]; ok {
+		return tag
+	}
+	if tag != "" { // a typo must not reveal the secret
+		return MaskFull
+	}
+	return ""
+}
+
+// logValue returns the value for logs of providers, masked if the field is secret
+func logValue(f reflect.StructField, value interface{}) interface{} {
+	if mask := getMask(f); mask != "" {
+		return MaskSecret(mask, value)
+	}
+	return value
+}
+
+func maskFull(string) string {
+	return secretMask
+}
+
+func maskLast4(value string) string {
+	if utf8.RuneCountInString(value) < 8 {
+		return secretMask
+	}
+	runes := []rune(value)
+	return secretMask + string(runes[len(runes)-4:])
+}
+
+func maskHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:4])
+}
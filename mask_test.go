@@ -0,0 +1,122 @@
+package configuration
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskSecret(t *testing.T) {
+	tests := []struct {
+		name     string
+		mask     string
+		value    interface{}
+		expected string
+	}{
+		{name: "default", mask: "", value: "s3cret-value", expected: "******"},
+		{name: "full", mask: MaskFull, value: "s3cret-value", expected: "******"},
+		{name: "last4", mask: MaskLast4, value: "sk_live_12345678", expected: "******5678"},
+		{name: "last4 of short value", mask: MaskLast4, value: "1234567", expected: "******"},
+		{name: "last4 of number", mask: MaskLast4, value: 4111111111111111, expected: "******1111"},
+		{name: "hash", mask: MaskHash, value: "test", expected: "sha256:9f86d081"},
+		{name: "unknown", mask: "unknown", value: "s3cret-value", expected: "******"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, MaskSecret(test.mask, test.value))
+		})
+	}
+}
+
+func TestSetSecretMask(t *testing.T) {
+	defer SetSecretMask(MaskFull)
+
+	assert.NoError(t, SetSecretMask(MaskHash))
+	assert.Equal(t, "sha256:9f86d081", MaskSecret("", "test"))
+	assert.EqualError(t, SetSecretMask("first2"), "unknown mask [first2]")
+
+	defer delete(gMasks, "first2")
+	assert.NoError(t, RegisterMask("first2", func(value string) string { return value[:2] + "******" }))
+	assert.NoError(t, SetSecretMask("first2"))
+	assert.Equal(t, "te******", MaskSecret("", "test"))
+
+	assert.EqualError(t, RegisterMask("true", maskFull), "invalid mask [true]")
+	assert.EqualError(t, RegisterMask("", maskFull), "invalid mask []")
+	assert.EqualError(t, RegisterMask("nil", nil), "invalid mask [nil]")
+}
+
+func TestExplain_Masked(t *testing.T) {
+	defer SetSecretMask(MaskFull)
+
+	cfg := struct {
+		Host     string `default:"localhost"`
+		Password string `default:"s3cret-password" secret:"true"`
+		APIKey   string `default:"sk_live_12345678" secret:"last4"`
+		Token    string `default:"test" secret:"hash"`
+		Typo     string `default:"s3cret" secret:"lats4"`
+		Public   string `default:"public" secret:"false"`
+	}{}
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	masked := map[string]interface{}{}
+	for _, f := range c.Explain() {
+		masked[f.Path] = f.Masked()
+	}
+	assert.Equal(t, map[string]interface{}{
+		"Host":     "localhost",
+		"Password": "******",
+		"APIKey":   "******5678",
+		"Token":    "sha256:9f86d081",
+		"Typo":     "******",
+		"Public":   "public",
+	}, masked)
+	assert.Equal(t, "s3cret-password", cfg.Password, "the configuration object isn't masked")
+
+	assert.NoError(t, SetSecretMask(MaskLast4))
+	for _, f := range c.Explain() {
+		if f.Path == "Password" {
+			assert.Equal(t, "******word", f.Masked())
+		}
+	}
+}
+
+func TestSecretLogs(t *testing.T) {
+	removeEnv, err := setEnv("MASK_TEST_PASSWORD", "s3cret-password")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	defer removeEnv()
+
+	cfg := struct {
+		Password string `env:"MASK_TEST_PASSWORD" secret:"true"`
+	}{}
+	var logs []string
+	c, err := New(&cfg, WithProviders(NewEnvProvider()), WithLogger(func(format string, v ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, v...))
+	}))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	joined := strings.Join(logs, "\n")
+	assert.Contains(t, joined, "envProvider: set [******] to field [Password]")
+	assert.NotContains(t, joined, "s3cret-password")
+}
+
+func TestFieldDiff_Masked(t *testing.T) {
+	d := FieldDiff{Path: "APIKey", Left: "sk_live_12345678", Secret: true, Mask: MaskLast4}
+	assert.Equal(t, "******5678", d.MaskedLeft())
+	assert.Nil(t, d.MaskedRight())
+
+	d = FieldDiff{Path: "Host", Left: "a", Right: "b"}
+	assert.Equal(t, "a", d.MaskedLeft())
+	assert.Equal(t, "b", d.MaskedRight())
+}
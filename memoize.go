@@ -0,0 +1,119 @@
+package configuration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+type runCacheKey struct{}
+
+// runCache keeps results of Memoize during a single InitValues (or Reload) call
+type runCache struct {
+	mu      sync.Mutex
+	entries map[string]*runCacheEntry
+}
+
+type runCacheEntry struct {
+	once sync.Once
+	val  interface{}
+	err  error
+}
+
+func withRunCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, runCacheKey{}, &runCache{entries: map[string]*runCacheEntry{}})
+}
+
+// Memoize returns the result of fetch cached by the key for the current InitValues (or Reload) call, so providers
+// which read many fields from the same document (a secret, an HTTP response) fetch and parse it once per run:
+//
+//	func (p secretProvider) ProvideError(ctx context.Context, field reflect.StructField, v reflect.Value, path ...string) (bool, error) {
+//		doc, err := configuration.Memoize(ctx, "secretProvider:"+p.name, func() (interface{}, error) { return p.fetch(ctx) })
+//		...
+//	}
+//
+// Errors are cached as well, so an unavailable source isn't requested for every field.
+// The context is passed to ContextProvider and ErrorProvider, without the cache (e.g. outside InitValues) fetch is called every time.
+func Memoize(ctx context.Context, key string, fetch func() (interface{}, error)) (interface{}, error) {
+	cache, ok := ctx.Value(runCacheKey{}).(*runCache)
+	if !ok {
+		return fetch()
+	}
+
+	cache.mu.Lock()
+	entry, ok := cache.entries[key]
+	if !ok {
+		entry = &runCacheEntry{}
+		cache.entries[key] = entry
+	}
+	cache.mu.Unlock()
+
+	entry.once.Do(func() {
+		entry.val, entry.err = fetch()
+	})
+	return entry.val, entry.err
+}
+
+// NewDocumentProvider creates the provider which reads values from the document (JSON or YAML) returned by fetch,
+// e.g. a secret or an HTTP response. The document is fetched and parsed once per InitValues call (see Memoize)
+// and fields are looked up by paths like in files. The name identifies the document (`.json` suffix makes it parsed as JSON).
+func NewDocumentProvider(name string, fetch func(ctx context.Context) ([]byte, error)) documentProvider {
+	return documentProvider{name: name, fetch: fetch}
+}
+
+type documentProvider struct {
+	name  string
+	fetch func(ctx context.Context) ([]byte, error)
+	opts  *options // of the configurator (see optionsBinder)
+}
+
+func (dp documentProvider) withOptions(o *options) Provider {
+	dp.opts = o
+	return dp
+}
+
+func (dp documentProvider) Provide(field reflect.StructField, v reflect.Value, path ...string) bool {
+	ok, _ := dp.ProvideError(context.Background(), field, v, path...)
+	return ok
+}
+
+// ProvideError returns ErrProviderUnavailable if the document cannot be fetched or parsed
+func (dp documentProvider) ProvideError(ctx context.Context, field reflect.StructField, v reflect.Value, path ...string) (bool, error) {
+	data, err := Memoize(ctx, "documentProvider:"+dp.name, func() (interface{}, error) {
+		return dp.document(ctx)
+	})
+	if err != nil {
+		dp.opts.errorf("documentProvider: [%s]: %v", dp.name, err)
+		return false, unavailableError(err)
+	}
+	return fileProvider{fileName: dp.name, fileData: data, opts: dp.opts}.ProvideError(ctx, field, v, path...)
+}
+
+// CheckHealth fetches and parses the document without setting values (see CheckProviders)
+func (dp documentProvider) CheckHealth(ctx context.Context) error {
+	_, err := dp.document(ctx)
+	return err
+}
+
+func (dp documentProvider) document(ctx context.Context) (interface{}, error) {
+	b, err := dp.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	decode := yaml.Unmarshal // YAML is a superset of JSON
+	if strings.HasSuffix(strings.ToLower(dp.name), ".json") {
+		decode = json.Unmarshal
+	}
+	var data interface{}
+	if err := decode(b, &data); err != nil {
+		return nil, fmt.Errorf("cannot parse: %v", err)
+	}
+	dp.opts.logf("documentProvider: fetched [%s]", dp.name)
+	return data, nil
+}
@@ -0,0 +1,71 @@
+package configuration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoize(t *testing.T) {
+	calls := 0
+	fetch := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+
+	ctx := withRunCache(context.Background())
+	for i := 0; i < 3; i++ {
+		val, err := Memoize(ctx, "doc", fetch)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, val)
+	}
+	val, _ := Memoize(ctx, "other", fetch)
+	assert.Equal(t, 2, val)
+
+	val, _ = Memoize(context.Background(), "doc", fetch)
+	assert.Equal(t, 3, val, "nothing is cached outside of InitValues")
+}
+
+func TestDocumentProvider(t *testing.T) {
+	cfg := struct {
+		Database struct {
+			User     string `json:"user"`
+			Password string `json:"password"`
+		} `json:"database"`
+		Token string `json:"token"`
+	}{}
+
+	fetches := 0
+	provider := NewDocumentProvider("secret.json", func(ctx context.Context) ([]byte, error) {
+		fetches++
+		return []byte(`{"database": {"user": "app", "password": "s3cret"}, "token": "abc"}`), nil
+	})
+	c, err := New(&cfg, WithProviders(provider))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, 1, fetches, "the document must be fetched once per run")
+	assert.Equal(t, "app", cfg.Database.User)
+	assert.Equal(t, "s3cret", cfg.Database.Password)
+	assert.Equal(t, "abc", cfg.Token)
+
+	assert.NoError(t, c.Reload())
+	assert.Equal(t, 2, fetches)
+
+	failing := NewDocumentProvider("secret", func(ctx context.Context) ([]byte, error) {
+		fetches++
+		return nil, errors.New("access denied")
+	})
+	fetches = 0
+	c, err = New(&cfg, WithProviders(failing))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+	assert.True(t, errors.Is(err, ErrProviderUnavailable))
+	assert.Equal(t, 1, fetches, "errors are cached too")
+}
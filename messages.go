@@ -0,0 +1,100 @@
+package configuration
+
+import "fmt"
+
+// IDs of user-facing messages (errors, usage of flags, generated docs) which can be reworded or translated
+// with SetMessages. Messages are formats of fmt.Sprintf, a replacement must use the same verbs,
+// explicit indexes can change their order: "%[2]s: the field %[1]s is not set".
+const (
+	MsgFieldNotSet        = "field_not_set"        // name, tags
+	MsgFieldRequired      = "field_required"       // path
+	MsgFieldError         = "field_error"          // path, error
+	MsgFieldProviderError = "field_provider_error" // name, tags, provider, error
+	MsgFieldsNotSet       = "fields_not_set"       // number of errors, list of errors
+	MsgFieldNotFound      = "field_not_found"      // path
+	MsgIncompleteGroup    = "incomplete_group"     // paths to set fields, paths to missing fields
+	MsgInvalidFormat      = "invalid_format"       // format (`duration`, `bytes`), value, hint
+	MsgValidateMin        = "validate_min"         // value, limit
+	MsgValidateMax        = "validate_max"         // value, limit
+	MsgValidateMinLen     = "validate_min_len"     // length, limit
+	MsgValidateMaxLen     = "validate_max_len"     // length, limit
+	MsgValidateOneOf      = "validate_one_of"      // value, allowed values
+	MsgUnknownValidator   = "unknown_validator"    // name
+	MsgFormatDuration     = "format_duration"
+	MsgFormatBytes        = "format_bytes"
+	MsgUnsetReport        = "unset_report"     // number of fields, list of providers with keys
+	MsgUnsetNoSources     = "unset_no_sources" // list of fields
+	MsgUnknownKey         = "unknown_key"      // key, file name
+	MsgKeySuggestion      = "key_suggestion"   // message about the unknown key, suggested key
+	MsgFlagSuggestion     = "flag_suggestion"  // error of the flag package, suggested flag
+	MsgUsageDefault       = "usage_default"    // usage, value, source
+	MsgUsageSourceEnv     = "usage_source_env" // name of ENV variable
+	MsgUsageSourceTag     = "usage_source_tag"
+	MsgUsageFormatted     = "usage_formatted" // usage, default value of the flag in the human-friendly form
+	MsgUsageNegated       = "usage_negated"   // name of the flag
+	MsgUsageAlias         = "usage_alias"     // new name of the flag
+	MsgDocsHeader         = "docs_header"
+	MsgDocsSecret         = "docs_secret"
+)
+
+var gDefaultMessages = map[string]string{
+	MsgFieldNotSet:        "configurator: field [%s] with tags [%s] cannot be set!",
+	MsgFieldRequired:      "configurator: required field [%s] is not set",
+	MsgFieldError:         "configurator: field [%s]: %v",
+	MsgFieldProviderError: "configurator: field [%s] with tags [%s] cannot be set by [%s]: %v",
+	MsgFieldsNotSet:       "configurator: %d fields cannot be set:\n%s",
+	MsgFieldNotFound:      "configurator: field [%s] not found",
+	MsgIncompleteGroup:    "either all fields of the group or none of them must be set: set [%s], missing [%s]",
+	MsgInvalidFormat:      "invalid %s [%s], %s",
+	MsgValidateMin:        "%v is less than %v",
+	MsgValidateMax:        "%v is greater than %v",
+	MsgValidateMinLen:     "length %d is less than %v",
+	MsgValidateMaxLen:     "length %d is greater than %v",
+	MsgValidateOneOf:      "%v is not one of [%s]",
+	MsgUnknownValidator:   "unknown validator [%s]",
+	MsgFormatDuration:     "expects e.g. 30s, 5m, 2d",
+	MsgFormatBytes:        "expects e.g. 512, 64KB, 10MiB",
+	MsgUnsetReport:        "configurator: %d fields are not set, provide them via:\n%s",
+	MsgUnsetNoSources:     "no sources: %s",
+	MsgUnknownKey:         "fileProvider: unknown key [%s] in [%s]",
+	MsgKeySuggestion:      "%s, did you mean [%s]?",
+	MsgFlagSuggestion:     "%v, did you mean -%s?",
+	MsgUsageDefault:       "%s (default %q from %s)",
+	MsgUsageSourceEnv:     "env %s",
+	MsgUsageSourceTag:     "default tag",
+	MsgUsageFormatted:     "%s (%s)",
+	MsgUsageNegated:       "disable -%s",
+	MsgUsageAlias:         "deprecated, use -%s",
+	MsgDocsHeader:         "| Key | Type | Env | Flag | Default | Description |",
+	MsgDocsSecret:         "(secret)",
+}
+
+var gMessages = copyMessages(gDefaultMessages)
+
+// SetMessages replaces user-facing messages by their IDs (see MsgFieldNotSet and others), unknown IDs are ignored
+// and a nil map restores the defaults. Like SetTagName, it must be called before creating providers:
+// usage of flags is built by NewFlagProvider.
+func SetMessages(messages map[string]string) {
+	if messages == nil {
+		gMessages = copyMessages(gDefaultMessages)
+		return
+	}
+	for id, format := range messages {
+		if _, ok := gDefaultMessages[id]; ok {
+			gMessages[id] = format
+		}
+	}
+}
+
+func copyMessages(messages map[string]string) map[string]string {
+	result := make(map[string]string, len(messages))
+	for id, format := range messages {
+		result[id] = format
+	}
+	return result
+}
+
+// msg formats the message with the given ID
+func msg(id string, args ...interface{}) string {
+	return fmt.Sprintf(gMessages[id], args...)
+}
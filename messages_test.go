@@ -0,0 +1,46 @@
+package configuration
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetMessages(t *testing.T) {
+	defer SetMessages(nil)
+
+	type config struct {
+		Name    string        `flag:"messages_name||Name des Dienstes" default:"app"`
+		Empty   string        `env:"MESSAGES_TEST_EMPTY"`
+		Timeout time.Duration `default:"soon" format:"duration"`
+	}
+
+	SetMessages(map[string]string{
+		MsgFieldNotSet:    "Feld %[1]s kann nicht gesetzt werden (%[2]s)",
+		MsgUsageDefault:   "%s (Standard %q aus %s)",
+		MsgUsageSourceTag: "default-Tag",
+		MsgFormatDuration: "z.B. 30s, 5m",
+		"unknown_message": "ignored",
+	})
+	assert.NotContains(t, gMessages, "unknown_message")
+
+	field := reflect.TypeOf(config{}).Field(0)
+	assert.Equal(t, `Name des Dienstes (Standard "app" aus default-Tag)`, usageWithEffectiveDefault(field, getFlagData(field, nil), nil))
+
+	var cfg config
+	c, err := New(&cfg, WithProviders(NewEnvProvider(), NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `Feld Empty kann nicht gesetzt werden (env:"MESSAGES_TEST_EMPTY")`)
+		assert.Contains(t, err.Error(), "invalid duration [soon], z.B. 30s, 5m")
+	}
+
+	SetMessages(nil)
+	assert.Equal(t, gDefaultMessages, gMessages)
+	assert.Equal(t, "configurator: field [Empty] with tags [] cannot be set!", (&FieldError{name: "Empty", Err: ErrNotSet}).Error())
+}
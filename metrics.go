@@ -0,0 +1,41 @@
+//go:build !tinygo
+// +build !tinygo
+
+package configuration
+
+import (
+	"expvar"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// PublishExpvar publishes values of the fields tagged with `metric:"true"` via expvar under the given name
+// (available at /debug/vars) together with the number of InitValues calls, the time of the last one
+// and the number of fields it processed (see FieldsProcessed):
+//
+//	{"generation": 1, "updated_at": "2020-01-02T03:04:05Z", "fields_processed": 42, "fields": {"Server.MaxConns": 100}}
+//
+// Values are read at the moment of the request. Panics if the name is already registered (see expvar.Publish).
+func (c configurator) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(c.metrics))
+}
+
+func (c configurator) metrics() interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	fields := map[string]interface{}{}
+	walkFields(reflect.ValueOf(c.config), nil, c.opts.tags(), func(path string, field reflect.StructField, v reflect.Value) {
+		if ok, _ := strconv.ParseBool(getMetricTag(field)); ok {
+			fields[path] = v.Interface()
+		}
+	})
+
+	return map[string]interface{}{
+		"generation":       c.stats.generation,
+		"updated_at":       c.stats.updatedAt.Format(time.RFC3339),
+		"fields_processed": c.stats.fieldsProcessed,
+		"fields":           fields,
+	}
+}
@@ -0,0 +1,45 @@
+//go:build !tinygo
+// +build !tinygo
+
+package configuration
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	cfg := struct {
+		Name     string `default:"test_name"`
+		MaxConns int    `default:"100" metric:"true"`
+		Server   struct {
+			Timeout time.Duration `json:"timeout" default:"1s" metric:"true"`
+			Secret  string        `default:"secret" metric:"false"`
+		}
+	}{}
+
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	c.PublishExpvar("test_config")
+
+	var got struct {
+		Generation int64                  `json:"generation"`
+		UpdatedAt  time.Time              `json:"updated_at"`
+		Fields     map[string]interface{} `json:"fields"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(expvar.Get("test_config").String()), &got))
+
+	assert.Equal(t, int64(1), got.Generation)
+	assert.False(t, got.UpdatedAt.IsZero())
+	assert.Equal(t, map[string]interface{}{
+		"MaxConns":       float64(100),
+		"Server.timeout": float64(time.Second),
+	}, got.Fields)
+}
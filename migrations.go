@@ -0,0 +1,81 @@
+package configuration
+
+import (
+	"fmt"
+)
+
+// ConfigVersionKey is the key in config files which holds the version of the file schema
+const ConfigVersionKey = "config_version"
+
+// Migration upgrades data decoded from a config file by one version (renames, splits, unit changes, etc.).
+// Nested objects are passed as map[string]interface{} for both json and yaml files.
+type Migration func(data map[string]interface{}) error
+
+// WithMigrations upgrades data of the file from the version stored in `config_version` key (0 if absent)
+// to the `current` one before any value is provided: migrations[n] upgrades the data from version n to n+1.
+// If migration fails, the provider doesn't provide any values.
+func (fp fileProvider) WithMigrations(current int, migrations map[int]Migration) fileProvider {
+	fp.migrations, fp.currentVersion = migrations, current
+	if fp.fileData == nil {
+		return fp
+	}
+
+	data, err := migrate(fp.fileData, current, migrations, fp.opts)
+	if err != nil {
+		fp.opts.errorf("fileProvider: %v", err)
+		fp.fileData = nil
+		return fp
+	}
+	fp.fileData = data
+	return fp
+}
+
+func migrate(fileData interface{}, current int, migrations map[int]Migration, o *options) (map[string]interface{}, error) {
+	data, ok := stringMapsDeep(fileData).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot migrate [%v]: not an object", fileData)
+	}
+
+	version := 0
+	if raw, ok := data[ConfigVersionKey]; ok {
+		v, err := parseInt(fmt.Sprint(raw), 0)
+		if err != nil {
+			return nil, fmt.Errorf("wrong %s [%v]: %v", ConfigVersionKey, raw, err)
+		}
+		version = int(v)
+	}
+	if version > current {
+		return nil, fmt.Errorf("%s [%d] is newer than supported [%d]", ConfigVersionKey, version, current)
+	}
+
+	for ; version < current; version++ {
+		fn, ok := migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration from version [%d]", version)
+		}
+		if err := fn(data); err != nil {
+			return nil, fmt.Errorf("migration from version [%d]: %v", version, err)
+		}
+		o.logf("fileProvider: migrated config from version [%d] to [%d]", version, version+1)
+	}
+	data[ConfigVersionKey] = current
+	return data, nil
+}
+
+// stringMapsDeep converts all maps decoded from yaml (map[interface{}]interface{}) to map[string]interface{}
+func stringMapsDeep(i interface{}) interface{} {
+	switch val := i.(type) {
+	case []interface{}:
+		for j := range val {
+			val[j] = stringMapsDeep(val[j])
+		}
+		return val
+	case map[string]interface{}, map[interface{}]interface{}:
+		m, _ := toStringMap(val)
+		for k := range m {
+			m[k] = stringMapsDeep(m[k])
+		}
+		return m
+	}
+	return i
+}
@@ -0,0 +1,100 @@
+package configuration
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type migrationsConfig struct {
+	Version int           `json:"config_version"`
+	Host    string        `json:"host"`
+	Port    int           `json:"port"`
+	Timeout time.Duration `json:"timeout"`
+}
+
+var testMigrations = map[int]Migration{
+	0: func(data map[string]interface{}) error { // `server` was split into `host` and `port`
+		server, _ := data["server"].(string)
+		parts := strings.SplitN(server, ":", 2)
+		if len(parts) != 2 {
+			return errors.New("wrong server")
+		}
+		data["host"], data["port"] = parts[0], parts[1]
+		delete(data, "server")
+		return nil
+	},
+	1: func(data map[string]interface{}) error { // `timeout_ms` was replaced with `timeout` duration
+		if ms, ok := data["timeout_ms"]; ok {
+			data["timeout"] = toDuration(ms)
+			delete(data, "timeout_ms")
+		}
+		return nil
+	},
+}
+
+func toDuration(ms interface{}) string {
+	switch v := ms.(type) {
+	case int:
+		return (time.Duration(v) * time.Millisecond).String()
+	case float64:
+		return (time.Duration(v) * time.Millisecond).String()
+	}
+	return ""
+}
+
+func TestFileProvider_WithMigrations(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		expected migrationsConfig
+		wantErr  bool
+	}{
+		{
+			name:     "from v0",
+			file:     "./testdata/migrations/v0.yml",
+			expected: migrationsConfig{Version: 2, Host: "localhost", Port: 8080, Timeout: 1500 * time.Millisecond},
+		},
+		{
+			name:     "from v1",
+			file:     "./testdata/migrations/v1.json",
+			expected: migrationsConfig{Version: 2, Host: "example.com", Port: 9090, Timeout: 500 * time.Millisecond},
+		},
+		{
+			name:     "newer version",
+			file:     "./testdata/migrations/v3.yml",
+			expected: migrationsConfig{},
+			wantErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var cfg migrationsConfig
+			fp := NewFileProvider(test.file).WithMigrations(2, testMigrations)
+
+			c, err := New(&cfg, WithProviders(fp))
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantErr, c.InitValues() != nil)
+
+			assert.Equal(t, test.expected, cfg)
+		})
+	}
+}
+
+func TestMigrate_Errors(t *testing.T) {
+	_, err := migrate(map[string]interface{}{}, 1, nil, nil)
+	assert.EqualError(t, err, "no migration from version [0]")
+
+	_, err = migrate(map[string]interface{}{ConfigVersionKey: "abc"}, 1, testMigrations, nil)
+	assert.Error(t, err)
+
+	_, err = migrate(map[string]interface{}{"server": "localhost"}, 1, testMigrations, nil)
+	assert.EqualError(t, err, "migration from version [0]: wrong server")
+
+	_, err = migrate([]interface{}{}, 1, testMigrations, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,20 @@
+package configuration
+
+import "fmt"
+
+// MustLoad fills up the configuration struct (ptr must be a pointer to it) with the providers.
+// It panics with the error listing all fields which cannot be set,
+// so it's meant for main() functions and small tools:
+//
+//	var cfg Config
+//	configuration.MustLoad(&cfg, configuration.NewEnvProvider(), configuration.NewDefaultProvider())
+func MustLoad(ptr interface{}, providers ...Provider) {
+	c, err := New(ptr, WithProviders(providers...))
+	if err != nil {
+		panic(fmt.Errorf("configuration: cannot load %T: %v", ptr, err))
+	}
+
+	if err := c.InitValues(); err != nil {
+		panic(fmt.Errorf("configuration: cannot load %T: %v", ptr, err))
+	}
+}
@@ -0,0 +1,47 @@
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mustLoadConfig struct {
+	Name     string `default:"app"`
+	Database struct {
+		Host string `default:"localhost"`
+		Port int    `default:"5432"`
+	}
+}
+
+func TestMustLoad(t *testing.T) {
+	var cfg mustLoadConfig
+	MustLoad(&cfg, NewDefaultProvider())
+	assert.Equal(t, "app", cfg.Name)
+	assert.Equal(t, "localhost", cfg.Database.Host)
+	assert.Equal(t, 5432, cfg.Database.Port)
+}
+
+func TestMustLoad_Panics(t *testing.T) {
+	type config struct {
+		Name     string `default:"app"`
+		LogLevel string
+		Database struct {
+			Host string
+		}
+	}
+
+	var cfg config
+	assert.PanicsWithError(t, "configuration: cannot load *configuration.config: configurator: 2 fields cannot be set:\n"+
+		"\tconfigurator: field [LogLevel] with tags [] cannot be set!\n"+
+		"\tconfigurator: field [Host] with tags [] cannot be set!", func() {
+		MustLoad(&cfg, NewDefaultProvider())
+	})
+
+	assert.PanicsWithError(t, "configuration: cannot load *configuration.config: providers not found", func() {
+		MustLoad(&cfg)
+	})
+	assert.Panics(t, func() {
+		MustLoad(cfg, NewDefaultProvider())
+	})
+}
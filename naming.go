@@ -0,0 +1,73 @@
+package configuration
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy converts parts of the path to a field (names of fields or keys from tags)
+// into a single key following some naming convention
+type NamingStrategy func(path ...string) string
+
+// Naming strategies for keys derived from names of fields
+var (
+	// SnakeCase converts ("Database", "MaxConns") into "database_max_conns"
+	SnakeCase NamingStrategy = func(path ...string) string {
+		return strings.ToLower(strings.Join(splitWords(path), "_"))
+	}
+	// ScreamingSnakeCase converts ("Database", "MaxConns") into "DATABASE_MAX_CONNS"
+	ScreamingSnakeCase NamingStrategy = func(path ...string) string {
+		return strings.ToUpper(strings.Join(splitWords(path), "_"))
+	}
+	// KebabCase converts ("Database", "MaxConns") into "database-max-conns"
+	KebabCase NamingStrategy = func(path ...string) string {
+		return strings.ToLower(strings.Join(splitWords(path), "-"))
+	}
+	// CamelCase converts ("Database", "MaxConns") into "databaseMaxConns"
+	CamelCase NamingStrategy = func(path ...string) string {
+		words := splitWords(path)
+		for i, w := range words {
+			w = strings.ToLower(w)
+			if i > 0 {
+				w = strings.ToUpper(w[:1]) + w[1:]
+			}
+			words[i] = w
+		}
+		return strings.Join(words, "")
+	}
+)
+
+// splitWords splits names like "HTTPServer", "max_conns" or "db-host2" into words
+func splitWords(parts []string) []string {
+	var words []string
+	for _, part := range parts {
+		var (
+			runes = []rune(part)
+			word  []rune
+		)
+		for i, r := range runes {
+			if r == '_' || r == '-' || r == '.' || unicode.IsSpace(r) {
+				if len(word) > 0 {
+					words = append(words, string(word))
+					word = nil
+				}
+				continue
+			}
+
+			if len(word) > 0 && unicode.IsUpper(r) {
+				prev := runes[i-1]
+				nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				// "maxConns" -> "max" "Conns", "HTTPServer" -> "HTTP" "Server"
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+					words = append(words, string(word))
+					word = nil
+				}
+			}
+			word = append(word, r)
+		}
+		if len(word) > 0 {
+			words = append(words, string(word))
+		}
+	}
+	return words
+}
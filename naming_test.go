@@ -0,0 +1,32 @@
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamingStrategies(t *testing.T) {
+	tests := map[string]struct {
+		strategy NamingStrategy
+		path     []string
+		expected string
+	}{
+		"snake":           {strategy: SnakeCase, path: []string{"Database", "MaxConns"}, expected: "database_max_conns"},
+		"snake acronym":   {strategy: SnakeCase, path: []string{"HTTPServer", "TLSConfig"}, expected: "http_server_tls_config"},
+		"snake from tag":  {strategy: SnakeCase, path: []string{"db", "max_conns"}, expected: "db_max_conns"},
+		"screaming snake": {strategy: ScreamingSnakeCase, path: []string{"Database", "maxConns"}, expected: "DATABASE_MAX_CONNS"},
+		"kebab":           {strategy: KebabCase, path: []string{"Server", "ReadTimeout"}, expected: "server-read-timeout"},
+		"kebab digits":    {strategy: KebabCase, path: []string{"Redis2Addr"}, expected: "redis2-addr"},
+		"camel":           {strategy: CamelCase, path: []string{"Database", "max_conns"}, expected: "databaseMaxConns"},
+		"camel acronym":   {strategy: CamelCase, path: []string{"APIKey"}, expected: "apiKey"},
+		"empty":           {strategy: SnakeCase, path: nil, expected: ""},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.strategy(test.path...))
+		})
+	}
+}
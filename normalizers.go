@@ -0,0 +1,103 @@
+package configuration
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Normalizer returns the canonical form of the value which is set into the field,
+// e.g. strings.TrimSpace for strings. The result must be of the same type as the value.
+type Normalizer func(value interface{}) (interface{}, error)
+
+// built-in normalizers for `normalize` tag, see WithNormalizer
+var builtinNormalizers = map[string]Normalizer{
+	"trim":      stringNormalizer(strings.TrimSpace),
+	"lower":     stringNormalizer(strings.ToLower),
+	"upper":     stringNormalizer(strings.ToUpper),
+	"trimslash": stringNormalizer(func(s string) string { return strings.TrimRight(s, "/") }),
+}
+
+func stringNormalizer(fn func(string) string) Normalizer {
+	return func(value interface{}) (interface{}, error) {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%T is not a string", value)
+		}
+		return fn(s), nil
+	}
+}
+
+func getNormalizeTag(f reflect.StructField) string {
+	return f.Tag.Get("normalize")
+}
+
+// normalize applies normalizers of the type of the field (see WithTypeNormalizer) and then
+// normalizers listed in `normalize` tag in order of definition: `normalize:"trim,lower"`
+func (c configurator) normalize(field reflect.StructField, v reflect.Value) error {
+	for t, fn := range c.opts.typeNormalizers {
+		if err := normalizeValue(v, t, fn); err != nil {
+			return err
+		}
+	}
+
+	tag := getNormalizeTag(field)
+	if tag == "" {
+		return nil
+	}
+	for _, name := range strings.Split(tag, ",") {
+		name = strings.TrimSpace(name)
+		fn, ok := c.opts.normalizers[name]
+		if !ok {
+			fn, ok = builtinNormalizers[name]
+		}
+		if !ok {
+			return fmt.Errorf("unknown normalizer [%s]", name)
+		}
+		if err := normalizeValue(v, nil, fn); err != nil {
+			return fmt.Errorf("normalizer [%s]: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// normalizeValue applies the normalizer to the value of the type `t` (to any value if `t` is nil),
+// values of pointers and items of slices and arrays are normalized one by one
+func normalizeValue(v reflect.Value, t reflect.Type, fn Normalizer) error {
+	switch {
+	case t != nil && v.Type() == t:
+		return setNormalized(v, fn)
+
+	case v.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return normalizeValue(v.Elem(), t, fn)
+
+	case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8:
+		for i := 0; i < v.Len(); i++ {
+			if err := normalizeValue(v.Index(i), t, fn); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case t == nil:
+		return setNormalized(v, fn)
+	}
+	return nil
+}
+
+func setNormalized(v reflect.Value, fn Normalizer) error {
+	result, err := fn(v.Interface())
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(result)
+	if !rv.IsValid() || rv.Type() != v.Type() {
+		return fmt.Errorf("normalizer returned %T instead of %v", result, v.Type())
+	}
+	v.Set(rv)
+	return nil
+}
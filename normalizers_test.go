@@ -0,0 +1,102 @@
+package configuration
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type hostname string
+
+func TestNormalizers(t *testing.T) {
+	type config struct {
+		Host    string        `default:"  DB.Example.COM " normalize:"trim,lower"`
+		BaseURL string        `default:"https://example.com///" normalize:"trimslash"`
+		Hosts   []string      `default:"A.example.com; B.example.com" normalize:"lower"`
+		Region  *string       `default:"eu-west-1" normalize:"region"`
+		Timeout time.Duration `default:"1500ms"`
+		Primary hostname      `default:"DB1.example.com"`
+		Mirrors []hostname    `default:"DB2.example.com;DB3.example.com"`
+		Plain   string        `default:" as is "`
+	}
+
+	roundDuration := func(value interface{}) (interface{}, error) {
+		return value.(time.Duration).Truncate(time.Second), nil
+	}
+	lowerHostname := func(value interface{}) (interface{}, error) {
+		return hostname(strings.ToLower(string(value.(hostname)))), nil
+	}
+
+	var cfg config
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()),
+		WithNormalizer("region", stringNormalizer(strings.ToUpper)),
+		WithTypeNormalizer(time.Duration(0), roundDuration),
+		WithTypeNormalizer(hostname(""), lowerHostname),
+	)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	assert.Equal(t, "db.example.com", cfg.Host)
+	assert.Equal(t, "https://example.com", cfg.BaseURL)
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, cfg.Hosts)
+	if assert.NotNil(t, cfg.Region) {
+		assert.Equal(t, "EU-WEST-1", *cfg.Region)
+	}
+	assert.Equal(t, time.Second, cfg.Timeout)
+	assert.Equal(t, hostname("db1.example.com"), cfg.Primary)
+	assert.Equal(t, []hostname{"db2.example.com", "db3.example.com"}, cfg.Mirrors)
+	assert.Equal(t, " as is ", cfg.Plain)
+}
+
+func TestNormalizers_Errors(t *testing.T) {
+	tests := map[string]struct {
+		cfg      interface{}
+		opts     []Option
+		expected string
+	}{
+		"unknown normalizer": {
+			cfg: &struct {
+				Host string `default:"host" normalize:"trim,nope"`
+			}{},
+			expected: "configurator: field [Host] with tags [default:\"host\" normalize:\"trim,nope\"] cannot be set by [defaultProvider]: unknown normalizer [nope]",
+		},
+		"not a string": {
+			cfg: &struct {
+				Port int `default:"80" normalize:"trim"`
+			}{},
+			expected: "normalizer [trim]: int is not a string",
+		},
+		"wrong type": {
+			cfg: &struct {
+				Port int `default:"80" normalize:"port"`
+			}{},
+			opts:     []Option{WithNormalizer("port", func(interface{}) (interface{}, error) { return "80", nil })},
+			expected: "normalizer [port]: normalizer returned string instead of int",
+		},
+		"failed": {
+			cfg: &struct {
+				Port int `default:"80"`
+			}{},
+			opts:     []Option{WithTypeNormalizer(0, func(interface{}) (interface{}, error) { return nil, errors.New("reserved port") })},
+			expected: "reserved port",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			c, err := New(test.cfg, append([]Option{WithProviders(NewDefaultProvider())}, test.opts...)...)
+			if err != nil {
+				t.Fatal("unexpected err: ", err)
+			}
+			err = c.InitValues()
+			assert.True(t, errors.Is(err, ErrParse))
+			assert.Contains(t, err.Error(), test.expected)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package configuration
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// parseInt parses decimal integers allowing digit separators and exponent forms: "1_000_000", "1e6"
+func parseInt(val string, bitSize int) (int64, error) {
+	s, err := stripDigitSeparators(val)
+	if err != nil {
+		return 0, err
+	}
+
+	i, err := strconv.ParseInt(s, 10, bitSize)
+	if err == nil || !isExponentForm(s) {
+		return i, err
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	minVal, maxVal := -math.Ldexp(1, bitSize-1), math.Ldexp(1, bitSize-1)
+	if f != math.Trunc(f) || f < minVal || f >= maxVal {
+		return 0, fmt.Errorf("value %q is not an integer of %d bits", val, bitSize)
+	}
+	return int64(f), nil
+}
+
+// parseUint is the same as parseInt but for unsigned integers
+func parseUint(val string, bitSize int) (uint64, error) {
+	s, err := stripDigitSeparators(val)
+	if err != nil {
+		return 0, err
+	}
+
+	i, err := strconv.ParseUint(s, 10, bitSize)
+	if err == nil || !isExponentForm(s) {
+		return i, err
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if f != math.Trunc(f) || f < 0 || f >= math.Ldexp(1, bitSize) {
+		return 0, fmt.Errorf("value %q is not an unsigned integer of %d bits", val, bitSize)
+	}
+	return uint64(f), nil
+}
+
+// parseFloat parses floats allowing digit separators: "1_000.5", "2.5e3"
+func parseFloat(val string, bitSize int) (float64, error) {
+	s, err := stripDigitSeparators(val)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(s, bitSize)
+}
+
+// stripDigitSeparators removes `_` placed between digits: "1_000_000" -> "1000000"
+func stripDigitSeparators(val string) (string, error) {
+	s := strings.TrimSpace(val)
+	if !strings.Contains(s, "_") {
+		return s, nil
+	}
+
+	isDigit := func(i int) bool { return i >= 0 && i < len(s) && s[i] >= '0' && s[i] <= '9' }
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '_' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if !isDigit(i-1) || !isDigit(i+1) {
+			return "", fmt.Errorf("invalid digit separator in %q", val)
+		}
+	}
+	return b.String(), nil
+}
+
+func isExponentForm(s string) bool {
+	return strings.ContainsAny(s, "eE")
+}
@@ -0,0 +1,80 @@
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNumbers(t *testing.T) {
+	tests := map[string]struct {
+		fn       func(string) (interface{}, error)
+		input    string
+		expected interface{}
+		fail     bool
+	}{
+		"int plain":           {fn: intFn(64), input: "42", expected: int64(42)},
+		"int separators":      {fn: intFn(64), input: "1_000_000", expected: int64(1000000)},
+		"int exponent":        {fn: intFn(64), input: "1e6", expected: int64(1000000)},
+		"int negative exp":    {fn: intFn(64), input: "-2.5E3", expected: int64(-2500)},
+		"int leading zero":    {fn: intFn(64), input: "010", expected: int64(10)},
+		"int fraction":        {fn: intFn(64), input: "1.5e0", fail: true},
+		"int overflow":        {fn: intFn(8), input: "1e3", fail: true},
+		"int bad separator":   {fn: intFn(64), input: "1__000", fail: true},
+		"int trailing sep":    {fn: intFn(64), input: "1000_", fail: true},
+		"uint separators":     {fn: uintFn(64), input: "4_294_967_296", expected: uint64(4294967296)},
+		"uint exponent":       {fn: uintFn(16), input: "6.5e4", expected: uint64(65000)},
+		"uint negative":       {fn: uintFn(64), input: "-1e3", fail: true},
+		"float separators":    {fn: floatFn(64), input: "1_000.25", expected: 1000.25},
+		"float exponent":      {fn: floatFn(64), input: "2.5e-3", expected: 0.0025},
+		"float bad separator": {fn: floatFn(64), input: "_1.5", fail: true},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			got, err := test.fn(test.input)
+			if test.fail {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, got)
+		})
+	}
+}
+
+func TestSetValue_NumericLiterals(t *testing.T) {
+	cfg := struct {
+		MaxBytes int64   `default:"10_485_760"`
+		Limit    uint32  `default:"1e6"`
+		Ratio    float64 `default:"1_000.5"`
+		Sizes    []int   `default:"1_024; 2e3"`
+		Ptr      *int    `default:"5e2"`
+	}{}
+
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	assert.Equal(t, int64(10485760), cfg.MaxBytes)
+	assert.Equal(t, uint32(1000000), cfg.Limit)
+	assert.Equal(t, 1000.5, cfg.Ratio)
+	assert.Equal(t, []int{1024, 2000}, cfg.Sizes)
+	assert.NotNil(t, cfg.Ptr)
+	assert.Equal(t, 500, *cfg.Ptr)
+}
+
+func intFn(bitSize int) func(string) (interface{}, error) {
+	return func(s string) (interface{}, error) { return parseInt(s, bitSize) }
+}
+
+func uintFn(bitSize int) func(string) (interface{}, error) {
+	return func(s string) (interface{}, error) { return parseUint(s, bitSize) }
+}
+
+func floatFn(bitSize int) func(string) (interface{}, error) {
+	return func(s string) (interface{}, error) { return parseFloat(s, bitSize) }
+}
@@ -0,0 +1,350 @@
+package configuration
+
+import (
+	"log"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Option configures the configurator (see New)
+type Option func(*options)
+
+type options struct {
+	providers        []Provider
+	loggingEnabled   bool
+	logger           Logger
+	failIfCannotSet  bool
+	stopOnFirstError bool
+	strictCoercion   bool
+	tagNames         tagNames
+	logLevel         LogLevel
+	logLevels        map[string]LogLevel
+	logPaths         []string
+	watchInterval    time.Duration
+	normalizers      map[string]Normalizer       // for `normalize` tag
+	typeNormalizers  map[reflect.Type]Normalizer // for all fields of the type
+	validators       map[string]Validator        // for `validate` tag
+	allowUnset       bool
+	secretAudit      func(SecretAccess)
+	ignoredKeys      func(IgnoredKeys) // see WithIgnoredKeysReport
+	unsetReport      bool
+	trackAccess      bool
+	timingReport     bool
+	trace            bool
+	environment      string // the name of the selected environment (see WithEnvironment)
+	environments     Environments
+	bootstrapFile    string // see WithBootstrapFile
+	maxFields        int    // 0 disables the limit
+	maxDepth         int
+
+	resolving atomic.Value // path to the field which is being resolved, for WithLogPaths
+	jitters   sync.Map     // path to the field -> the random part of the jitter of its default (see jitterFactor)
+}
+
+// WithProviders sets the providers respecting their order: first defined -> first executed
+func WithProviders(providers ...Provider) Option {
+	return func(o *options) {
+		o.providers = append(o.providers, providers...)
+	}
+}
+
+// WithEnvironment selects the chain of providers by the name of the environment, so one binary codifies
+// the configuration strategy of all environments:
+//
+//	WithEnvironment(os.Getenv("APP_ENV"), Environments{
+//		"dev":  func() ([]Provider, error) { return []Provider{NewFileProvider("dev.yml"), NewEnvProvider()}, nil },
+//		"prod": func() ([]Provider, error) { return NewProvidersFromURLs("env://?prefix=APP", "consul://...") },
+//	})
+//
+// Providers of WithProviders go before the chain of the environment. New fails if the environment is unknown.
+func WithEnvironment(name string, environments Environments) Option {
+	return func(o *options) {
+		o.environment = name
+		o.environments = environments
+	}
+}
+
+// WithBootstrapFile appends providers described in the bootstrap file (see NewProvidersFromFile)
+// after providers of WithProviders and WithEnvironment. New fails if the file cannot be read.
+func WithBootstrapFile(fileName string) Option {
+	return func(o *options) {
+		o.bootstrapFile = fileName
+	}
+}
+
+// WithLogger enables logging of the resolution of every field with the given logger (e.g. log.Printf)
+func WithLogger(l Logger) Option {
+	return func(o *options) {
+		o.loggingEnabled = true
+		o.logger = l
+	}
+}
+
+// WithLogLevel sets the verbosity of logs (see WithLogger) of the given sources: names of providers
+// (e.g. `envProvider`, `fileProvider`) or `configurator`. Without sources it sets the level for all of them:
+//
+//	WithLogLevel(LogErrors), WithLogLevel(LogTrace, "fileProvider")
+func WithLogLevel(level LogLevel, sources ...string) Option {
+	return func(o *options) {
+		if len(sources) == 0 {
+			o.logLevel = level
+			return
+		}
+		if o.logLevels == nil {
+			o.logLevels = map[string]LogLevel{}
+		}
+		for _, source := range sources {
+			o.logLevels[source] = level
+		}
+	}
+}
+
+// WithLogPaths limits logs of the resolution of fields to the fields matching any of the patterns
+// (see path.Match), e.g. WithLogPaths("Database.*") logs only fields under `Database`
+func WithLogPaths(patterns ...string) Option {
+	return func(o *options) {
+		o.logPaths = append(o.logPaths, patterns...)
+	}
+}
+
+// WithWatchInterval sets how often Watch checks files for changes (1s by default)
+func WithWatchInterval(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.watchInterval = d
+		}
+	}
+}
+
+// WithNormalizer registers the normalizer which can be referred from `normalize` tag by the name
+// (it overrides the built-in normalizers: `trim`, `lower`, `upper`, `trimslash`):
+//
+//	WithNormalizer("host", normalizeHost) // Host string `env:"HOST" normalize:"trim,host"`
+func WithNormalizer(name string, fn Normalizer) Option {
+	return func(o *options) {
+		if o.normalizers == nil {
+			o.normalizers = map[string]Normalizer{}
+		}
+		o.normalizers[name] = fn
+	}
+}
+
+// WithTypeNormalizer registers the normalizer for all fields of the type of the sample value
+// (including items of slices and values of pointers), it's applied before normalizers from `normalize` tag:
+//
+//	WithTypeNormalizer(url.URL{}, stripTrailingSlash)
+func WithTypeNormalizer(sample interface{}, fn Normalizer) Option {
+	return func(o *options) {
+		if o.typeNormalizers == nil {
+			o.typeNormalizers = map[reflect.Type]Normalizer{}
+		}
+		o.typeNormalizers[reflect.TypeOf(sample)] = fn
+	}
+}
+
+// WithValidator registers the validator which can be referred from `validate` tag by the name
+// (it overrides the built-in rules: `min`, `max`, `oneof`):
+//
+//	WithValidator("port", checkPort) // Port int `env:"PORT" validate:"port"`
+func WithValidator(name string, fn Validator) Option {
+	return func(o *options) {
+		if o.validators == nil {
+			o.validators = map[string]Validator{}
+		}
+		o.validators[name] = fn
+	}
+}
+
+// AllowUnset makes fields which aren't found by any provider keep their values instead of failing
+// unless they are tagged `required:"true"`, so optional fields can be left empty
+func AllowUnset() Option {
+	return func(o *options) {
+		o.allowUnset = true
+	}
+}
+
+// WithUnsetReport makes InitValues try all fields and return *UnsetReport which lists every field
+// not set by any provider with ENV variables, flags and keys of files which can set it (see KeyDescriber)
+// instead of failing on the first one. Other errors are returned as usual.
+func WithUnsetReport() Option {
+	return func(o *options) {
+		o.unsetReport = true
+	}
+}
+
+// WithIgnoredKeysReport makes InitValues (and Reload) call fn with keys of sources which don't match any field,
+// e.g. settings left in files and env after changes of the schema, so they can be exported as telemetry
+// and cleaned up across the fleet. Sources implement IgnoredKeysLister (files, env with the prefix).
+// fn is called while the configurator is locked and must not call its methods.
+func WithIgnoredKeysReport(fn func(IgnoredKeys)) Option {
+	return func(o *options) {
+		o.ignoredKeys = fn
+	}
+}
+
+// WithTimingReport makes InitValues (and Reload) log how long it took and the time spent in every provider
+// with the logger (see WithLogger, log.Printf by default) even if other logs are disabled:
+//
+//	configurator: InitValues took 4s: SSMProvider 3.2s, fileProvider 700ms, envProvider 100ms
+//
+// Timings of the last call are returned by Timings as well.
+func WithTimingReport() Option {
+	return func(o *options) {
+		o.timingReport = true
+	}
+}
+
+// WithTrace makes InitValues (and Reload) record the reproducibility manifest: versions of providers, digests
+// of files, answers of every provider for every field with timestamps and hashes of values (see Manifest).
+// The manifest of the last call is returned by Manifest.
+func WithTrace() Option {
+	return func(o *options) {
+		o.trace = true
+	}
+}
+
+// WithAccessTracking makes the configurator count reads of fields via Get, so fields which are never read
+// can be found with UnreadFields. Direct reads of the configuration object can't be tracked.
+func WithAccessTracking() Option {
+	return func(o *options) {
+		o.trackAccess = true
+	}
+}
+
+// WithSecretAudit registers the hook which is called every time the value of a field tagged `secret:"true"`
+// is read via Get or Explain (and the admin endpoint), e.g. to write the audit log of access to credentials.
+// The hook is called synchronously after the configurator is unlocked.
+func WithSecretAudit(fn func(SecretAccess)) Option {
+	return func(o *options) {
+		o.secretAudit = fn
+	}
+}
+
+// WithMaxFields limits the number of fields of the configuration object (10000 by default, 0 disables the limit),
+// fields of all items of maps are counted as well. New fails if the type has more fields, InitValues fails
+// once it processes more fields, both with ErrLimitExceeded. See FieldsProcessed for the last number.
+func WithMaxFields(n int) Option {
+	return func(o *options) {
+		o.maxFields = n
+	}
+}
+
+// WithMaxDepth limits nesting of structs in the configuration object (32 by default),
+// New (and InitValues for maps of structs) fails with ErrLimitExceeded if it's nested deeper
+func WithMaxDepth(n int) Option {
+	return func(o *options) {
+		o.maxDepth = n
+	}
+}
+
+// FailIfCannotSet makes the program exit (os.Exit(1)) if any field cannot be set
+func FailIfCannotSet() Option {
+	return func(o *options) {
+		o.failIfCannotSet = true
+	}
+}
+
+// StopOnFirstError makes InitValues return the error of the first field which cannot be set
+// instead of trying all fields and returning the error listing all of them
+func StopOnFirstError() Option {
+	return func(o *options) {
+		o.stopOnFirstError = true
+	}
+}
+
+// ContinueOnError does nothing: InitValues tries all fields unless StopOnFirstError is given.
+//
+// Deprecated: it's the default behavior.
+func ContinueOnError() Option {
+	return func(o *options) {}
+}
+
+// WithStrictCoercion makes unparsable, overflowing (`300` into `int8`), lossy (`1.5` into `int`)
+// and ambiguous (`1` or `t` into `bool`) values errors instead of zero or truncated values,
+// so the provider doesn't set the field and the next one is tried
+func WithStrictCoercion() Option {
+	return func(o *options) {
+		o.strictCoercion = true
+	}
+}
+
+// WithTagName makes providers read the tag `name` instead of `tag` (one of TagDefault, TagEnv, TagFlag, TagCfg)
+// for this configurator only, e.g. WithTagName(TagCfg, "config"). NewFlagProvider registers flags when it's created,
+// so it must get the same names with WithFlagTagName.
+func WithTagName(tag, name string) Option {
+	return func(o *options) {
+		if _, ok := o.tagNames[tag]; ok && name != "" {
+			o.tagNames[tag] = name
+		}
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{
+		logger:        log.Printf,
+		logLevel:      LogTrace,
+		watchInterval: time.Second,
+		maxFields:     defaultMaxFields,
+		maxDepth:      defaultMaxDepth,
+		tagNames:      baseTagNames(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// optionsBinder is implemented by providers of the package: the copy returned by withOptions logs,
+// converts values and reads tags as set by the options of the configurator
+type optionsBinder interface {
+	withOptions(o *options) Provider
+}
+
+// bind returns providers bound to the options (see optionsBinder), other providers are returned as is
+func (o *options) bind(providers []Provider) []Provider {
+	bound := make([]Provider, len(providers))
+	for i, p := range providers {
+		bound[i] = o.bindProvider(p)
+	}
+	return bound
+}
+
+func (o *options) bindProvider(p Provider) Provider {
+	b, ok := p.(optionsBinder)
+	if !ok {
+		return p
+	}
+	// types embedding providers of the package are not unwrapped
+	bound := b.withOptions(o)
+	if t := reflect.TypeOf(p); reflect.TypeOf(bound) != t && (t.Kind() != reflect.Ptr || reflect.TypeOf(bound) != t.Elem()) {
+		return p
+	}
+	return bound
+}
+
+// tags returns names of the tags read from fields, nil options read the names set with SetTagName
+func (o *options) tags() tagNames {
+	if o == nil {
+		return nil
+	}
+	return o.tagNames
+}
+
+// strict reports whether values are converted as set by WithStrictCoercion
+func (o *options) strict() bool {
+	return o != nil && o.strictCoercion
+}
+
+func (o *options) currentPath() string {
+	if o == nil {
+		return ""
+	}
+	p, _ := o.resolving.Load().(string)
+	return p
+}
+
+func (o *options) setCurrentPath(p string) {
+	o.resolving.Store(p)
+}
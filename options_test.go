@@ -0,0 +1,147 @@
+package configuration
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew_Options(t *testing.T) {
+	type config struct {
+		Name  string `def:"name"`
+		Port  int8   `def:"300"`
+		Host  string `default:"localhost"`
+		Empty string
+	}
+
+	var cfg config
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()), WithTagName(TagDefault, "def"))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.EqualError(t, c.InitValues(), "configurator: 2 fields cannot be set:\n"+
+		"\tconfigurator: field [Host] with tags [default:\"localhost\"] cannot be set!\n"+
+		"\tconfigurator: field [Empty] with tags [] cannot be set!")
+	assert.Equal(t, "name", cfg.Name)
+
+	var strictCfg config
+	strict, err := New(&strictCfg,
+		WithProviders(NewDefaultProvider()), WithTagName(TagDefault, "def"), WithStrictCoercion())
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = strict.InitValues()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "3 fields cannot be set")
+	assert.Contains(t, err.Error(), "field [Port]")
+
+	// options don't leak from one configurator to another
+	plainCfg := struct {
+		Host string `default:"localhost"`
+	}{}
+	plain, err := New(&plainCfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, plain.InitValues())
+	assert.Equal(t, "localhost", plainCfg.Host)
+
+	cfg = config{}
+	assert.Error(t, c.InitValues())
+	assert.Equal(t, "name", cfg.Name)
+	assert.NotZero(t, cfg.Port, "best-effort conversion without WithStrictCoercion")
+}
+
+func TestNew_TagNames(t *testing.T) {
+	type config struct {
+		Host  string `cenv:"TAGS_HOST" env:"TAGS_SAME"`
+		Other string `env:"TAGS_SAME"` // conflicts only with the default names
+		Port  int    `cli:"tags_port|8080"`
+	}
+	os.Args = []string{"smth", "-tags_port=9090"}
+
+	var cfg config
+	_, err := New(&cfg, WithProviders(NewEnvProvider()), WithTagName(TagEnv, "cenv"))
+	assert.NoError(t, err, "conflicts are checked by the names of the configurator")
+	_, err = New(&cfg, WithProviders(NewEnvProvider()))
+	assert.EqualError(t, err, "configurator: fields [Host] and [Other] use the same env variable [TAGS_SAME]")
+
+	cli := NewFlagProvider(&cfg, WithFlagTagName(TagFlag, "cli"), WithFlagTagName(TagEnv, "cenv"))
+	_, err = New(&cfg, WithProviders(NewFlagProvider(&cfg, WithFlagTagName(TagEnv, "cenv"))), WithTagName(TagFlag, "cli"), WithTagName(TagEnv, "cenv"))
+	assert.EqualError(t, err, "configurator: flagProvider reads tag [flag] instead of [cli] (see WithFlagTagName)")
+
+	c, err := New(&cfg, WithProviders(cli), WithTagName(TagFlag, "cli"), WithTagName(TagEnv, "cenv"), AllowUnset())
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, 9090, cfg.Port)
+}
+
+func TestNew_LogLevels(t *testing.T) {
+	type config struct {
+		Name     string `default:"name"`
+		Database struct {
+			Host string `env:"CFG_LOG_DB_HOST" default:"localhost"`
+		}
+		Missing string
+	}
+
+	tests := map[string]struct {
+		opts     []Option
+		contains []string
+		absent   []string
+	}{
+		"trace by default": {
+			contains: []string{"defaultProvider: set [name] to field [Name]", "envProvider: ", "cannot be set"},
+		},
+		"errors only": {
+			opts:     []Option{WithLogLevel(LogErrors)},
+			contains: []string{"field [Missing] with tags [] cannot be set"},
+			absent:   []string{"defaultProvider: ", "envProvider: "},
+		},
+		"provider is muted": {
+			opts:     []Option{WithLogLevel(LogOff, "envProvider")},
+			contains: []string{"defaultProvider: "},
+			absent:   []string{"envProvider: "},
+		},
+		"single provider": {
+			opts:     []Option{WithLogLevel(LogOff), WithLogLevel(LogTrace, "defaultProvider")},
+			contains: []string{"defaultProvider: "},
+			absent:   []string{"envProvider: ", "cannot be set"},
+		},
+		"paths": {
+			opts:     []Option{WithLogPaths("Database.*")},
+			contains: []string{"to field [Host]"},
+			absent:   []string{"to field [Name]", "cannot be set"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var logs []string
+			logger := func(format string, v ...interface{}) {
+				logs = append(logs, fmt.Sprintf(format, v...))
+			}
+
+			var cfg config
+			opts := append([]Option{WithProviders(NewEnvProvider(), NewDefaultProvider()), WithLogger(logger)}, test.opts...)
+			c, err := New(&cfg, opts...)
+			if err != nil {
+				t.Fatal("unexpected err: ", err)
+			}
+			assert.Error(t, c.InitValues())
+
+			all := strings.Join(logs, "\n")
+			for _, s := range test.contains {
+				assert.Contains(t, all, s)
+			}
+			for _, s := range test.absent {
+				assert.NotContains(t, all, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package configuration
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// NewOverrideProvider creates an in-memory provider which sets values put with Set.
+// It's meant to be the first provider in the list to override values from the others at runtime
+// (see NewAdminHandler).
+func NewOverrideProvider() overrideProvider {
+	return overrideProvider{
+		mu:     &sync.RWMutex{},
+		values: map[string]string{},
+	}
+}
+
+type overrideProvider struct {
+	mu     *sync.RWMutex
+	values map[string]string // path to the field joined with `.` -> value
+	opts   *options          // of the configurator (see optionsBinder)
+}
+
+func (op overrideProvider) withOptions(o *options) Provider {
+	op.opts = o
+	return op
+}
+
+// Set stores the value for the field located at the path, e.g. Set("Database.Host", "localhost")
+func (op overrideProvider) Set(path, value string) error {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.values[path] = value
+	return nil
+}
+
+// Delete removes the override for the path
+func (op overrideProvider) Delete(path string) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	delete(op.values, path)
+}
+
+// replace removes all overrides and stores the values instead
+func (op overrideProvider) replace(values map[string]string) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+
+	for k := range op.values {
+		delete(op.values, k)
+	}
+	for k, v := range values {
+		op.values[k] = v
+	}
+}
+
+// Values returns a copy of all overrides
+func (op overrideProvider) Values() map[string]string {
+	op.mu.RLock()
+	defer op.mu.RUnlock()
+
+	values := make(map[string]string, len(op.values))
+	for k, v := range op.values {
+		values[k] = v
+	}
+	return values
+}
+
+func (op overrideProvider) Provide(field reflect.StructField, v reflect.Value, path ...string) bool {
+	ok, _ := op.ProvideError(context.Background(), field, v, path...)
+	return ok
+}
+
+// ProvideError returns the error if the stored value cannot be parsed
+func (op overrideProvider) ProvideError(_ context.Context, field reflect.StructField, v reflect.Value, path ...string) (bool, error) {
+	valStr, ok := op.lookup(strings.Join(path, pathSeparator))
+	if !ok {
+		return false, nil
+	}
+
+	if err := setField(field, v, valStr, op.opts.strict()); err != nil {
+		op.opts.errorf("overrideProvider: %v", err)
+		return false, parseError(err)
+	}
+	op.opts.logf("overrideProvider: set [%v] to field [%s]", logValue(field, valStr), strings.Join(path, pathSeparator))
+	return true, nil
+}
+
+func (op overrideProvider) lookup(path string) (string, bool) {
+	op.mu.RLock()
+	defer op.mu.RUnlock()
+
+	if val, ok := op.values[path]; ok {
+		return val, true
+	}
+	for k, val := range op.values {
+		if strings.EqualFold(k, path) {
+			return val, true
+		}
+	}
+	return "", false
+}
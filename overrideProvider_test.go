@@ -0,0 +1,35 @@
+package configuration
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverrideProvider(t *testing.T) {
+	type testStruct struct {
+		Host string
+	}
+	testObj := testStruct{}
+
+	fieldType := reflect.TypeOf(&testObj).Elem().Field(0)
+	fieldVal := reflect.ValueOf(&testObj).Elem().Field(0)
+
+	provider := NewOverrideProvider()
+	if provider.Provide(fieldType, fieldVal, "Database", "Host") {
+		t.Fatal("must be false")
+	}
+
+	assert.NoError(t, provider.Set("database.host", "db.internal"))
+	if !provider.Provide(fieldType, fieldVal, "Database", "Host") {
+		t.Fatal("cannot set value")
+	}
+	assert.Equal(t, "db.internal", testObj.Host)
+	assert.Equal(t, map[string]string{"database.host": "db.internal"}, provider.Values())
+
+	provider.Delete("database.host")
+	if provider.Provide(fieldType, fieldVal, "Database", "Host") {
+		t.Fatal("must be false after Delete")
+	}
+}
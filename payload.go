@@ -0,0 +1,125 @@
+package configuration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Values of `payload` tag. A nested struct tagged with the format is looked up by providers as a single value
+// (e.g. a secret from a secrets manager) which fans out into its fields:
+//
+//	DB struct {
+//		User     string `json:"username"`
+//		Password string `json:"password" payload:"plain"`
+//	} `env:"DB_SECRET" payload:"auto"`
+//
+// `auto` sniffs the value: JSON objects fan out, other values are set to the field tagged `payload:"plain"` as is.
+// `json` and `yaml` require the value to be an object (mapping). Fields which are absent in the payload
+// (or the whole struct if no provider has the payload) are set by providers as usual.
+const (
+	PayloadAuto  = "auto"
+	PayloadJSON  = "json"
+	PayloadYAML  = "yaml"
+	PayloadPlain = "plain"
+)
+
+var stringType = reflect.TypeOf("")
+
+// payloadProvider sets fields of the struct tagged `payload` from the decoded payload,
+// it's inserted into the chain before the provider of the payload
+type payloadProvider struct {
+	values fileProvider
+}
+
+func (pp payloadProvider) Provide(field reflect.StructField, v reflect.Value, path ...string) bool {
+	ok, _ := pp.ProvideError(context.Background(), field, v, path...)
+	return ok
+}
+
+// ProvideError returns the error if the value from the payload cannot be set to the field
+func (pp payloadProvider) ProvideError(ctx context.Context, field reflect.StructField, v reflect.Value, path ...string) (bool, error) {
+	return pp.values.ProvideError(ctx, field, v, path...)
+}
+
+// fillUpPayload fills the nested struct tagged `payload` from the payload found by the first provider which has it
+func (c configurator) fillUpPayload(ctx context.Context, field reflect.StructField, v reflect.Value, currentPath []string) error {
+	target := v.Addr()
+	if field.Type.Kind() == reflect.Ptr {
+		v.Set(reflect.New(field.Type.Elem()))
+		target = v
+	}
+
+	var (
+		payloadField = reflect.StructField{Name: field.Name, Type: stringType, Tag: field.Tag}
+		raw          = reflect.New(stringType).Elem()
+	)
+	for i, provider := range c.providers {
+		ok, err := provide(ctx, provider, payloadField, raw, currentPath)
+		if err != nil {
+			return &FieldError{Path: strings.Join(currentPath, pathSeparator), Tag: string(field.Tag), Provider: providerName(provider), Err: err, name: field.Name}
+		}
+		if !ok {
+			continue
+		}
+
+		data, err := decodePayload(getPayloadTag(field), raw.String(), field.Type)
+		if err != nil {
+			return &FieldError{Path: strings.Join(currentPath, pathSeparator), Tag: string(field.Tag), Provider: providerName(provider), Err: parseError(err), name: field.Name}
+		}
+		c.opts.logf("configurator: payload of [%s] is found by [%s]", strings.Join(currentPath, pathSeparator), providerName(provider))
+
+		for j := len(currentPath) - 1; j >= 0; j-- { // the payload is located at the path of the struct
+			data = map[string]interface{}{currentPath[j]: data}
+		}
+		payload := payloadProvider{values: fileProvider{fileName: providerName(provider), fileData: data, opts: c.opts}}
+
+		withPayload := c
+		withPayload.providers = append(append(c.providers[:i:i], payload), c.providers[i:]...)
+		return withPayload.fillUp(ctx, target.Interface(), currentPath...)
+	}
+	return c.fillUp(ctx, target.Interface(), currentPath...)
+}
+
+// decodePayload decodes the object of the payload or, for plain values, returns the object
+// with the value of the field tagged `payload:"plain"`
+func decodePayload(format, val string, t reflect.Type) (interface{}, error) {
+	var data interface{}
+	switch format {
+	case PayloadAuto:
+		if trimmed := strings.TrimSpace(val); strings.HasPrefix(trimmed, "{") && json.Unmarshal([]byte(trimmed), &data) == nil {
+			return data, nil
+		}
+		return plainPayload(val, t)
+	case PayloadJSON:
+		if err := json.Unmarshal([]byte(val), &data); err != nil {
+			return nil, err
+		}
+	case PayloadYAML:
+		if err := yaml.Unmarshal([]byte(val), &data); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := toStringMap(data); !ok {
+		return nil, fmt.Errorf("%s payload is not an object", format)
+	}
+	return data, nil
+}
+
+func plainPayload(val string, t reflect.Type) (interface{}, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); getPayloadTag(f) == PayloadPlain && !isInternalField(f) {
+			return map[string]interface{}{getFieldKey(f): val}, nil
+		}
+	}
+	return nil, errors.New(`the payload is not a JSON object and no field is tagged payload:"plain"`)
+}
@@ -0,0 +1,122 @@
+package configuration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type payloadCredentials struct {
+	User     string `json:"username" default:"admin"`
+	Password string `json:"password" payload:"plain" secret:"true"`
+	Port     int    `json:"port" env:"PAYLOAD_TEST_PORT"`
+}
+
+func TestConfigurator_Payload(t *testing.T) {
+	type (
+		autoConfig struct {
+			DB *payloadCredentials `env:"PAYLOAD_TEST_SECRET" payload:"auto"`
+		}
+		jsonConfig struct {
+			DB payloadCredentials `env:"PAYLOAD_TEST_SECRET" payload:"json"`
+		}
+		yamlConfig struct {
+			DB payloadCredentials `env:"PAYLOAD_TEST_SECRET" payload:"yaml"`
+		}
+	)
+	configs := map[string]func() interface{}{
+		PayloadAuto: func() interface{} { return &autoConfig{} },
+		PayloadJSON: func() interface{} { return &jsonConfig{} },
+		PayloadYAML: func() interface{} { return &yamlConfig{} },
+	}
+
+	tests := map[string]struct {
+		format   string
+		value    string
+		expected payloadCredentials
+		fail     bool
+	}{
+		"json object":    {format: PayloadAuto, value: `{"username": "app", "password": "s3cret", "port": 5432}`, expected: payloadCredentials{User: "app", Password: "s3cret", Port: 5432}},
+		"partial object": {format: PayloadAuto, value: ` {"password": "s3cret"}`, expected: payloadCredentials{User: "admin", Password: "s3cret", Port: 6543}},
+		"plain":          {format: PayloadAuto, value: `s3cret`, expected: payloadCredentials{User: "admin", Password: "s3cret", Port: 6543}},
+		"broken json":    {format: PayloadAuto, value: `{"password": `, expected: payloadCredentials{User: "admin", Password: `{"password": `, Port: 6543}},
+		"yaml":           {format: PayloadYAML, value: "username: app\npassword: s3cret", expected: payloadCredentials{User: "app", Password: "s3cret", Port: 6543}},
+		"json required":  {format: PayloadJSON, value: `s3cret`, fail: true},
+		"not an object":  {format: PayloadYAML, value: `s3cret`, fail: true},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			removeSecret, err := setEnv("PAYLOAD_TEST_SECRET", test.value)
+			if err != nil {
+				t.Fatal("unexpected err: ", err)
+			}
+			defer removeSecret()
+			removePort, err := setEnv("PAYLOAD_TEST_PORT", "6543")
+			if err != nil {
+				t.Fatal("unexpected err: ", err)
+			}
+			defer removePort()
+
+			cfg := configs[test.format]()
+			c, err := New(cfg, WithProviders(NewEnvProvider(), NewDefaultProvider()))
+			if err != nil {
+				t.Fatal("unexpected err: ", err)
+			}
+
+			err = c.InitValues()
+			if test.fail {
+				assert.True(t, errors.Is(err, ErrParse), err)
+				return
+			}
+			assert.NoError(t, err)
+			switch cfg := cfg.(type) {
+			case *autoConfig:
+				if assert.NotNil(t, cfg.DB) {
+					assert.Equal(t, test.expected, *cfg.DB)
+				}
+			case *jsonConfig:
+				assert.Equal(t, test.expected, cfg.DB)
+			case *yamlConfig:
+				assert.Equal(t, test.expected, cfg.DB)
+			}
+		})
+	}
+}
+
+func TestConfigurator_PayloadFallback(t *testing.T) {
+	var cfg struct {
+		DB payloadCredentials `env:"PAYLOAD_TEST_ABSENT" payload:"auto" json:"db"`
+	}
+	c, err := New(&cfg, WithProviders(NewEnvProvider(), NewFileProvider("./testdata/payload.yml"), NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, payloadCredentials{User: "file_user", Password: "file_password", Port: 5432}, cfg.DB,
+		"fields are set one by one if no provider has the payload")
+	assert.Equal(t, "fileProvider", c.sources["db.password"])
+}
+
+func TestConfigurator_PayloadNoPlainField(t *testing.T) {
+	removeSecret, err := setEnv("PAYLOAD_TEST_SECRET", "s3cret")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	defer removeSecret()
+
+	var cfg struct {
+		DB struct {
+			Password string
+		} `env:"PAYLOAD_TEST_SECRET" payload:"auto"`
+	}
+	c, err := New(&cfg, WithProviders(NewEnvProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+	assert.True(t, errors.Is(err, ErrParse))
+	assert.Contains(t, err.Error(), `no field is tagged payload:"plain"`)
+}
@@ -0,0 +1,34 @@
+//go:build (linux && cgo) || (darwin && cgo) || (freebsd && cgo)
+// +build linux,cgo darwin,cgo freebsd,cgo
+
+package configuration
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// PluginProviderSymbol is the name of the variable which Go plugins must export to be loaded with NewPluginProvider
+const PluginProviderSymbol = "Provider"
+
+// NewPluginProvider loads the provider from the Go plugin (built with `go build -buildmode=plugin`).
+// The plugin must export the variable `Provider` implementing the Provider interface.
+func NewPluginProvider(path string) (Provider, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sym, err := p.Lookup(PluginProviderSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	switch provider := sym.(type) {
+	case *Provider: // var Provider configuration.Provider = ...
+		return *provider, nil
+	case Provider: // var Provider myProvider
+		return provider, nil
+	}
+	return nil, fmt.Errorf("symbol [%s] of type %T in [%s] doesn't implement Provider", PluginProviderSymbol, sym, path)
+}
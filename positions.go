@@ -0,0 +1,203 @@
+package configuration
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// positionError adds the location of the value in the source to the error: `config.yml:12:5: invalid duration [5 minutes]`
+type positionError struct {
+	position string
+	err      error
+}
+
+func (e positionError) Error() string { return fmt.Sprintf("%s: %v", e.position, e.err) }
+func (e positionError) Unwrap() error { return e.err }
+
+// withPosition adds the position of the value (see PositionProvider) to the error of parsing or validation
+func withPosition(p Provider, path []string, err error) error {
+	pp, ok := p.(PositionProvider)
+	if !ok {
+		return err
+	}
+	if position := pp.Position(path...); position != "" {
+		return positionError{position: position, err: err}
+	}
+	return err
+}
+
+// findPosition returns the line and the column (both start from 1) of the value located at the path in the file.
+// Keys are compared like lookupKey does, values inside of lists and flow mappings of YAML are not located.
+func findPosition(fileName string, b []byte, path []string) (line, col int, ok bool) {
+	if len(path) == 0 {
+		return 0, 0, false
+	}
+	if strings.HasSuffix(strings.ToLower(fileName), ".json") {
+		return jsonPosition(b, path)
+	}
+	return yamlPosition(b, path)
+}
+
+// yamlPosition scans lines of the YAML document following the indentation of block mappings
+func yamlPosition(b []byte, path []string) (line, col int, ok bool) {
+	type key struct {
+		indent int
+		name   string
+	}
+	var (
+		stack       []key
+		blockIndent = -1 // indentation of the key with a block scalar (`|`, `>`), its lines are skipped
+	)
+
+	for i, l := range bytes.Split(b, []byte("\n")) {
+		text := strings.TrimRight(string(l), "\r")
+		content := strings.TrimLeft(text, " ")
+		indent := len(text) - len(content)
+		if content == "" || content[0] == '#' || content == "---" || content == "..." {
+			continue
+		}
+		if blockIndent >= 0 && indent > blockIndent {
+			continue
+		}
+		blockIndent = -1
+
+		for strings.HasPrefix(content, "- ") { // items of lists: `- name: value`
+			trimmed := strings.TrimLeft(content[2:], " ")
+			indent += len(content) - len(trimmed)
+			content = trimmed
+		}
+
+		name, rest, found := splitYAMLKey(content)
+		if !found {
+			continue
+		}
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		stack = append(stack, key{indent: indent, name: name})
+
+		value := strings.TrimLeft(rest, " ")
+		if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			blockIndent = indent
+		}
+		if len(stack) != len(path) {
+			continue
+		}
+		matched := true
+		for j := range path {
+			if normalizeKey(stack[j].name) != normalizeKey(path[j]) {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		if value == "" || value[0] == '#' { // nested mapping or list, point to the key
+			return i + 1, indent + 1, true
+		}
+		return i + 1, len(text) - len(value) + 1, true
+	}
+	return 0, 0, false
+}
+
+// splitYAMLKey splits `key: value` to the key and the rest after the colon
+func splitYAMLKey(content string) (name, rest string, ok bool) {
+	if q := content[0]; q == '"' || q == '\'' {
+		end := strings.IndexByte(content[1:], q)
+		if end < 0 || !strings.HasPrefix(content[end+2:], ":") {
+			return "", "", false
+		}
+		name, rest = content[1:end+1], content[end+3:]
+		if unquoted, err := strconv.Unquote(content[:end+2]); err == nil && q == '"' {
+			name = unquoted
+		}
+		return name, rest, rest == "" || rest[0] == ' '
+	}
+
+	if strings.HasSuffix(content, ":") && !strings.Contains(content, ": ") {
+		return content[:len(content)-1], "", true
+	}
+	i := strings.Index(content, ": ")
+	if i <= 0 || content[0] == '{' || content[0] == '[' {
+		return "", "", false
+	}
+	return content[:i], content[i+1:], true
+}
+
+// jsonPosition scans tokens of the JSON document tracking keys of nested objects
+func jsonPosition(b []byte, path []string) (line, col int, ok bool) {
+	type frame struct {
+		object bool
+		key    string
+	}
+	var (
+		stack     []frame
+		expectKey bool
+		found     bool // the key is found, the value starts at the next token
+	)
+
+	line = 1
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		if c == '\n' {
+			line++
+			col = 0
+			continue
+		}
+		col++
+		if found && c != ' ' && c != '\t' && c != '\r' && c != ':' {
+			return line, col, true
+		}
+
+		switch c {
+		case '{':
+			stack = append(stack, frame{object: true})
+			expectKey = true
+		case '[':
+			stack = append(stack, frame{})
+			expectKey = false
+		case '}', ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			expectKey = false
+		case ',':
+			expectKey = len(stack) > 0 && stack[len(stack)-1].object
+		case '"':
+			start := i
+			for i++; i < len(b) && b[i] != '"'; i++ {
+				if b[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(b) {
+				return 0, 0, false
+			}
+			col += i - start // strings of JSON cannot contain new lines
+			if !expectKey {
+				continue
+			}
+			expectKey = false
+			name, err := strconv.Unquote(string(b[start : i+1]))
+			if err != nil {
+				name = string(b[start+1 : i])
+			}
+			stack[len(stack)-1].key = name
+			if len(stack) != len(path) {
+				continue
+			}
+			found = true
+			for j := range path {
+				if !stack[j].object || normalizeKey(stack[j].key) != normalizeKey(path[j]) {
+					found = false
+					break
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
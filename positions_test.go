@@ -0,0 +1,85 @@
+package configuration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindPosition(t *testing.T) {
+	yml := []byte("# comment\nname: test\nlist:\n  - a: 1\n    b: 2\ntext: |\n  port: 1\ninside:\n  'beta': 42\n  port:   8080 # comment\n  nested:\n    deep: true\n")
+	jsn := []byte("{\n  \"name\": \"test\",\n  \"list\": [{\"port\": 1}],\n  \"inside\": {\n    \"beta\" :  42,\n    \"port\": \"8080\"\n  }\n}")
+
+	tests := map[string]struct {
+		fileName string
+		source   []byte
+		path     []string
+		line     int
+		col      int
+		fail     bool
+	}{
+		"yml top level":   {fileName: "c.yml", source: yml, path: []string{"name"}, line: 2, col: 7},
+		"yml quoted key":  {fileName: "c.yml", source: yml, path: []string{"inside", "beta"}, line: 9, col: 11},
+		"yml comment":     {fileName: "c.yml", source: yml, path: []string{"inside", "port"}, line: 10, col: 11},
+		"yml mapping":     {fileName: "c.yml", source: yml, path: []string{"inside", "nested"}, line: 11, col: 3},
+		"yml deep":        {fileName: "c.yml", source: yml, path: []string{"Inside", "Nested", "Deep"}, line: 12, col: 11},
+		"yml block":       {fileName: "c.yml", source: yml, path: []string{"text", "port"}, fail: true},
+		"yml absent":      {fileName: "c.yml", source: yml, path: []string{"port"}, fail: true},
+		"json top level":  {fileName: "c.json", source: jsn, path: []string{"name"}, line: 2, col: 11},
+		"json nested":     {fileName: "c.JSON", source: jsn, path: []string{"inside", "beta"}, line: 5, col: 15},
+		"json string":     {fileName: "c.json", source: jsn, path: []string{"inside", "port"}, line: 6, col: 13},
+		"json in array":   {fileName: "c.json", source: jsn, path: []string{"list", "port"}, fail: true},
+		"json absent":     {fileName: "c.json", source: jsn, path: []string{"port"}, fail: true},
+		"empty path":      {fileName: "c.json", source: jsn, fail: true},
+		"unterminated":    {fileName: "c.json", source: []byte(`{"name": "te`), path: []string{"inside"}, fail: true},
+		"not a structure": {fileName: "c.yml", source: []byte("just text"), path: []string{"name"}, fail: true},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			line, col, ok := findPosition(test.fileName, test.source, test.path)
+			if test.fail {
+				assert.False(t, ok)
+				return
+			}
+			assert.True(t, ok)
+			assert.Equal(t, test.line, line, "line")
+			assert.Equal(t, test.col, col, "column")
+		})
+	}
+}
+
+func TestFileProvider_Position(t *testing.T) {
+	type config struct {
+		Name   string `validate:"min=10"`
+		Server struct {
+			Timeout  int64 `format:"duration"`
+			Port     int   `validate:"min=1024"`
+			MaxConns uint16
+		}
+	}
+
+	var cfg config
+	c, err := New(&cfg, WithProviders(NewFileProvider("./testdata/positions.yml")), WithStrictCoercion())
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+	assert.True(t, errors.Is(err, ErrParse))
+	assert.True(t, errors.Is(err, ErrInvalid))
+	for _, msg := range []string{
+		"./testdata/positions.yml:2:7: min=10: length 7 is less than 10",
+		"./testdata/positions.yml:4:12: invalid duration [5 minutes]",
+		"./testdata/positions.yml:5:9: min=1024: 80 is less than 1024",
+		"./testdata/positions.yml:6:15: cannot convert [70000]",
+	} {
+		assert.Contains(t, err.Error(), msg)
+	}
+
+	fp := NewFileProvider("./testdata/positions.yml")
+	assert.Equal(t, "./testdata/positions.yml:5:9", fp.Position("server", "port"))
+	assert.Empty(t, fp.Position("server", "absent"))
+	assert.Empty(t, NewFileProvider("./testdata/absent.yml").Position("name"))
+}
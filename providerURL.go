@@ -0,0 +1,112 @@
+package configuration
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ProviderFactory creates a provider from the parsed URL, e.g. `env://?prefix=MYAPP`
+type ProviderFactory func(u *url.URL) (Provider, error)
+
+var gProviderFactories = map[string]ProviderFactory{
+	"env":     newEnvProviderFromURL,
+	"file":    newFileProviderFromURL,
+	"default": func(*url.URL) (Provider, error) { return NewDefaultProvider(), nil },
+	"build":   func(*url.URL) (Provider, error) { return NewBuildInfoProvider(), nil },
+}
+
+var gNamingStrategies = map[string]NamingStrategy{
+	"snake":           SnakeCase,
+	"screaming_snake": ScreamingSnakeCase,
+	"kebab":           KebabCase,
+	"camel":           CamelCase,
+}
+
+// RegisterProviderScheme registers the factory of providers for URLs with the given scheme (e.g. `consul`).
+// Built-in schemes (`env`, `file`, `default`, `build`) can be overridden as well.
+func RegisterProviderScheme(scheme string, factory ProviderFactory) {
+	gProviderFactories[strings.ToLower(scheme)] = factory
+}
+
+// NewProviderFromURL creates the provider from the string like `env://?prefix=MYAPP` or `file:///etc/app/config.yaml`
+// using factories registered for the scheme of the URL
+func NewProviderFromURL(rawURL string) (Provider, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	factory, ok := gProviderFactories[strings.ToLower(u.Scheme)]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider scheme [%s] in [%s]", u.Scheme, rawURL)
+	}
+	return factory(u)
+}
+
+// NewProvidersFromURLs creates the chain of providers from URLs (see NewProviderFromURL).
+// Empty strings are skipped, so the result of strings.Split(os.Getenv("CONFIG_PROVIDERS"), ",") can be passed as is.
+func NewProvidersFromURLs(rawURLs ...string) ([]Provider, error) {
+	var providers []Provider
+	for _, rawURL := range rawURLs {
+		rawURL = strings.TrimSpace(rawURL)
+		if rawURL == "" {
+			continue
+		}
+
+		p, err := NewProviderFromURL(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	return providers, nil
+}
+
+// newEnvProviderFromURL supports query params: `prefix` and `naming` (snake, screaming_snake, kebab, camel or derived)
+func newEnvProviderFromURL(u *url.URL) (Provider, error) {
+	ep := NewEnvProvider().WithPrefix(u.Query().Get("prefix"))
+
+	switch name := u.Query().Get("naming"); name {
+	case "":
+	case "derived":
+		ep = ep.WithDerivedNames()
+	default:
+		naming, err := namingFromURL(name)
+		if err != nil {
+			return nil, err
+		}
+		ep = ep.WithNaming(naming)
+	}
+	return ep, nil
+}
+
+// newFileProviderFromURL supports absolute (`file:///etc/app.yml`) and relative (`file://./app.yml`) paths
+// and the `naming` query param
+func newFileProviderFromURL(u *url.URL) (Provider, error) {
+	path := u.Opaque
+	if path == "" {
+		path = u.Host + u.Path
+	}
+	if path == "" {
+		return nil, fmt.Errorf("empty path of the file in [%s]", u)
+	}
+
+	fp := NewFileProvider(path)
+	if name := u.Query().Get("naming"); name != "" {
+		naming, err := namingFromURL(name)
+		if err != nil {
+			return nil, err
+		}
+		fp = fp.WithNaming(naming)
+	}
+	return fp, nil
+}
+
+func namingFromURL(name string) (NamingStrategy, error) {
+	naming, ok := gNamingStrategies[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown naming strategy [%s]", name)
+	}
+	return naming, nil
+}
@@ -0,0 +1,69 @@
+package configuration
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProviderFromURL(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected Provider
+		wantErr  bool
+	}{
+		{url: "env://", expected: NewEnvProvider()},
+		{url: "env://?prefix=MYAPP", expected: NewEnvProvider().WithPrefix("MYAPP")},
+		{url: "env://?naming=derived", expected: NewEnvProvider().WithDerivedNames()},
+		{url: "env://?naming=unknown", wantErr: true},
+		{url: "default://", expected: NewDefaultProvider()},
+		{url: "build://", expected: NewBuildInfoProvider()},
+		{url: "file://./testdata/input.yml", expected: NewFileProvider("./testdata/input.yml")},
+		{url: "file:testdata/input.json", expected: NewFileProvider("testdata/input.json")},
+		{url: "file://", wantErr: true},
+		{url: "consul://localhost:8500/app/", wantErr: true},
+		{url: "://", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.url, func(t *testing.T) {
+			got, err := NewProviderFromURL(test.url)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.IsType(t, test.expected, got)
+			if ep, ok := got.(envProvider); ok {
+				assert.Equal(t, test.expected.(envProvider).prefix, ep.prefix)
+				assert.Equal(t, test.expected.(envProvider).naming == nil, ep.naming == nil)
+			}
+			if fp, ok := got.(fileProvider); ok {
+				assert.Equal(t, test.expected.(fileProvider).fileData, fp.fileData)
+			}
+		})
+	}
+}
+
+func TestRegisterProviderScheme(t *testing.T) {
+	defer delete(gProviderFactories, "mem")
+
+	RegisterProviderScheme("mem", func(u *url.URL) (Provider, error) {
+		if u.Host == "" {
+			return nil, errors.New("empty name")
+		}
+		return NewOverrideProvider(), nil
+	})
+
+	providers, err := NewProvidersFromURLs("mem://test", "", " env://?prefix=APP")
+	assert.NoError(t, err)
+	if assert.Len(t, providers, 2) {
+		assert.IsType(t, overrideProvider{}, providers[0])
+		assert.IsType(t, envProvider{}, providers[1])
+	}
+
+	_, err = NewProvidersFromURLs("env://", "mem://")
+	assert.EqualError(t, err, "empty name")
+}
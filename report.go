@@ -0,0 +1,106 @@
+package configuration
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Constraints of violations which aren't rules of `validate` tag (see Violation)
+const (
+	ConstraintSet       = "set"       // the value isn't set by any provider
+	ConstraintRequired  = "required"  // the field is required but isn't set
+	ConstraintParse     = "parse"     // the value cannot be converted to the type of the field
+	ConstraintAvailable = "available" // the provider is unavailable
+	ConstraintGroup     = "group"     // the group is incomplete
+	ConstraintKnownKey  = "known_key" // the source has the unknown key
+)
+
+// ValidationReport is the machine-readable report of all violations of the last InitValues (or Reload) call,
+// e.g. for CI pipelines and admission webhooks (see ValidationReport of the configurator)
+type ValidationReport struct {
+	Valid      bool        `json:"valid"` // there are no violations with SeverityError
+	Violations []Violation `json:"violations"`
+}
+
+// Violation describes the field which cannot be set or doesn't pass the validation
+type Violation struct {
+	Severity   string      `json:"severity"`           // SeverityError or SeverityWarn (the field keeps its value)
+	Path       string      `json:"path,omitempty"`     // empty for unknown keys, the key is in the message
+	Constraint string      `json:"constraint"`         // the rule of `validate` tag (e.g. `min=1`) or one of ConstraintSet and others
+	Value      interface{} `json:"value,omitempty"`    // the received value of the rule, masked for secrets (see MaskFull)
+	Provider   string      `json:"provider,omitempty"` // the provider which set the value
+	Position   string      `json:"position,omitempty"` // e.g. `config.yml:12:5`
+	Message    string      `json:"message"`
+}
+
+// ValidationReport returns violations of the last InitValues call: errors returned by it (all of them
+// unless StopOnFirstError is given) and invalid values of fields tagged `severity:"warn"` which are only logged.
+func (c configurator) ValidationReport() ValidationReport {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	report := ValidationReport{Valid: true, Violations: []Violation{}}
+	for _, v := range violations(c.stats.lastErr) {
+		report.Valid = false
+		report.Violations = append(report.Violations, v)
+	}
+	report.Violations = append(report.Violations, c.stats.warnings...)
+	return report
+}
+
+// JSON returns the indented JSON of the report
+func (r ValidationReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// violations converts the error of InitValues to violations with SeverityError
+func violations(err error) []Violation {
+	if err == nil {
+		return nil
+	}
+	if multi, ok := err.(interface{ Unwrap() []error }); ok { // loadErrors, *UnsetReport
+		var result []Violation
+		for _, e := range multi.Unwrap() {
+			result = append(result, violations(e)...)
+		}
+		return result
+	}
+
+	var fe *FieldError
+	if !errors.As(err, &fe) {
+		v := Violation{Severity: SeverityError, Message: err.Error()}
+		if errors.Is(err, ErrUnknownKey) {
+			v.Constraint = ConstraintKnownKey
+		}
+		return []Violation{v}
+	}
+	return []Violation{newViolation(SeverityError, fe)}
+}
+
+func newViolation(severity string, fe *FieldError) Violation {
+	v := Violation{Severity: severity, Path: fe.Path, Provider: fe.Provider, Message: fe.Err.Error()}
+
+	var (
+		re ruleError
+		pe positionError
+	)
+	if errors.As(fe.Err, &pe) {
+		v.Position = pe.position
+		v.Message = pe.err.Error()
+	}
+	switch {
+	case errors.As(fe.Err, &re):
+		v.Constraint, v.Value, v.Message = re.rule, re.value, re.err.Error()
+	case fe.Err == ErrRequired:
+		v.Constraint = ConstraintRequired
+	case errors.Is(fe.Err, ErrNotSet):
+		v.Constraint = ConstraintSet
+	case errors.Is(fe.Err, ErrParse):
+		v.Constraint = ConstraintParse
+	case errors.Is(fe.Err, ErrProviderUnavailable):
+		v.Constraint = ConstraintAvailable
+	case errors.Is(fe.Err, ErrIncompleteGroup):
+		v.Constraint = ConstraintGroup
+	}
+	return v
+}
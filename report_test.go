@@ -0,0 +1,100 @@
+package configuration
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type reportConfig struct {
+	Port     int    `json:"port" validate:"min=1"`
+	LogLevel string `json:"log_level" validate:"oneof=debug|info|warn"`
+	MaxBody  int    `json:"max_body" format:"bytes"`
+	Token    string `json:"token" validate:"min=8" secret:"true" default:"short"`
+	Name     string `json:"name"`
+	TLS      struct {
+		MinVersion string `json:"min_version" severity:"warn" default:"1.2" validate:"oneof=1.2|1.3"`
+		CAFile     string `json:"ca_file" severity:"warn"`
+	} `json:"tls"`
+}
+
+func TestValidationReport(t *testing.T) {
+	var cfg reportConfig
+	c, err := New(&cfg, WithProviders(NewFileProvider("./testdata/report.yml"), NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Error(t, c.InitValues())
+
+	report := c.ValidationReport()
+	assert.False(t, report.Valid)
+	assert.Equal(t, []Violation{
+		{
+			Severity: SeverityError, Path: "port", Constraint: "min=1", Value: 0, Provider: "fileProvider",
+			Position: "./testdata/report.yml:1:7", Message: "0 is less than 1",
+		},
+		{
+			Severity: SeverityError, Path: "log_level", Constraint: "oneof=debug|info|warn", Value: "verbose", Provider: "fileProvider",
+			Position: "./testdata/report.yml:2:12", Message: "verbose is not one of [debug, info, warn]",
+		},
+		{
+			Severity: SeverityError, Path: "max_body", Constraint: ConstraintParse, Provider: "fileProvider",
+			Position: "./testdata/report.yml:3:11", Message: "invalid bytes [lots], expects e.g. 512, 64KB, 10MiB",
+		},
+		{
+			Severity: SeverityError, Path: "token", Constraint: "min=8", Value: "******", Provider: "defaultProvider",
+			Message: "length 5 is less than 8",
+		},
+		{Severity: SeverityError, Path: "name", Constraint: ConstraintSet, Message: ErrNotSet.Error()},
+		{
+			Severity: SeverityWarn, Path: "tls.min_version", Constraint: "oneof=1.2|1.3", Value: "1.5", Provider: "fileProvider",
+			Position: "./testdata/report.yml:5:16", Message: "1.5 is not one of [1.2, 1.3]",
+		},
+	}, report.Violations)
+
+	b, err := report.JSON()
+	assert.NoError(t, err)
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal(b, &decoded))
+	assert.Equal(t, false, decoded["valid"])
+	assert.Len(t, decoded["violations"], 6)
+}
+
+func TestValidationReport_Valid(t *testing.T) {
+	cfg := struct {
+		Port int `default:"8080" validate:"min=1"`
+	}{}
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	report := c.ValidationReport()
+	assert.True(t, report.Valid)
+	assert.Empty(t, report.Violations)
+
+	b, err := report.JSON()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"valid": true, "violations": []}`, string(b))
+}
+
+func TestValidationReport_UnknownKeys(t *testing.T) {
+	cfg := struct {
+		Name string `json:"name" default:"app"`
+	}{}
+	c, err := New(&cfg, WithProviders(NewFileProvider("./testdata/strict.yml").WithStrictKeys(), NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Error(t, c.InitValues())
+
+	report := c.ValidationReport()
+	assert.False(t, report.Valid)
+	assert.NotEmpty(t, report.Violations)
+	for _, v := range report.Violations {
+		assert.Equal(t, ConstraintKnownKey, v.Constraint)
+		assert.Contains(t, v.Message, "unknown key")
+	}
+}
@@ -0,0 +1,171 @@
+package configuration
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit is the reusable section of the configuration for rate limits, validated by InitValues:
+//
+//	type Config struct {
+//		API configuration.RateLimit `json:"api_limit"` // api_limit.rps, api_limit.burst
+//	}
+//
+//	limiter := cfg.API.Limiter()
+//	if !limiter.Allow() { /* 429 */ }
+type RateLimit struct {
+	RPS   float64 `default:"10" validate:"min=0"` // requests per second, 0 disables the limit
+	Burst int     `default:"1" validate:"min=1"`
+}
+
+// Limiter returns the token bucket which is filled with RPS tokens per second up to Burst tokens
+func (r RateLimit) Limiter() *RateLimiter {
+	return &RateLimiter{rps: r.RPS, burst: float64(r.Burst), tokens: float64(r.Burst), updated: time.Now()}
+}
+
+// RateLimiter is the token bucket of RateLimit, safe for concurrent use
+type RateLimiter struct {
+	mu      sync.Mutex
+	rps     float64
+	burst   float64
+	tokens  float64
+	updated time.Time
+}
+
+// Allow takes the token if it's available
+func (l *RateLimiter) Allow() bool {
+	return l.reserve(time.Now()) == 0
+}
+
+// Wait takes the token waiting for it if necessary, returns ctx.Err() if the context is done first
+// (the token is taken anyway, like by a request which is cancelled)
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	delay := l.take(time.Now())
+	l.mu.Unlock()
+	if delay == 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve takes the token only if it's available now, returns the delay until it's available otherwise
+func (l *RateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rps <= 0 {
+		return 0
+	}
+	l.refill(now)
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rps * float64(time.Second))
+}
+
+// take takes the token going into debt if it isn't available, returns the time to wait for it
+func (l *RateLimiter) take(now time.Time) time.Duration {
+	if l.rps <= 0 {
+		return 0
+	}
+	l.refill(now)
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rps * float64(time.Second))
+}
+
+func (l *RateLimiter) refill(now time.Time) {
+	if elapsed := now.Sub(l.updated); elapsed > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+elapsed.Seconds()*l.rps)
+		l.updated = now
+	}
+}
+
+// Retry is the reusable section of the configuration for retry policies with exponential backoff,
+// validated by InitValues:
+//
+//	err := cfg.Retry.Do(ctx, func() error { return client.Call(ctx) })
+type Retry struct {
+	MaxAttempts    int           `default:"3" validate:"min=1"` // including the first attempt
+	InitialBackoff time.Duration `default:"100ms" validate:"min=0s"`
+	MaxBackoff     time.Duration `default:"10s" validate:"min=0s"`
+	Multiplier     float64       `default:"2" validate:"min=1"`
+	Jitter         float64       `default:"0.2" validate:"min=0,max=1"` // random ±20% of every delay
+}
+
+// Backoff returns the sequence of delays between attempts of the policy
+func (r Retry) Backoff() *Backoff {
+	return &Backoff{policy: r}
+}
+
+// Do calls fn until it succeeds, attempts are exhausted or the context is done, returns the last error of fn
+// (or ctx.Err() if the context is done before the first attempt)
+func (r Retry) Do(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	backoff := r.Backoff()
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		delay, ok := backoff.Next()
+		if !ok {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// Backoff is the sequence of delays of Retry, it isn't safe for concurrent use
+type Backoff struct {
+	policy   Retry
+	attempts int // failed attempts
+}
+
+// Next returns the delay before the next attempt or false if attempts are exhausted
+func (b *Backoff) Next() (time.Duration, bool) {
+	b.attempts++
+	if b.attempts >= b.policy.MaxAttempts {
+		return 0, false
+	}
+
+	delay := float64(b.policy.InitialBackoff) * math.Pow(b.policy.Multiplier, float64(b.attempts-1))
+	if max := float64(b.policy.MaxBackoff); max > 0 && delay > max {
+		delay = max
+	}
+	if b.policy.Jitter > 0 {
+		gRandMu.Lock()
+		delay *= 1 + b.policy.Jitter*(2*gRand.Float64()-1)
+		gRandMu.Unlock()
+	}
+	return time.Duration(delay), true
+}
+
+// Reset starts the sequence from the first delay
+func (b *Backoff) Reset() {
+	b.attempts = 0
+}
@@ -0,0 +1,99 @@
+package configuration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResilienceSections(t *testing.T) {
+	var cfg struct {
+		Limit RateLimit
+		Retry Retry
+	}
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, RateLimit{RPS: 10, Burst: 1}, cfg.Limit)
+	assert.Equal(t, Retry{MaxAttempts: 3, InitialBackoff: 100 * time.Millisecond, MaxBackoff: 10 * time.Second, Multiplier: 2, Jitter: 0.2}, cfg.Retry)
+
+	env := map[string]string{"APP_LIMIT_BURST": "0", "APP_RETRY_JITTER": "1.5"}
+	c, err = New(&cfg, WithProviders(NewEnvProvider().WithEnv(env).WithPrefix("APP").WithDerivedNames(), NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+	assert.True(t, errors.Is(err, ErrInvalid), "sections are validated at load time")
+	assert.Contains(t, err.Error(), "min=1: 0 is less than 1")
+	assert.Contains(t, err.Error(), "max=1: 1.5 is greater than 1")
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := RateLimit{RPS: 2, Burst: 2}.Limiter()
+	start := limiter.updated
+	assert.Equal(t, time.Duration(0), limiter.reserve(start))
+	assert.Equal(t, time.Duration(0), limiter.reserve(start), "the burst is available right away")
+	assert.Equal(t, 500*time.Millisecond, limiter.reserve(start))
+	assert.Equal(t, time.Duration(0), limiter.reserve(start.Add(500*time.Millisecond)), "the token is added every 500ms")
+	assert.Equal(t, time.Duration(0), limiter.take(start.Add(time.Second)))
+	assert.Equal(t, 500*time.Millisecond, limiter.take(start.Add(time.Second)), "Wait goes into debt")
+	assert.Equal(t, time.Second, limiter.take(start.Add(time.Second)))
+
+	unlimited := RateLimit{RPS: 0, Burst: 1}.Limiter()
+	for i := 0; i < 100; i++ {
+		assert.True(t, unlimited.Allow())
+	}
+
+	slow := RateLimit{RPS: 0.001, Burst: 1}.Limiter()
+	assert.NoError(t, slow.Wait(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, slow.Wait(ctx))
+}
+
+func TestRetry(t *testing.T) {
+	backoff := Retry{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: 3 * time.Second, Multiplier: 2}.Backoff()
+	for _, expected := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		delay, ok := backoff.Next()
+		assert.True(t, ok)
+		assert.Equal(t, expected, delay)
+	}
+	_, ok := backoff.Next()
+	assert.False(t, ok, "attempts are exhausted")
+	backoff.Reset()
+	delay, _ := backoff.Next()
+	assert.Equal(t, time.Second, delay)
+
+	jittered := Retry{MaxAttempts: 2, InitialBackoff: time.Second, Multiplier: 2, Jitter: 0.5}.Backoff()
+	delay, _ = jittered.Next()
+	assert.True(t, delay >= 500*time.Millisecond && delay <= 1500*time.Millisecond, delay)
+
+	var calls int
+	policy := Retry{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 1}
+	err := policy.Do(context.Background(), func() error {
+		calls++
+		if calls < 2 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	err = policy.Do(context.Background(), func() error {
+		calls++
+		return errors.New("permanent")
+	})
+	assert.EqualError(t, err, "permanent")
+	assert.Equal(t, 3, calls)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, policy.Do(ctx, func() error { return nil }))
+}
@@ -0,0 +1,47 @@
+package configuration
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Runtimes detected by DetectRuntime, `default_<runtime>` tags (e.g. `default_dev:"console"`) take priority
+// over `default` tag in the detected runtime (see NewDefaultProvider)
+const (
+	RuntimeKubernetes = "k8s"
+	RuntimeDocker     = "docker"
+	RuntimeLambda     = "lambda"
+	RuntimeDev        = "dev" // none of the others, e.g. the laptop of the developer
+)
+
+var (
+	gRuntimeOnce sync.Once
+	gRuntime     string
+)
+
+// DetectRuntime returns the runtime the process runs in: RuntimeLambda, RuntimeKubernetes, RuntimeDocker
+// or RuntimeDev. It's detected once by variables set by the platforms and by files of containers.
+func DetectRuntime() string {
+	gRuntimeOnce.Do(func() {
+		gRuntime = detectRuntime(os.LookupEnv, ioutil.ReadFile)
+	})
+	return gRuntime
+}
+
+func detectRuntime(lookupEnv func(key string) (string, bool), readFile func(name string) ([]byte, error)) string {
+	if _, ok := lookupEnv("AWS_LAMBDA_FUNCTION_NAME"); ok {
+		return RuntimeLambda
+	}
+	if _, ok := lookupEnv("KUBERNETES_SERVICE_HOST"); ok {
+		return RuntimeKubernetes
+	}
+	if _, err := readFile("/.dockerenv"); err == nil {
+		return RuntimeDocker
+	}
+	if b, err := readFile("/proc/1/cgroup"); err == nil && strings.Contains(string(b), "docker") {
+		return RuntimeDocker
+	}
+	return RuntimeDev
+}
@@ -0,0 +1,70 @@
+package configuration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectRuntime(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		files    map[string]string
+		expected string
+	}{
+		{name: "lambda", env: map[string]string{"AWS_LAMBDA_FUNCTION_NAME": "fn", "KUBERNETES_SERVICE_HOST": "x"}, expected: RuntimeLambda},
+		{name: "kubernetes", env: map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1"}, files: map[string]string{"/.dockerenv": ""}, expected: RuntimeKubernetes},
+		{name: "docker", files: map[string]string{"/.dockerenv": ""}, expected: RuntimeDocker},
+		{name: "docker cgroup", files: map[string]string{"/proc/1/cgroup": "0::/docker/3f2a"}, expected: RuntimeDocker},
+		{name: "dev", files: map[string]string{"/proc/1/cgroup": "0::/init.scope"}, expected: RuntimeDev},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lookupEnv := func(key string) (string, bool) {
+				val, ok := test.env[key]
+				return val, ok
+			}
+			readFile := func(name string) ([]byte, error) {
+				content, ok := test.files[name]
+				if !ok {
+					return nil, errors.New("not found")
+				}
+				return []byte(content), nil
+			}
+			assert.Equal(t, test.expected, detectRuntime(lookupEnv, readFile))
+		})
+	}
+}
+
+func TestDefaultProvider_Runtime(t *testing.T) {
+	type config struct {
+		LogFormat string `default:"json" default_dev:"console"`
+		Workers   int    `default:"8" default_lambda:"1"`
+		Region    string `cfg:"default=us-east-1,default_k8s=eu-west-1"`
+	}
+
+	tests := []struct {
+		runtime  string
+		expected config
+	}{
+		{runtime: RuntimeDev, expected: config{LogFormat: "console", Workers: 8, Region: "us-east-1"}},
+		{runtime: RuntimeLambda, expected: config{LogFormat: "json", Workers: 1, Region: "us-east-1"}},
+		{runtime: RuntimeKubernetes, expected: config{LogFormat: "json", Workers: 8, Region: "eu-west-1"}},
+		{runtime: "staging", expected: config{LogFormat: "json", Workers: 8, Region: "us-east-1"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.runtime, func(t *testing.T) {
+			var cfg config
+			c, err := New(&cfg, WithProviders(NewDefaultProvider().WithRuntime(test.runtime)))
+			if err != nil {
+				t.Fatal("unexpected err: ", err)
+			}
+			assert.NoError(t, c.InitValues())
+			assert.Equal(t, test.expected, cfg)
+		})
+	}
+}
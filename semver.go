@@ -0,0 +1,180 @@
+package configuration
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version (https://semver.org) which can be used as a type of configuration field:
+//
+//	MinClientVersion Version `default:"v1.2.0"`
+//
+// The value is validated while it's set so malformed versions are reported at startup.
+type Version struct {
+	Major, Minor, Patch uint64
+	PreRelease          string // e.g. "rc.1" from "1.2.3-rc.1"
+	Build               string // e.g. "exp.sha.5114f85" from "1.2.3+exp.sha.5114f85"
+}
+
+// ParseVersion parses semantic version string like "1.2.3", "v1.2.3-rc.1" or "1.2.3+build.5"
+func ParseVersion(s string) (Version, error) {
+	var (
+		v   Version
+		str = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	)
+
+	if i := strings.Index(str, "+"); i >= 0 {
+		v.Build = str[i+1:]
+		str = str[:i]
+		if !validIdentifiers(v.Build, false) {
+			return Version{}, fmt.Errorf("invalid build metadata in version %q", s)
+		}
+	}
+	if i := strings.Index(str, "-"); i >= 0 {
+		v.PreRelease = str[i+1:]
+		str = str[:i]
+		if !validIdentifiers(v.PreRelease, true) {
+			return Version{}, fmt.Errorf("invalid pre-release in version %q", s)
+		}
+	}
+
+	parts := strings.Split(str, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("invalid version %q: expected MAJOR.MINOR.PATCH", s)
+	}
+	for i, dst := range []*uint64{&v.Major, &v.Minor, &v.Patch} {
+		if !isNumeric(parts[i]) || (len(parts[i]) > 1 && parts[i][0] == '0') {
+			return Version{}, fmt.Errorf("invalid version %q: wrong number %q", s, parts[i])
+		}
+		n, err := strconv.ParseUint(parts[i], 10, 64)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid version %q: %v", s, err)
+		}
+		*dst = n
+	}
+	return v, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (v *Version) UnmarshalText(text []byte) error {
+	parsed, err := ParseVersion(string(text))
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// String returns the version in MAJOR.MINOR.PATCH[-PRERELEASE][+BUILD] format
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare returns -1, 0 or +1 depending on whether v is lower, equal or greater than other.
+// Build metadata is ignored as the specification requires.
+func (v Version) Compare(other Version) int {
+	if c := compareUint(v.Major, other.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, other.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, other.Patch); c != 0 {
+		return c
+	}
+	return comparePreRelease(v.PreRelease, other.PreRelease)
+}
+
+// LessThan reports whether v is lower than other
+func (v Version) LessThan(other Version) bool {
+	return v.Compare(other) < 0
+}
+
+// AtLeast reports whether v is greater than or equal to other
+func (v Version) AtLeast(other Version) bool {
+	return v.Compare(other) >= 0
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func comparePreRelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "": // a version without pre-release has higher precedence
+		return 1
+	case b == "":
+		return -1
+	}
+
+	var (
+		aIDs = strings.Split(a, ".")
+		bIDs = strings.Split(b, ".")
+	)
+	for i := 0; i < len(aIDs) && i < len(bIDs); i++ {
+		aNum, bNum := isNumeric(aIDs[i]), isNumeric(bIDs[i])
+		switch {
+		case aNum && bNum:
+			an, _ := strconv.ParseUint(aIDs[i], 10, 64)
+			bn, _ := strconv.ParseUint(bIDs[i], 10, 64)
+			if c := compareUint(an, bn); c != 0 {
+				return c
+			}
+		case aNum: // numeric identifiers have lower precedence than alphanumeric
+			return -1
+		case bNum:
+			return 1
+		default:
+			if c := strings.Compare(aIDs[i], bIDs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareUint(uint64(len(aIDs)), uint64(len(bIDs)))
+}
+
+func validIdentifiers(s string, noLeadingZeros bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		for _, r := range id {
+			if !(r == '-' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')) {
+				return false
+			}
+		}
+		if noLeadingZeros && isNumeric(id) && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
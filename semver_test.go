@@ -0,0 +1,83 @@
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected Version
+		fail     bool
+	}{
+		"plain":            {input: "1.2.3", expected: Version{Major: 1, Minor: 2, Patch: 3}},
+		"v prefix":         {input: "v0.10.0", expected: Version{Minor: 10}},
+		"pre-release":      {input: "1.0.0-rc.1", expected: Version{Major: 1, PreRelease: "rc.1"}},
+		"build":            {input: "1.0.0+exp.sha.5114f85", expected: Version{Major: 1, Build: "exp.sha.5114f85"}},
+		"pre & build":      {input: "1.0.0-beta+001", expected: Version{Major: 1, PreRelease: "beta", Build: "001"}},
+		"missing patch":    {input: "1.2", fail: true},
+		"leading zero":     {input: "01.2.3", fail: true},
+		"not a number":     {input: "1.x.3", fail: true},
+		"empty pre":        {input: "1.2.3-", fail: true},
+		"pre leading zero": {input: "1.2.3-01", fail: true},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseVersion(test.input)
+			if test.fail {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, got)
+		})
+	}
+}
+
+func TestVersion_Compare(t *testing.T) {
+	// ordered by precedence according to the specification
+	ordered := []string{
+		"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta",
+		"1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0",
+	}
+
+	for i := 0; i < len(ordered)-1; i++ {
+		lower, _ := ParseVersion(ordered[i])
+		higher, _ := ParseVersion(ordered[i+1])
+
+		assert.True(t, lower.LessThan(higher), "%s < %s", lower, higher)
+		assert.True(t, higher.AtLeast(lower), "%s >= %s", higher, lower)
+		assert.Equal(t, 1, higher.Compare(lower))
+	}
+
+	v1, _ := ParseVersion("1.0.0+build.1")
+	v2, _ := ParseVersion("1.0.0+build.2")
+	assert.Equal(t, 0, v1.Compare(v2))
+	assert.Equal(t, "1.0.0+build.1", v1.String())
+}
+
+func TestConfigurator_Version(t *testing.T) {
+	cfg := struct {
+		MinVersion Version `default:"v1.4.0-rc.1"`
+	}{}
+
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, Version{Major: 1, Minor: 4, PreRelease: "rc.1"}, cfg.MinVersion)
+
+	invalid := struct {
+		MinVersion Version `default:"1.4"`
+	}{}
+	c, err = New(&invalid, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Error(t, c.InitValues())
+}
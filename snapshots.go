@@ -0,0 +1,188 @@
+package configuration
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+type snapshots struct {
+	limit       int // 0 means snapshots are disabled
+	items       []snapshot
+	healthCheck func() error
+}
+
+type snapshot struct {
+	config  reflect.Value // deep copy of the configuration object
+	sources map[string]string
+}
+
+// KeepSnapshots makes the configurator keep deep copies of the last `n` successfully initialized
+// configurations, Reload rolls back to the latest of them on failure
+func (c configurator) KeepSnapshots(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.history.limit = n
+	if len(c.history.items) > n {
+		c.history.items = c.history.items[len(c.history.items)-n:]
+	}
+}
+
+// SetHealthCheck registers a function which is called by Reload after new values are applied,
+// an error makes Reload roll back to the previous snapshot.
+// The function is called while the configurator is locked, so it must not call methods of the configurator.
+func (c configurator) SetHealthCheck(fn func() error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.history.healthCheck = fn
+}
+
+// Reload re-runs providers like InitValues filling up a copy of the configuration object which is applied
+// only if it succeeds. If it fails or the health check returns an error, the previous values are kept
+// (or rolled back to the latest snapshot, see KeepSnapshots) and the error is returned.
+func (c configurator) Reload() error {
+	return c.ReloadContext(context.Background())
+}
+
+// ReloadContext is the same as Reload but passes the context to providers (see InitValuesContext)
+func (c configurator) ReloadContext(ctx context.Context) error {
+	old, err := c.reload(ctx)
+	if err == nil {
+		c.notify(old)
+	}
+	return err
+}
+
+// reload fills up a copy of the configuration object and applies it like reloadWatched,
+// returns the copy of the previous configuration for OnChange callbacks
+func (c configurator) reload(ctx context.Context) (reflect.Value, error) {
+	defer c.lock()()
+	defer c.beginChange()()
+
+	old := c.copyForCallbacks()
+	prev := c.snapshot()
+	fresh := c
+	fresh.config = deepCopy(prev.config).Addr().Interface()
+	fresh.sources = map[string]string{}
+	err := fresh.initValues(ctx)
+	if err == nil {
+		c.restore(snapshot{config: reflect.ValueOf(fresh.config).Elem(), sources: fresh.sources})
+		if c.history.healthCheck != nil {
+			if hcErr := c.history.healthCheck(); hcErr != nil {
+				c.restore(prev)
+				err = fmt.Errorf("configurator: health check failed: %v", hcErr)
+			}
+		}
+	}
+	if err == nil {
+		c.history.push(c.snapshot())
+		return old, nil
+	}
+
+	if c.history.limit > 0 && len(c.history.items) > 0 {
+		c.restore(c.history.items[len(c.history.items)-1])
+		c.opts.errorf("configurator: reload failed, rolled back to the previous snapshot: %v", err)
+		return old, fmt.Errorf("%v (rolled back to the previous configuration)", err)
+	}
+	return old, err
+}
+
+// Snapshots returns copies of the kept snapshots from the oldest to the latest one
+func (c configurator) Snapshots() []interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	result := make([]interface{}, 0, len(c.history.items))
+	for _, s := range c.history.items {
+		result = append(result, deepCopy(s.config).Addr().Interface())
+	}
+	return result
+}
+
+func (c configurator) snapshot() snapshot {
+	return snapshot{
+		config:  deepCopy(reflect.ValueOf(c.config).Elem()),
+		sources: c.copySources(),
+	}
+}
+
+func (c configurator) restore(s snapshot) {
+	reflect.ValueOf(c.config).Elem().Set(deepCopy(s.config))
+
+	for k := range c.sources {
+		delete(c.sources, k)
+	}
+	for k, v := range s.sources {
+		c.sources[k] = v
+	}
+}
+
+func (s *snapshots) push(item snapshot) {
+	if s.limit <= 0 {
+		return
+	}
+
+	s.items = append(s.items, item)
+	if len(s.items) > s.limit {
+		s.items = s.items[len(s.items)-s.limit:]
+	}
+}
+
+// deepCopy returns an addressable deep copy of the value: pointers, slices and maps are not shared
+func deepCopy(v reflect.Value) reflect.Value {
+	dst := reflect.New(v.Type()).Elem()
+	copyValue(dst, v)
+	return dst
+}
+
+func copyValue(dst, src reflect.Value) {
+	switch src.Kind() {
+	case reflect.Ptr:
+		if src.IsNil() {
+			return
+		}
+		ptr := reflect.New(src.Type().Elem())
+		copyValue(ptr.Elem(), src.Elem())
+		dst.Set(ptr)
+
+	case reflect.Struct:
+		dst.Set(src) // copies unexported fields as is
+		for i := 0; i < src.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				copyValue(dst.Field(i), src.Field(i))
+			}
+		}
+
+	case reflect.Slice:
+		if src.IsNil() {
+			return
+		}
+		slice := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			copyValue(slice.Index(i), src.Index(i))
+		}
+		dst.Set(slice)
+
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			copyValue(dst.Index(i), src.Index(i))
+		}
+
+	case reflect.Map:
+		if src.IsNil() {
+			return
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		for _, key := range src.MapKeys() {
+			val := reflect.New(src.Type().Elem()).Elem()
+			copyValue(val, src.MapIndex(key))
+			m.SetMapIndex(key, val)
+		}
+		dst.Set(m)
+
+	default:
+		dst.Set(src)
+	}
+}
@@ -0,0 +1,125 @@
+package configuration
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigurator_Reload(t *testing.T) {
+	type config struct {
+		Port   int `default:"80"`
+		Limits *struct {
+			Max []int `default:"1;2"`
+		}
+	}
+	var (
+		cfg       config
+		overrides = NewOverrideProvider()
+		healthErr error
+	)
+
+	c, err := New(&cfg, WithProviders(overrides, NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	c.KeepSnapshots(2)
+	c.SetHealthCheck(func() error { return healthErr })
+	assert.NoError(t, c.InitValues())
+
+	// successful reload
+	_ = overrides.Set("Port", "8080")
+	assert.NoError(t, c.Reload())
+	assert.Equal(t, 8080, cfg.Port)
+
+	// health check fails: rollback to 8080
+	_ = overrides.Set("Port", "9090")
+	_ = overrides.Set("Limits.Max", "3")
+	healthErr = errors.New("port is not reachable")
+	assert.Error(t, c.Reload())
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, []int{1, 2}, cfg.Limits.Max)
+	assert.Equal(t, "overrideProvider", c.Sources()["Port"])
+
+	snapshots := c.Snapshots()
+	assert.Len(t, snapshots, 2)
+	assert.Equal(t, 80, snapshots[0].(*config).Port)
+	assert.Equal(t, 8080, snapshots[1].(*config).Port)
+
+	// snapshots are not shared with the configuration object
+	cfg.Limits.Max[0] = 100
+	assert.Equal(t, []int{1, 2}, c.Snapshots()[1].(*config).Limits.Max)
+}
+
+func TestConfigurator_ReloadWithoutSnapshots(t *testing.T) {
+	cfg := struct {
+		Port int `default:"80"`
+	}{}
+
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	c.SetHealthCheck(func() error { return errors.New("unhealthy") })
+	assert.EqualError(t, c.Reload(), "configurator: health check failed: unhealthy")
+	assert.Empty(t, c.Snapshots())
+}
+
+func TestConfigurator_ReloadKeepsValuesOnError(t *testing.T) {
+	cfg := struct {
+		Host string
+		Port int `validate:"min=1"`
+	}{}
+	overrides := NewOverrideProvider()
+
+	c, err := New(&cfg, WithProviders(overrides))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	_ = overrides.Set("Host", "localhost")
+	_ = overrides.Set("Port", "80")
+	assert.NoError(t, c.InitValues())
+
+	// no snapshots: the failed reload doesn't leave the object partly overwritten
+	_ = overrides.Set("Host", "example.com")
+	_ = overrides.Set("Port", "0")
+	assert.Error(t, c.Reload())
+	assert.Equal(t, "localhost", cfg.Host)
+	assert.Equal(t, 80, cfg.Port)
+}
+
+func TestDeepCopy(t *testing.T) {
+	type inner struct {
+		Values map[string][]string
+	}
+	var (
+		i   = 1
+		src = struct {
+			Ptr   *int
+			Inner *inner
+			Arr   [2]*int
+		}{
+			Ptr:   &i,
+			Inner: &inner{Values: map[string][]string{"k": {"v"}}},
+			Arr:   [2]*int{&i, nil},
+		}
+		dst = deepCopy(reflect.ValueOf(&src).Elem())
+	)
+
+	assert.Equal(t, src, dst.Interface())
+
+	i = 2
+	src.Inner.Values["k"][0] = "changed"
+	copied := dst.Interface().(struct {
+		Ptr   *int
+		Inner *inner
+		Arr   [2]*int
+	})
+	assert.Equal(t, 1, *copied.Ptr)
+	assert.Equal(t, 1, *copied.Arr[0])
+	assert.Equal(t, "v", copied.Inner.Values["k"][0])
+}
@@ -0,0 +1,53 @@
+package configuration
+
+import "strings"
+
+// closestMatch returns the candidate which is the closest to the name (case-insensitive edit distance)
+// if it's close enough to be a typo: "nmae" -> "name", "timeout_secnds" -> "timeout_seconds"
+func closestMatch(name string, candidates []string) (string, bool) {
+	var (
+		best     string
+		bestDist = -1
+	)
+	for _, candidate := range candidates {
+		d := editDistance(strings.ToLower(name), strings.ToLower(candidate))
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = candidate, d
+		}
+	}
+	if bestDist < 0 || bestDist > 3 || bestDist*2 > len([]rune(name)) {
+		return "", false
+	}
+	return best, true
+}
+
+// editDistance is the Levenshtein distance between the strings
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(first int, rest ...int) int {
+	for _, v := range rest {
+		if v < first {
+			first = v
+		}
+	}
+	return first
+}
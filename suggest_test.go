@@ -0,0 +1,34 @@
+package configuration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClosestMatch(t *testing.T) {
+	candidates := []string{"name", "timeout_seconds", "host", "port"}
+	tests := map[string]struct {
+		name     string
+		expected string
+		found    bool
+	}{
+		"transposition":  {name: "nmae", expected: "name", found: true},
+		"missing letter": {name: "timeout_secnds", expected: "timeout_seconds", found: true},
+		"case":           {name: "HOST", expected: "host", found: true},
+		"too far":        {name: "database", found: false},
+		"too short":      {name: "x", found: false},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			got, ok := closestMatch(test.name, candidates)
+			assert.Equal(t, test.found, ok)
+			assert.Equal(t, test.expected, got)
+		})
+	}
+
+	_, ok := closestMatch("name", nil)
+	assert.False(t, ok)
+}
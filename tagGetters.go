@@ -1,19 +1,267 @@
 package configuration
 
-import "reflect"
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
 
-func getEnvTag(f reflect.StructField) string {
-	return f.Tag.Get("env")
+// Names of the tags which are read by the providers
+const (
+	TagDefault = "default"
+	TagEnv     = "env"
+	TagFlag    = "flag"
+	TagCfg     = "cfg" // combined tag: `cfg:"env=DB_HOST,flag=db-host,default=localhost,required"`
+)
+
+// tagNames maps the tags read by providers (TagDefault, TagEnv, TagFlag, TagCfg) to the names of the tags
+// in fields (see WithTagName), nil reads the names set with SetTagName
+type tagNames map[string]string
+
+func (n tagNames) name(tag string) string {
+	if n == nil {
+		gBaseTagMu.RLock()
+		defer gBaseTagMu.RUnlock()
+		n = gBaseTagNames
+	}
+	if name, ok := n[tag]; ok {
+		return name
+	}
+	return tag
+}
+
+// gBaseTagNames are names set with SetTagName, configurators start with them (see WithTagName)
+var (
+	gBaseTagNames = tagNames{
+		TagDefault: TagDefault,
+		TagEnv:     TagEnv,
+		TagFlag:    TagFlag,
+		TagCfg:     TagCfg,
+	}
+	gBaseTagMu sync.RWMutex
+)
+
+// baseTagNames returns a copy of the names set with SetTagName
+func baseTagNames() tagNames {
+	gBaseTagMu.RLock()
+	defer gBaseTagMu.RUnlock()
+
+	names := make(tagNames, len(gBaseTagNames))
+	for tag, name := range gBaseTagNames {
+		names[tag] = name
+	}
+	return names
+}
+
+// options of the combined tag which don't have a value
+var cfgTagBoolOptions = map[string]bool{
+	"required": true,
+	"relpath":  true,
+	"inherit":  true,
 }
 
-func getFlagTag(f reflect.StructField) string {
-	return f.Tag.Get("flag")
+// SetTagName makes providers read the tag `name` instead of `tag` (one of TagDefault, TagEnv, TagFlag, TagCfg),
+// e.g. SetTagName(TagDefault, "cfgdefault") to avoid collisions with other libraries.
+// Must be called before creating providers and configurators, use WithTagName to rename tags for one configurator.
+func SetTagName(tag, name string) {
+	gBaseTagMu.Lock()
+	defer gBaseTagMu.Unlock()
+	if _, ok := gBaseTagNames[tag]; ok && name != "" {
+		gBaseTagNames[tag] = name
+	}
+}
+
+func getEnvTag(f reflect.StructField, names tagNames) string {
+	return lookupTag(f, TagEnv, names)
+}
+
+func getFlagTag(f reflect.StructField, names tagNames) string {
+	return lookupTag(f, TagFlag, names)
 }
 
 func getJSONTag(f reflect.StructField) string {
 	return f.Tag.Get("json")
 }
 
-func getDefaultTag(f reflect.StructField) string {
-	return f.Tag.Get("default")
+func getYAMLTag(f reflect.StructField) string {
+	return f.Tag.Get("yaml")
+}
+
+func getSecretTag(f reflect.StructField) string {
+	return f.Tag.Get("secret")
+}
+
+func getFormatTag(f reflect.StructField) string {
+	return f.Tag.Get("format")
+}
+
+func getDefaultFromTag(f reflect.StructField) string {
+	return f.Tag.Get("defaultFrom")
+}
+
+func getDefaultReleaseTag(f reflect.StructField) string {
+	return f.Tag.Get("defaultRelease")
+}
+
+func getBuildTag(f reflect.StructField) string {
+	return strings.ToLower(f.Tag.Get("build"))
+}
+
+func getPayloadTag(f reflect.StructField) string {
+	return strings.ToLower(f.Tag.Get("payload"))
+}
+
+// isPayload reports whether the nested struct is tagged with the format of the payload (see fillUpPayload)
+func isPayload(f reflect.StructField) bool {
+	t := f.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch getPayloadTag(f) {
+	case PayloadAuto, PayloadJSON, PayloadYAML:
+		return t.Kind() == reflect.Struct
+	}
+	return false
+}
+
+// Values of `severity` tag: fields tagged `severity:"warn"` which cannot be set keep their values
+// and the failure is only logged, other fields fail InitValues
+const (
+	SeverityError = "error"
+	SeverityWarn  = "warn"
+)
+
+// isGroup reports whether the nested struct is tagged `group:"true"` (see fillUpGroup)
+func isGroup(f reflect.StructField) bool {
+	t := f.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	b, _ := strconv.ParseBool(f.Tag.Get("group"))
+	return b && t.Kind() == reflect.Struct
+}
+
+// isRelPath reports whether the field is tagged `relpath:"true"` or has the `relpath` option of the combined tag
+// (see fileProvider.resolvePaths)
+func isRelPath(f reflect.StructField, names tagNames) bool {
+	return boolTag(f, "relpath", names)
+}
+
+// isInherited reports whether the struct field is tagged `inherit:"true"` or has the `inherit` option
+// of the combined tag (see structFields), the field can be unexported if it's embedded
+func isInherited(f reflect.StructField, names tagNames) bool {
+	return boolTag(f, "inherit", names) &&
+		(f.PkgPath == "" || f.Anonymous) && f.Type.Kind() == reflect.Struct && !isLeafStruct(f.Type)
+}
+
+func isWarnOnly(f reflect.StructField) bool {
+	return strings.EqualFold(f.Tag.Get("severity"), SeverityWarn)
+}
+
+func getValidateTag(f reflect.StructField) string {
+	return f.Tag.Get("validate")
+}
+
+// isRequired reports whether the field is tagged `required:"true"` or has `required` option in the combined tag
+func isRequired(f reflect.StructField, names tagNames) bool {
+	return boolTag(f, "required", names)
+}
+
+// boolTag reports whether the field is tagged `<tag>:"true"`; the option of the combined tag is used
+// if the separate tag is absent
+func boolTag(f reflect.StructField, tag string, names tagNames) bool {
+	val, ok := f.Tag.Lookup(tag)
+	if !ok {
+		val = getCfgTag(f, names)[tag]
+	}
+	b, _ := strconv.ParseBool(val)
+	return b
+}
+
+func getTTLTag(f reflect.StructField) string {
+	return f.Tag.Get("ttl")
+}
+
+func getMetricTag(f reflect.StructField) string {
+	return f.Tag.Get("metric")
+}
+
+func getDefaultTag(f reflect.StructField, names tagNames) string {
+	return lookupTag(f, TagDefault, names)
+}
+
+// getRuntimeDefaultTag returns the value of `default_<runtime>` tag (or the option of the combined tag)
+func getRuntimeDefaultTag(f reflect.StructField, runtime string, names tagNames) string {
+	if val, ok := f.Tag.Lookup(names.name(TagDefault) + "_" + runtime); ok {
+		return val
+	}
+	return getCfgTag(f, names)[TagDefault+"_"+runtime]
+}
+
+// lookupTag returns the value of the separate tag or, if it's absent, the value of the option
+// with the same name from the combined `cfg` tag
+func lookupTag(f reflect.StructField, tag string, names tagNames) string {
+	if val, ok := f.Tag.Lookup(names.name(tag)); ok {
+		return val
+	}
+	return getCfgTag(f, names)[tag]
+}
+
+// getCfgTag parses the combined tag `cfg:"env=DB_HOST,flag=db-host,default=localhost,required"` into a map.
+// Options without a value (like `required`) are set to "true".
+// A comma which doesn't start a new option stays in the value: `default=a,b` -> "a,b".
+func getCfgTag(f reflect.StructField, names tagNames) map[string]string {
+	tag := f.Tag.Get(names.name(TagCfg))
+	if tag == "" {
+		return nil
+	}
+
+	var (
+		options = map[string]string{}
+		last    string
+	)
+	for _, part := range strings.Split(tag, ",") {
+		key := strings.TrimSpace(part)
+		if i := strings.Index(part, "="); i >= 0 {
+			key = strings.TrimSpace(part[:i])
+		}
+
+		known := key == TagDefault || key == TagEnv || key == TagFlag ||
+			strings.HasPrefix(key, TagDefault+"_") // defaults of runtimes: `default_dev=console`
+		switch {
+		case known && strings.Contains(part, "="):
+			last = key
+			options[key] = part[strings.Index(part, "=")+1:]
+		case cfgTagBoolOptions[key]:
+			last = ""
+			options[key] = "true"
+		case last != "":
+			options[last] += "," + part
+		}
+	}
+	return options
+}
+
+// getFieldKey returns the name of the field from `json` or `yaml` tag (options like `omitempty` are dropped),
+// the name of the field itself if both tags are absent or an empty string for fields tagged `json:"-"`
+// (`json:"-,"` is the key `-` like in encoding/json)
+func getFieldKey(f reflect.StructField) string {
+	jsonTag := getJSONTag(f)
+	if jsonTag == "-" {
+		return ""
+	}
+	if name := strings.Split(jsonTag, ",")[0]; name != "" {
+		return name
+	}
+	if name := strings.Split(getYAMLTag(f), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	return f.Name
+}
+
+// isInternalField reports whether the field must not be touched: unexported fields,
+// `XXX_` fields of protobuf-generated structs and fields tagged `json:"-"`
+func isInternalField(f reflect.StructField) bool {
+	return f.PkgPath != "" || strings.HasPrefix(f.Name, "XXX_") || getFieldKey(f) == ""
 }
@@ -3,11 +3,13 @@ package configuration
 import (
 	"reflect"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGetTags(t *testing.T) {
 	type testStruct struct {
-		Name string `json:"jsonVal"  default:"defaultVal"  env:"envVal"  flag:"flagVal"`
+		Name string `json:"jsonVal"  yaml:"yamlVal"  default:"defaultVal"  env:"envVal"  flag:"flagVal"`
 	}
 	field := reflect.TypeOf(&testStruct{}).Elem().Field(0)
 
@@ -21,19 +23,24 @@ func TestGetTags(t *testing.T) {
 			fn:             getJSONTag,
 			expectedResult: "jsonVal",
 		},
+		{
+			name:           "yaml",
+			fn:             getYAMLTag,
+			expectedResult: "yamlVal",
+		},
 		{
 			name:           "default",
-			fn:             getDefaultTag,
+			fn:             func(f reflect.StructField) string { return getDefaultTag(f, nil) },
 			expectedResult: "defaultVal",
 		},
 		{
 			name:           "env",
-			fn:             getEnvTag,
+			fn:             func(f reflect.StructField) string { return getEnvTag(f, nil) },
 			expectedResult: "envVal",
 		},
 		{
 			name:           "flag",
-			fn:             getFlagTag,
+			fn:             func(f reflect.StructField) string { return getFlagTag(f, nil) },
 			expectedResult: "flagVal",
 		},
 	}
@@ -48,3 +55,110 @@ func TestGetTags(t *testing.T) {
 		})
 	}
 }
+
+func TestGetFieldKey(t *testing.T) {
+	type testStruct struct {
+		Name     string
+		JSONName string `json:"json_name"`
+		Options  string `json:"opt_name,omitempty"`
+		OnlyOpts string `json:",omitempty"`
+		Skipped  string `json:"-"`
+		YAMLName string `yaml:"yaml_name"`
+		Both     string `json:"json_both" yaml:"yaml_both"`
+		YAMLOnly string `json:"-" yaml:"yaml_only"`
+		Dash     string `json:"-,"`
+		YAMLSkip string `yaml:"-"`
+	}
+	expected := []string{"Name", "json_name", "opt_name", "OnlyOpts", "", "yaml_name", "json_both", "", "-", "YAMLSkip"}
+
+	typ := reflect.TypeOf(testStruct{})
+	for i := 0; i < typ.NumField(); i++ {
+		if got := getFieldKey(typ.Field(i)); got != expected[i] {
+			t.Errorf("\nexpected result: [%s] \nbut got: [%s]", expected[i], got)
+		}
+	}
+}
+
+func TestSetTagName(t *testing.T) {
+	defer func() {
+		SetTagName(TagDefault, TagDefault)
+		SetTagName(TagEnv, TagEnv)
+		SetTagName(TagFlag, TagFlag)
+	}()
+
+	type testStruct struct {
+		Name string `default:"other_lib" cfgdefault:"defaultVal" cfgenv:"envVal" cfgflag:"flagVal"`
+	}
+	field := reflect.TypeOf(&testStruct{}).Elem().Field(0)
+
+	SetTagName(TagDefault, "cfgdefault")
+	SetTagName(TagEnv, "cfgenv")
+	SetTagName(TagFlag, "cfgflag")
+	SetTagName("unknown", "cfgunknown")
+	SetTagName(TagFlag, "")
+
+	if got := getDefaultTag(field, nil); got != "defaultVal" {
+		t.Errorf("\nexpected result: [%s] \nbut got: [%s]", "defaultVal", got)
+	}
+	if got := getEnvTag(field, nil); got != "envVal" {
+		t.Errorf("\nexpected result: [%s] \nbut got: [%s]", "envVal", got)
+	}
+	if got := getFlagTag(field, nil); got != "flagVal" {
+		t.Errorf("\nexpected result: [%s] \nbut got: [%s]", "flagVal", got)
+	}
+}
+
+func TestGetCfgTag(t *testing.T) {
+	type testStruct struct {
+		Combined string `cfg:"env=DB_HOST,flag=db-host|localhost|database host, with port,default=localhost,required"`
+		Separate string `cfg:"env=CFG_ENV,default=cfg_default" env:"ENV" default:""`
+		Empty    string
+	}
+	var (
+		typ      = reflect.TypeOf(testStruct{})
+		combined = typ.Field(0)
+		separate = typ.Field(1)
+	)
+
+	assert.Equal(t, map[string]string{
+		"env":      "DB_HOST",
+		"flag":     "db-host|localhost|database host, with port",
+		"default":  "localhost",
+		"required": "true",
+	}, getCfgTag(combined, nil))
+	assert.Nil(t, getCfgTag(typ.Field(2), nil))
+
+	assert.Equal(t, "DB_HOST", getEnvTag(combined, nil))
+	assert.Equal(t, "db-host|localhost|database host, with port", getFlagTag(combined, nil))
+	assert.Equal(t, "localhost", getDefaultTag(combined, nil))
+
+	// separate tags have priority even if they are empty
+	assert.Equal(t, "ENV", getEnvTag(separate, nil))
+	assert.Equal(t, "", getDefaultTag(separate, nil))
+}
+
+func TestBoolTags(t *testing.T) {
+	type testStruct struct {
+		Combined platformConfig `cfg:"inherit"`
+		Renamed  platformConfig `config:"inherit"`
+		Both     platformConfig `inherit:"false" cfg:"inherit"`
+		RelPath  string         `relpath:"true"`
+		Options  string         `cfg:"default=certs/tls.pem,relpath"`
+	}
+	var (
+		typ   = reflect.TypeOf(testStruct{})
+		names = baseTagNames()
+	)
+	names[TagCfg] = "config"
+
+	assert.True(t, isInherited(typ.Field(0), nil))
+	assert.False(t, isInherited(typ.Field(0), names))
+	assert.False(t, isInherited(typ.Field(1), nil))
+	assert.True(t, isInherited(typ.Field(1), names))
+	assert.False(t, isInherited(typ.Field(2), nil), "separate tags have priority")
+
+	assert.True(t, isRelPath(typ.Field(3), nil))
+	assert.True(t, isRelPath(typ.Field(4), nil))
+	assert.Equal(t, "certs/tls.pem", getDefaultTag(typ.Field(4), nil))
+	assert.False(t, isRelPath(typ.Field(4), names))
+}
@@ -0,0 +1,50 @@
+package configuration
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// TenantProviders returns a list of providers for the tenant located in `dir`
+type TenantProviders func(tenant, dir string) []Provider
+
+// LoadTenants initializes a separate configuration object for every subdirectory of `dir`.
+// The name of a subdirectory is used as the tenant name (key of the result map).
+// `newCfg` must return a new pointer to the configuration struct on each call.
+// Shared base values are achieved by adding common providers (e.g. a base file) after
+// the tenant-specific ones in the list returned by `providers`.
+func LoadTenants(
+	dir string,
+	newCfg func() interface{},
+	providers TenantProviders,
+	opts ...Option, // applied to configurators of all tenants, WithProviders is added from `providers`
+) (map[string]interface{}, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tenants := map[string]interface{}{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		var (
+			tenant    = entry.Name()
+			tenantDir = filepath.Join(dir, tenant)
+			cfgPtr    = newCfg()
+		)
+
+		c, err := New(cfgPtr, append(opts[:len(opts):len(opts)], WithProviders(providers(tenant, tenantDir)...))...)
+		if err != nil {
+			return nil, fmt.Errorf("tenant [%s]: %v", tenant, err)
+		}
+		if err := c.InitValues(); err != nil {
+			return nil, fmt.Errorf("tenant [%s]: %v", tenant, err)
+		}
+		tenants[tenant] = cfgPtr
+	}
+	return tenants, nil
+}
@@ -0,0 +1,45 @@
+package configuration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTenants(t *testing.T) {
+	type tenantCfg struct {
+		Name    string
+		Timeout time.Duration
+	}
+
+	tenants, err := LoadTenants(
+		"./testdata/tenants",
+		func() interface{} { return &tenantCfg{} },
+		func(_, dir string) []Provider {
+			return []Provider{
+				NewFileProvider(dir + "/config.yml"),
+				NewFileProvider("./testdata/tenants/base.yml"),
+			}
+		},
+	)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"alpha": &tenantCfg{Name: "alpha_name", Timeout: 5 * time.Second},
+		"beta":  &tenantCfg{Name: "base_name", Timeout: 10 * time.Second},
+	}, tenants)
+}
+
+func TestLoadTenants_NotFound(t *testing.T) {
+	_, err := LoadTenants(
+		"./testdata/not_existing_dir",
+		func() interface{} { return &struct{}{} },
+		func(_, _ string) []Provider { return []Provider{NewDefaultProvider()} },
+	)
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+}
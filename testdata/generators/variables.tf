@@ -0,0 +1,42 @@
+variable "name" {
+  type        = string
+  default     = "app"
+  description = "name of the service"
+}
+
+variable "log_level" {
+  type        = string
+  default     = "info"
+}
+
+variable "timeout" {
+  type        = string
+  default     = "5s"
+}
+
+variable "replicas" {
+  type        = number
+  default     = 3
+  description = "number of replicas"
+}
+
+variable "hosts" {
+  type        = list(string)
+  default     = ["a","b"]
+}
+
+variable "max_size" {
+  type        = string
+  default     = "64KB"
+  description = "expects e.g. 512, 64KB, 10MiB"
+}
+
+variable "database_host" {
+  type        = string
+  default     = "localhost"
+}
+
+variable "database_password" {
+  type        = string
+  sensitive   = true
+}
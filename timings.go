@@ -0,0 +1,62 @@
+package configuration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Timings describes how long the last InitValues (or Reload) took, e.g. to find the slow provider at startup
+type Timings struct {
+	Total     time.Duration            `json:"total"`
+	Providers map[string]time.Duration `json:"providers"` // name of the provider -> time spent in its calls
+	Fields    map[string]time.Duration `json:"fields"`    // path to the field -> time spent resolving it
+}
+
+func newTimings() Timings {
+	return Timings{Providers: map[string]time.Duration{}, Fields: map[string]time.Duration{}}
+}
+
+func (t Timings) copy() Timings {
+	result := Timings{
+		Total:     t.Total,
+		Providers: make(map[string]time.Duration, len(t.Providers)),
+		Fields:    make(map[string]time.Duration, len(t.Fields)),
+	}
+	for name, d := range t.Providers {
+		result.Providers[name] = d
+	}
+	for path, d := range t.Fields {
+		result.Fields[path] = d
+	}
+	return result
+}
+
+// String returns the summary with providers sorted from the slowest:
+// "took 4s: SSMProvider 3.2s, fileProvider 700ms, envProvider 100ms"
+func (t Timings) String() string {
+	names := make([]string, 0, len(t.Providers))
+	for name := range t.Providers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if t.Providers[names[i]] != t.Providers[names[j]] {
+			return t.Providers[names[i]] > t.Providers[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %v", name, t.Providers[name])
+	}
+	return fmt.Sprintf("took %v: %s", t.Total, strings.Join(parts, ", "))
+}
+
+// Timings returns per-provider and per-field timings of the last InitValues (or Reload)
+func (c configurator) Timings() Timings {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.stats.timings.copy()
+}
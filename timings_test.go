@@ -0,0 +1,46 @@
+package configuration
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigurator_Timings(t *testing.T) {
+	cfg := struct {
+		Host string `env:"HOST"`
+		Port int    `default:"80"`
+	}{}
+
+	var logs []string
+	c, err := New(&cfg,
+		WithProviders(slowProvider{delay: 10 * time.Millisecond}, NewEnvProvider().WithEnv(map[string]string{"HOST": "localhost"})),
+		WithTimingReport(),
+		WithLogger(func(format string, v ...interface{}) { logs = append(logs, fmt.Sprintf(format, v...)) }),
+		WithLogLevel(LogOff),
+	)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Equal(t, time.Duration(0), c.Timings().Total)
+	assert.NoError(t, c.InitValues())
+
+	timings := c.Timings()
+	assert.True(t, timings.Providers["slowProvider"] >= 20*time.Millisecond, "unexpected timings: %v", timings)
+	assert.True(t, timings.Providers["slowProvider"] > timings.Providers["envProvider"])
+	assert.True(t, timings.Fields["Host"] >= 10*time.Millisecond)
+	assert.True(t, timings.Fields["Port"] >= 10*time.Millisecond)
+	assert.True(t, timings.Total >= timings.Fields["Host"]+timings.Fields["Port"])
+
+	if assert.Len(t, logs, 1) {
+		assert.True(t, strings.HasPrefix(logs[0], "configurator: InitValues took "), logs[0])
+		assert.Contains(t, logs[0], ": slowProvider ")
+		assert.Contains(t, logs[0], ", envProvider ")
+	}
+
+	timings.Fields["Host"] = 0
+	assert.NotEqual(t, time.Duration(0), c.Timings().Fields["Host"], "timings must be copied")
+}
@@ -0,0 +1,253 @@
+package configuration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ManifestVersion is the version of the format of Manifest
+const ManifestVersion = 1
+
+// Results of attempts of providers in TraceAttempt
+const (
+	TraceSet      = "set"
+	TraceNotFound = "not_found"
+	TraceFailed   = "failed"
+)
+
+// Manifest is the reproducibility manifest of the last InitValues (or Reload) call recorded with WithTrace:
+// which providers were asked for every field, what they answered and which value was set.
+// Marshalled to JSON, it can be attached to the report of an incident and replayed with NewManifestProvider.
+type Manifest struct {
+	Version     int            `json:"version"` // ManifestVersion
+	StartedAt   time.Time      `json:"started_at"`
+	Duration    time.Duration  `json:"duration"`
+	GoVersion   string         `json:"go_version"`
+	Build       string         `json:"build,omitempty"` // path@version of the main module
+	Providers   []ProviderInfo `json:"providers"`
+	Fields      []TraceField   `json:"fields"`      // sorted by path
+	Fingerprint string         `json:"fingerprint"` // hash of paths and hashes of all values
+}
+
+// ProviderInfo describes the provider of the chain in Manifest
+type ProviderInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"` // see VersionedProvider
+	Source  string `json:"source,omitempty"`  // e.g. the name of the file
+	Digest  string `json:"digest,omitempty"`  // SHA-256 of the content of the source
+}
+
+// TraceField is the resolution of the field in Manifest
+type TraceField struct {
+	Path     string          `json:"path"`
+	Source   string          `json:"source,omitempty"` // the provider which set the value, empty if it isn't set
+	Value    json.RawMessage `json:"value,omitempty"`  // JSON of the value, omitted for secrets
+	Hash     string          `json:"hash"`             // SHA-256 of JSON of the value (beware that weak secrets can be brute-forced)
+	Secret   bool            `json:"secret,omitempty"`
+	Time     time.Time       `json:"time"`
+	Attempts []TraceAttempt  `json:"attempts"` // in the order of providers
+}
+
+// TraceAttempt is the answer of the provider for the field
+type TraceAttempt struct {
+	Provider string `json:"provider"`
+	Result   string `json:"result"` // TraceSet, TraceNotFound or TraceFailed
+	Error    string `json:"error,omitempty"`
+}
+
+// Manifest returns the manifest of the last InitValues call, false if WithTrace isn't enabled or nothing is recorded yet
+func (c configurator) Manifest() (Manifest, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.stats.manifest == nil {
+		return Manifest{}, false
+	}
+	return c.stats.manifest.copy(), true
+}
+
+// Mismatches returns paths (sorted) of fields whose values differ from the other manifest or exist only in one of them,
+// e.g. to check that the replay in the lab reproduced the configuration of production
+func (m Manifest) Mismatches(other Manifest) []string {
+	hashes := make(map[string]string, len(m.Fields))
+	for _, f := range m.Fields {
+		hashes[f.Path] = f.Hash
+	}
+
+	var result []string
+	for _, f := range other.Fields {
+		hash, ok := hashes[f.Path]
+		if !ok || hash != f.Hash {
+			result = append(result, f.Path)
+		}
+		delete(hashes, f.Path)
+	}
+	for path := range hashes {
+		result = append(result, path)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func (m Manifest) copy() Manifest {
+	result := m
+	result.Providers = append([]ProviderInfo(nil), m.Providers...)
+	result.Fields = make([]TraceField, len(m.Fields))
+	for i, f := range m.Fields {
+		f.Value = append(json.RawMessage(nil), f.Value...)
+		f.Attempts = append([]TraceAttempt(nil), f.Attempts...)
+		result.Fields[i] = f
+	}
+	return result
+}
+
+func newManifest(providers []Provider, startedAt time.Time) *Manifest {
+	m := &Manifest{Version: ManifestVersion, StartedAt: startedAt, GoVersion: runtime.Version()}
+	info, ok := debug.ReadBuildInfo()
+	if ok && info.Main.Path != "" {
+		m.Build = info.Main.Path + "@" + info.Main.Version
+	}
+	for _, p := range providers {
+		m.Providers = append(m.Providers, providerInfo(p, info))
+	}
+	return m
+}
+
+// digester is implemented by providers which read the whole source at once (see fileProvider)
+type digester interface {
+	digest() (source, digest string)
+}
+
+func providerInfo(p Provider, info *debug.BuildInfo) ProviderInfo {
+	result := ProviderInfo{Name: providerName(p)}
+	if vp, ok := p.(VersionedProvider); ok {
+		result.Version = vp.Version()
+	} else if info != nil {
+		result.Version = moduleVersion(info, reflect.TypeOf(p))
+	}
+	if d, ok := p.(digester); ok {
+		result.Source, result.Digest = d.digest()
+	}
+	return result
+}
+
+// moduleVersion returns the version of the module which defines the type
+func moduleVersion(info *debug.BuildInfo, t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	pkg := t.PkgPath()
+	modules := append([]*debug.Module{&info.Main}, info.Deps...)
+	for _, m := range modules {
+		if m.Path != "" && (pkg == m.Path || strings.HasPrefix(pkg, m.Path+"/")) {
+			if m.Replace != nil {
+				return m.Replace.Version
+			}
+			return m.Version
+		}
+	}
+	return ""
+}
+
+// add records the resolution of the field
+func (m *Manifest) add(field reflect.StructField, v reflect.Value, path, source string, attempts []TraceAttempt) {
+	f := TraceField{Path: path, Source: source, Secret: isSecret(field), Time: time.Now(), Attempts: attempts}
+	b, err := json.Marshal(v.Interface())
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprintf("%#v", v.Interface()))
+	}
+	sum := sha256.Sum256(b)
+	f.Hash = hex.EncodeToString(sum[:])
+	if !f.Secret {
+		f.Value = b
+	}
+	m.Fields = append(m.Fields, f)
+}
+
+// finish sorts fields and computes the fingerprint
+func (m *Manifest) finish() {
+	m.Duration = time.Since(m.StartedAt)
+	sort.SliceStable(m.Fields, func(i, j int) bool { return m.Fields[i].Path < m.Fields[j].Path })
+
+	h := sha256.New()
+	for _, f := range m.Fields {
+		fmt.Fprintf(h, "%q=%q\n", f.Path, f.Hash)
+	}
+	m.Fingerprint = hex.EncodeToString(h.Sum(nil))
+}
+
+func traceAttempt(provider Provider, result string, err error) TraceAttempt {
+	attempt := TraceAttempt{Provider: providerName(provider), Result: result}
+	if err != nil {
+		attempt.Error = err.Error()
+	}
+	return attempt
+}
+
+// NewManifestProvider creates the provider which sets values recorded in the manifest (see WithTrace),
+// so the configuration of an incident can be replayed exactly. Values of secrets aren't recorded,
+// providers after it must set them; compare the new manifest with Mismatches to check the replay.
+func NewManifestProvider(m Manifest) manifestProvider {
+	values := make(map[string]json.RawMessage, len(m.Fields))
+	for _, f := range m.Fields {
+		if f.Source != "" && len(f.Value) > 0 {
+			values[f.Path] = f.Value
+		}
+	}
+	return manifestProvider{values: values}
+}
+
+type manifestProvider struct {
+	values map[string]json.RawMessage // path to the field -> JSON of the value
+	opts   *options                   // of the configurator (see optionsBinder)
+}
+
+func (mp manifestProvider) withOptions(o *options) Provider {
+	mp.opts = o
+	return mp
+}
+
+func (mp manifestProvider) Provide(field reflect.StructField, v reflect.Value, path ...string) bool {
+	ok, _ := mp.ProvideError(context.Background(), field, v, path...)
+	return ok
+}
+
+// ProvideError returns the error if the recorded value cannot be decoded into the field
+func (mp manifestProvider) ProvideError(_ context.Context, field reflect.StructField, v reflect.Value, path ...string) (bool, error) {
+	key := strings.Join(path, pathSeparator)
+	raw, ok := mp.values[key]
+	if !ok {
+		return false, nil
+	}
+
+	ptr := reflect.New(v.Type())
+	if err := json.Unmarshal(raw, ptr.Interface()); err != nil {
+		err = fmt.Errorf("cannot decode [%s]: %v", key, err)
+		mp.opts.errorf("manifestProvider: %v", err)
+		return false, parseError(err)
+	}
+	v.Set(ptr.Elem())
+	mp.opts.logf("manifestProvider: set [%v] to field [%s]", logValue(field, string(raw)), key)
+	return true, nil
+}
+
+// Keys returns keys of the map located at the path which are recorded in the manifest
+func (mp manifestProvider) Keys(path ...string) []string {
+	prefix := strings.Join(path, pathSeparator) + pathSeparator
+	seen := map[string]interface{}{}
+	for key := range mp.values {
+		if rest := strings.TrimPrefix(key, prefix); rest != key {
+			seen[strings.SplitN(rest, pathSeparator, 2)[0]] = nil
+		}
+	}
+	return sortedKeys(seen)
+}
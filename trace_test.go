@@ -0,0 +1,141 @@
+package configuration
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type traceConfig struct {
+	Host      string        `json:"host" default:"localhost"`
+	Port      int           `json:"port" env:"TRACE_TEST_PORT" default:"5432"`
+	Timeout   time.Duration `json:"timeout" default:"1s"`
+	Password  string        `json:"password" env:"TRACE_TEST_PASSWORD" secret:"true"`
+	Upstreams map[string]struct {
+		URL string `json:"url"`
+	} `json:"upstreams"`
+}
+
+func TestWithTrace(t *testing.T) {
+	removePort, err := setEnv("TRACE_TEST_PORT", "6432")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	defer removePort()
+	removePassword, err := setEnv("TRACE_TEST_PASSWORD", "s3cret")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	defer removePassword()
+
+	var cfg traceConfig
+	c, err := New(&cfg, WithProviders(NewEnvProvider(), NewFileProvider("./testdata/trace.yml"), NewDefaultProvider()), WithTrace())
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	_, ok := c.Manifest()
+	assert.False(t, ok, "nothing is recorded before InitValues")
+	assert.NoError(t, c.InitValues())
+
+	manifest, ok := c.Manifest()
+	assert.True(t, ok)
+	assert.Equal(t, ManifestVersion, manifest.Version)
+	assert.NotEmpty(t, manifest.GoVersion)
+	assert.Len(t, manifest.Fingerprint, 64)
+
+	assert.Len(t, manifest.Providers, 3)
+	assert.Equal(t, "envProvider", manifest.Providers[0].Name)
+	assert.Equal(t, "fileProvider", manifest.Providers[1].Name)
+	assert.Equal(t, "./testdata/trace.yml", manifest.Providers[1].Source)
+	assert.Len(t, manifest.Providers[1].Digest, 64)
+
+	fields := map[string]TraceField{}
+	for _, f := range manifest.Fields {
+		fields[f.Path] = f
+	}
+	assert.Len(t, fields, 6)
+
+	host := fields["host"]
+	assert.Equal(t, "fileProvider", host.Source)
+	assert.Equal(t, json.RawMessage(`"db.internal"`), host.Value)
+	assert.False(t, host.Time.IsZero())
+	assert.Equal(t, []TraceAttempt{
+		{Provider: "envProvider", Result: TraceNotFound},
+		{Provider: "fileProvider", Result: TraceSet},
+	}, host.Attempts)
+
+	assert.Equal(t, "envProvider", fields["port"].Source)
+	assert.Equal(t, json.RawMessage(`5000000000`), fields["timeout"].Value)
+	assert.Equal(t, json.RawMessage(`"http://api.internal"`), fields["upstreams.api.url"].Value)
+
+	password := fields["password"]
+	assert.True(t, password.Secret)
+	assert.Nil(t, password.Value, "values of secrets aren't recorded")
+	assert.Len(t, password.Hash, 64)
+
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	var recorded Manifest
+	if err := json.Unmarshal(b, &recorded); err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+
+	// replay: recorded values, the secret is set by the environment of the lab
+	var replayed traceConfig
+	replay, err := New(&replayed, WithProviders(NewManifestProvider(recorded), NewEnvProvider()), WithTrace())
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	removePort()
+	assert.NoError(t, replay.InitValues())
+	assert.Equal(t, cfg, replayed)
+
+	replayManifest, _ := replay.Manifest()
+	assert.Empty(t, recorded.Mismatches(replayManifest))
+	assert.Equal(t, recorded.Fingerprint, replayManifest.Fingerprint)
+
+	removePassword()
+	if _, err := setEnv("TRACE_TEST_PASSWORD", "other"); err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, replay.InitValues())
+	replayManifest, _ = replay.Manifest()
+	assert.Equal(t, []string{"password"}, recorded.Mismatches(replayManifest))
+}
+
+func TestWithTrace_Failures(t *testing.T) {
+	cfg := struct {
+		Port int `default:"port" validate:"min=1"`
+	}{}
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()), WithTrace())
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Error(t, c.InitValues())
+
+	manifest, ok := c.Manifest()
+	assert.True(t, ok)
+	assert.Len(t, manifest.Fields, 1)
+	assert.Equal(t, "", manifest.Fields[0].Source)
+	assert.Len(t, manifest.Fields[0].Attempts, 1)
+	assert.Equal(t, TraceFailed, manifest.Fields[0].Attempts[0].Result)
+	assert.NotEmpty(t, manifest.Fields[0].Attempts[0].Error)
+}
+
+func TestManifest_Disabled(t *testing.T) {
+	cfg := struct {
+		Name string `default:"test"`
+	}{}
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	_, ok := c.Manifest()
+	assert.False(t, ok)
+}
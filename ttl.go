@@ -0,0 +1,122 @@
+package configuration
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// checkTTLs returns an error if the value of `ttl` tag of any field isn't a positive duration (see parseDuration)
+func checkTTLs(t reflect.Type, names tagNames) error {
+	var err error
+	walkTypes(t, nil, names, func(path string, field reflect.StructField) {
+		tag := getTTLTag(field)
+		if err != nil || tag == "" {
+			return
+		}
+		if d, e := parseDuration(tag); e != nil || d <= 0 {
+			err = fmt.Errorf("configurator: field [%s] has invalid ttl [%s]", path, tag)
+		}
+	})
+	return err
+}
+
+// fieldTTL returns the duration of `ttl` tag, 0 if the field doesn't expire
+func fieldTTL(f reflect.StructField) time.Duration {
+	d, _ := parseDuration(getTTLTag(f))
+	return d
+}
+
+// expirations returns times when values of fields tagged `ttl` which are set at the moment expire
+func expirations(cfg interface{}, names tagNames, now time.Time) map[string]time.Time {
+	result := map[string]time.Time{}
+	walkFields(reflect.ValueOf(cfg), nil, names, func(path string, field reflect.StructField, _ reflect.Value) {
+		if ttl := fieldTTL(field); ttl > 0 {
+			result[path] = now.Add(ttl)
+		}
+	})
+	return result
+}
+
+// expiredFields returns paths of fields whose values are expired
+func (c configurator) expiredFields(now time.Time) map[string]bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	expired := map[string]bool{}
+	for path, expires := range c.watch.expires {
+		if !now.Before(expires) {
+			expired[path] = true
+		}
+	}
+	return expired
+}
+
+// refreshExpired asks providers again for values of expired fields (see Watch)
+func (c configurator) refreshExpired(ctx context.Context) {
+	expired := c.expiredFields(time.Now())
+	if len(expired) == 0 {
+		return
+	}
+
+	c.opts.logf("configurator: values of %d fields are expired, refreshing", len(expired))
+	old, err := c.refreshFields(ctx, expired)
+	if err != nil { // the old values are kept, the fields are refreshed again after their TTLs
+		c.opts.errorf("configurator: watch: %v", err)
+		return
+	}
+	c.notify(old)
+}
+
+// refreshFields fills up the fields at the paths in a copy of the configuration object and applies it
+// like reloadWatched, values of other fields are kept. Returns the copy of the previous configuration.
+// Expiration times of the fields are renewed even if it fails, so a failing provider is asked again
+// only after the TTLs instead of on every check of Watch.
+func (c configurator) refreshFields(ctx context.Context, paths map[string]bool) (reflect.Value, error) {
+	defer c.lock()()
+	defer c.beginChange()()
+	defer c.renewExpirations(paths)
+
+	ctx = withRunCache(ctx)
+
+	stats := *c.stats
+	stats.timings, stats.manifest, stats.warnings = newTimings(), nil, nil
+
+	prev := c.snapshot()
+	fresh := c
+	fresh.config = deepCopy(prev.config).Addr().Interface()
+	fresh.sources = c.copySources()
+	fresh.stats = &stats
+	fresh.unset, fresh.failures = nil, nil
+
+	var err error
+	walkFieldPaths(reflect.ValueOf(fresh.config), nil, fresh.opts.tags(), func(path []string, field reflect.StructField, v reflect.Value) {
+		if err == nil && paths[strings.Join(path, pathSeparator)] {
+			err = fresh.applyProviders(ctx, field, v, path)
+		}
+	})
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	c.restore(snapshot{config: reflect.ValueOf(fresh.config).Elem(), sources: fresh.sources})
+	if c.history.healthCheck != nil {
+		if err := c.history.healthCheck(); err != nil {
+			c.restore(prev)
+			return reflect.Value{}, err
+		}
+	}
+	c.history.push(c.snapshot())
+	return prev.config, nil
+}
+
+// renewExpirations sets new expiration times of the fields at the paths, the configurator must be locked
+func (c configurator) renewExpirations(paths map[string]bool) {
+	for path, expires := range expirations(c.config, c.opts.tags(), time.Now()) {
+		if paths[path] {
+			c.watch.expires[path] = expires
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package configuration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch_TTL(t *testing.T) {
+	type config struct {
+		Host  string `yaml:"host"`
+		Token string `yaml:"token" ttl:"20ms"`
+	}
+
+	var (
+		mu      sync.Mutex
+		version int
+		fetched int
+	)
+	secrets := NewDocumentProvider("secrets.yml", func(context.Context) ([]byte, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetched++
+		return []byte(fmt.Sprintf("host: host-%d\ntoken: token-%d", version, version)), nil
+	})
+
+	var cfg config
+	c, err := New(&cfg, WithProviders(secrets), WithWatchInterval(5*time.Millisecond))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, config{Host: "host-0", Token: "token-0"}, cfg)
+
+	changes := make(chan config, 10)
+	c.OnChange(func(_, newCfg interface{}) {
+		changes <- *newCfg.(*config)
+	})
+	assert.NoError(t, c.Start(context.Background()))
+	defer c.Stop()
+
+	// the first check of Watch reloads all fields
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		mu.Lock()
+		if fetched >= 2 || time.Now().After(deadline) {
+			version = 1
+			mu.Unlock()
+			break
+		}
+		mu.Unlock()
+	}
+
+	select {
+	case change := <-changes:
+		assert.Equal(t, config{Host: "host-0", Token: "token-1"}, change, "only the expired field is refreshed")
+	case <-time.After(time.Second):
+		t.Fatal("expired field is not refreshed")
+	}
+	c.Stop()
+
+	token, _ := c.Get("token")
+	assert.Equal(t, "token-1", token)
+	host, _ := c.Get("host")
+	assert.Equal(t, "host-0", host)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.True(t, fetched >= 3)
+}
+
+func TestWatch_TTLBackOff(t *testing.T) {
+	type config struct {
+		Token string `yaml:"token" ttl:"1h"`
+	}
+
+	fetched := 0
+	secrets := NewDocumentProvider("secrets.yml", func(context.Context) ([]byte, error) {
+		fetched++
+		if fetched > 1 {
+			return nil, errors.New("vault is sealed")
+		}
+		return []byte("token: token-0"), nil
+	})
+
+	var cfg config
+	c, err := New(&cfg, WithProviders(secrets))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	c.watch.expires["token"] = time.Now().Add(-time.Second)
+	c.refreshExpired(context.Background())
+	c.refreshExpired(context.Background())
+
+	assert.Equal(t, 2, fetched, "the failed refresh is retried after the TTL, not on every check")
+	assert.Equal(t, "token-0", cfg.Token)
+	assert.True(t, c.watch.expires["token"].After(time.Now().Add(59*time.Minute)))
+}
+
+func TestNew_InvalidTTL(t *testing.T) {
+	cfg := struct {
+		Token string `ttl:"soon"`
+	}{}
+	_, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	assert.EqualError(t, err, "configurator: field [Token] has invalid ttl [soon]")
+}
+
+func TestFieldTTL(t *testing.T) {
+	cfg := struct {
+		Token  string `ttl:"1d"`
+		Secret string `ttl:"1w12h"`
+		Name   string
+	}{}
+	typ := reflect.TypeOf(cfg)
+	assert.NoError(t, checkTTLs(typ, baseTagNames()))
+	assert.Equal(t, 24*time.Hour, fieldTTL(typ.Field(0)))
+	assert.Equal(t, 7*24*time.Hour+12*time.Hour, fieldTTL(typ.Field(1)))
+	assert.Equal(t, time.Duration(0), fieldTTL(typ.Field(2)))
+}
@@ -0,0 +1,81 @@
+package configuration
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// UnsetReport is returned from InitValues with WithUnsetReport option: it lists all fields which are not set
+// by any provider together with keys which providers look for (see KeyDescriber), grouped by providers:
+//
+//	configurator: 3 fields are not set, provide them via:
+//		envProvider: DB_HOST, DB_PASSWORD
+//		flagProvider: -db-host
+//		no sources: Name
+type UnsetReport struct {
+	Fields  []*FieldError       // errors of fields which are not set (ErrNotSet or ErrRequired)
+	Keys    map[string][]string // name of the provider -> keys which it looks for, e.g. `envProvider` -> [`DB_HOST`]
+	order   []string            // names of providers in order of the chain
+	orphans []string            // paths to fields which no provider looks for
+}
+
+// Unwrap returns errors of all fields (for errors.Is and errors.As of Go 1.20+)
+func (r *UnsetReport) Unwrap() []error {
+	errs := make([]error, len(r.Fields))
+	for i, fe := range r.Fields {
+		errs[i] = fe
+	}
+	return errs
+}
+
+// Is reports whether any of the errors of fields matches the target (errors.Is of older Go versions ignores Unwrap() []error)
+func (r *UnsetReport) Is(target error) bool {
+	return anyIs(r.Unwrap(), target)
+}
+
+// As finds the first of the errors of fields which matches the target (see Is)
+func (r *UnsetReport) As(target interface{}) bool {
+	return anyAs(r.Unwrap(), target)
+}
+
+func (r *UnsetReport) Error() string {
+	var lines []string
+	for _, name := range r.order {
+		lines = append(lines, fmt.Sprintf("\t%s: %s", name, strings.Join(r.Keys[name], ", ")))
+	}
+	if len(r.orphans) > 0 {
+		lines = append(lines, "\t"+msg(MsgUnsetNoSources, strings.Join(r.orphans, ", ")))
+	}
+	return msg(MsgUnsetReport, len(r.Fields), strings.Join(lines, "\n"))
+}
+
+// add records the unset field and keys which providers look for
+func (r *UnsetReport) add(fieldErr *FieldError, providers []Provider, field reflect.StructField, path []string) {
+	r.Fields = append(r.Fields, fieldErr)
+	described := false
+	for _, provider := range providers {
+		kd, ok := provider.(KeyDescriber)
+		if !ok {
+			continue
+		}
+		key := kd.DescribeKey(field, path...)
+		if key == "" {
+			continue
+		}
+
+		described = true
+		name := providerName(provider)
+		if _, ok := r.Keys[name]; !ok {
+			r.order = append(r.order, name)
+		}
+		r.Keys[name] = append(r.Keys[name], key)
+	}
+	if !described {
+		r.orphans = append(r.orphans, fieldErr.Path)
+	}
+}
+
+func newUnsetReport() *UnsetReport {
+	return &UnsetReport{Keys: map[string][]string{}}
+}
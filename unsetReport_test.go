@@ -0,0 +1,69 @@
+package configuration
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithUnsetReport(t *testing.T) {
+	cfg := struct {
+		Name     string `yaml:"name"`
+		Database struct {
+			Host     string `yaml:"host" env:"DB_HOST" flag:"db-host||database host"`
+			Password string `yaml:"password" env:"DB_PASSWORD"`
+			Port     int    `yaml:"port" env:"DB_PORT" default:"5432"`
+		} `yaml:"database"`
+		Token string `env:"TOKEN" required:"true"`
+	}{}
+
+	c, err := New(&cfg,
+		WithProviders(
+			NewEnvProvider().WithPrefix("APP").WithEnv(map[string]string{"APP_DB_PORT": "5433"}),
+			NewFlagProvider(&cfg),
+			NewDefaultProvider(),
+		),
+		WithUnsetReport(),
+	)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+
+	var report *UnsetReport
+	if !assert.True(t, errors.As(err, &report), "unexpected error: %v", err) {
+		return
+	}
+	assert.Len(t, report.Fields, 4)
+	assert.True(t, errors.Is(err, ErrNotSet))
+	assert.True(t, errors.Is(err, ErrRequired))
+	assert.Equal(t, []string{"APP_DB_HOST", "APP_DB_PASSWORD", "APP_TOKEN"}, report.Keys["envProvider"])
+	assert.Equal(t, `configurator: 4 fields are not set, provide them via:
+	envProvider: APP_DB_HOST, APP_DB_PASSWORD, APP_TOKEN
+	flagProvider: -db-host
+	no sources: name`, err.Error())
+	assert.Equal(t, 5433, cfg.Database.Port, "other fields are set")
+}
+
+func TestWithUnsetReport_File(t *testing.T) {
+	cfg := struct {
+		Name    string `yaml:"name"`
+		Missing string `yaml:"missing"`
+		Other   string `yaml:"other"`
+	}{}
+
+	c, err := New(&cfg, WithProviders(NewFileProvider("./testdata/input.yml")), WithUnsetReport())
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+	assert.EqualError(t, err, "configurator: 2 fields are not set, provide them via:\n\tfileProvider: missing in ./testdata/input.yml, other in ./testdata/input.yml")
+	assert.Equal(t, "test_name_yml", cfg.Name)
+
+	c, err = New(&cfg, WithProviders(NewDefaultProvider()), WithUnsetReport())
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.Error(t, c.InitValues())
+}
@@ -0,0 +1,165 @@
+package configuration
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validator checks the value which is set into the field, param is the part of the rule after `=`:
+// `validate:"port"` -> (value, ""), `validate:"prefix=/api"` -> (value, "/api")
+type Validator func(value interface{}, param string) error
+
+// validate checks the value against rules of `validate` tag: `validate:"min=1,max=65535"`.
+// Built-in rules are `min`, `max` (values of numbers, lengths of strings, slices and maps) and
+// `oneof` (`oneof=debug|info|warn`), other rules are registered with WithValidator.
+func (c configurator) validate(field reflect.StructField, v reflect.Value) error {
+	tag := getValidateTag(field)
+	if tag == "" {
+		return nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		rule = strings.TrimSpace(rule)
+		name, param := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, param = rule[:i], rule[i+1:]
+		}
+
+		var err error
+		fn, ok := c.opts.validators[name]
+		switch {
+		case ok:
+			err = fn(v.Interface(), param)
+		case name == "min":
+			err = checkLimit(v, param, true)
+		case name == "max":
+			err = checkLimit(v, param, false)
+		case name == "oneof":
+			err = checkOneOf(v, param)
+		default:
+			err = errors.New(msg(MsgUnknownValidator, name))
+		}
+		if err != nil {
+			return ruleError{rule: rule, value: logValue(field, v.Interface()), err: err}
+		}
+	}
+	return nil
+}
+
+// ruleError is the violation of the rule of `validate` tag: `min=1: 0 is less than 1`
+type ruleError struct {
+	rule  string
+	value interface{} // masked if the field is secret
+	err   error
+}
+
+func (e ruleError) Error() string { return fmt.Sprintf("%s: %v", e.rule, e.err) }
+func (e ruleError) Unwrap() error { return e.err }
+
+// checkLimit compares the value (or the length of strings, slices and maps) with the param of `min` or `max` rule
+func checkLimit(v reflect.Value, param string, isMin bool) error {
+	var (
+		cmp        int // -1 if the value is less than the limit, 1 if it's greater
+		shown      interface{}
+		isLen      bool
+		errMessage = MsgValidateMax
+	)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		limit, err := strconv.Atoi(param)
+		if err != nil {
+			return err
+		}
+		cmp, shown, isLen = compareInts(int64(v.Len()), int64(limit)), v.Len(), true
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var limit int64
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := parseDuration(param)
+			if err != nil {
+				return err
+			}
+			limit = int64(d)
+		} else {
+			i, err := parseInt(param, 64)
+			if err != nil {
+				return err
+			}
+			limit = i
+		}
+		cmp, shown = compareInts(v.Int(), limit), v.Interface()
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		limit, err := parseUint(param, 64)
+		if err != nil {
+			return err
+		}
+		switch {
+		case v.Uint() < limit:
+			cmp = -1
+		case v.Uint() > limit:
+			cmp = 1
+		}
+		shown = v.Interface()
+
+	case reflect.Float32, reflect.Float64:
+		limit, err := parseFloat(param, 64)
+		if err != nil {
+			return err
+		}
+		switch {
+		case v.Float() < limit:
+			cmp = -1
+		case v.Float() > limit:
+			cmp = 1
+		}
+		shown = v.Interface()
+
+	default:
+		return fmt.Errorf("not supported for %v", v.Type())
+	}
+
+	if (isMin && cmp >= 0) || (!isMin && cmp <= 0) {
+		return nil
+	}
+	switch {
+	case isMin && isLen:
+		errMessage = MsgValidateMinLen
+	case isMin:
+		errMessage = MsgValidateMin
+	case isLen:
+		errMessage = MsgValidateMaxLen
+	}
+	return errors.New(msg(errMessage, shown, param))
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// checkOneOf checks that the value is one of the `|`-separated values of the param
+func checkOneOf(v reflect.Value, param string) error {
+	val := fmt.Sprint(v.Interface())
+	for _, allowed := range strings.Split(param, "|") {
+		if val == strings.TrimSpace(allowed) {
+			return nil
+		}
+	}
+	return errors.New(msg(MsgValidateOneOf, val, strings.Replace(param, "|", ", ", -1)))
+}
@@ -0,0 +1,176 @@
+package configuration
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		cfg      interface{}
+		expected string
+	}{
+		"min": {
+			cfg: &struct {
+				Port int `default:"0" validate:"min=1,max=65535"`
+			}{},
+			expected: "min=1: 0 is less than 1",
+		},
+		"max": {
+			cfg: &struct {
+				Port uint16 `default:"65535" validate:"max=8080"`
+			}{},
+			expected: "max=8080: 65535 is greater than 8080",
+		},
+		"float": {
+			cfg: &struct {
+				Ratio float64 `default:"1.5" validate:"max=1"`
+			}{},
+			expected: "max=1: 1.5 is greater than 1",
+		},
+		"duration": {
+			cfg: &struct {
+				Timeout time.Duration `default:"1s" validate:"min=5s"`
+			}{},
+			expected: "min=5s: 1s is less than 5s",
+		},
+		"length": {
+			cfg: &struct {
+				Name string `default:"ab" validate:"min=3"`
+			}{},
+			expected: "min=3: length 2 is less than 3",
+		},
+		"slice length": {
+			cfg: &struct {
+				Hosts []string `default:"a;b;c" validate:"max=2"`
+			}{},
+			expected: "max=2: length 3 is greater than 2",
+		},
+		"oneof": {
+			cfg: &struct {
+				Level string `default:"trace" validate:"oneof=debug|info|warn"`
+			}{},
+			expected: "oneof=debug|info|warn: trace is not one of [debug, info, warn]",
+		},
+		"unknown": {
+			cfg: &struct {
+				Level string `default:"info" validate:"level"`
+			}{},
+			expected: "level: unknown validator [level]",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			c, err := New(test.cfg, WithProviders(NewDefaultProvider()))
+			if err != nil {
+				t.Fatal("unexpected err: ", err)
+			}
+			err = c.InitValues()
+			assert.True(t, errors.Is(err, ErrInvalid), "unexpected error: %v", err)
+			assert.Contains(t, err.Error(), test.expected)
+		})
+	}
+}
+
+func TestValidate_Valid(t *testing.T) {
+	cfg := struct {
+		Port    int           `default:"8080" validate:"min=1,max=65535"`
+		Timeout time.Duration `default:"10s" validate:"min=5s,max=1m"`
+		Level   string        `default:"info" validate:"oneof=debug|info|warn"`
+		Name    *string       `default:"app" validate:"min=1"`
+	}{}
+
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, 8080, cfg.Port)
+	assert.Equal(t, "info", cfg.Level)
+}
+
+func TestWithValidator(t *testing.T) {
+	cfg := struct {
+		Path string `env:"VALIDATOR_PATH" default:"api" validate:"prefix=/"`
+	}{}
+
+	prefix := func(value interface{}, param string) error {
+		if !strings.HasPrefix(fmt.Sprint(value), param) {
+			return fmt.Errorf("must start with %q", param)
+		}
+		return nil
+	}
+	c, err := New(&cfg,
+		WithProviders(NewEnvProvider().WithEnv(map[string]string{"VALIDATOR_PATH": "/v1"}), NewDefaultProvider()),
+		WithValidator("prefix", prefix),
+	)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	assert.Equal(t, "/v1", cfg.Path)
+
+	err = c.Set("Path", "v2")
+	assert.True(t, errors.Is(err, ErrInvalid))
+	assert.Contains(t, err.Error(), `prefix=/: must start with "/"`)
+	assert.Equal(t, "/v1", cfg.Path)
+}
+
+func TestRequired(t *testing.T) {
+	cfg := struct {
+		Host     string `env:"REQUIRED_HOST" required:"true"`
+		Token    string `cfg:"env=REQUIRED_TOKEN,required"`
+		Optional string `env:"REQUIRED_OPTIONAL"`
+		Port     int    `env:"REQUIRED_PORT" default:"80"`
+	}{}
+
+	c, err := New(&cfg,
+		WithProviders(NewEnvProvider().WithEnv(map[string]string{}), NewDefaultProvider()),
+		AllowUnset(),
+	)
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+	assert.True(t, errors.Is(err, ErrRequired))
+	assert.True(t, errors.Is(err, ErrNotSet))
+
+	var errs loadErrors
+	if assert.True(t, errors.As(err, &errs)) {
+		assert.Len(t, errs, 2, "the optional field must be left empty")
+		assert.Equal(t, "configurator: required field [Host] is not set", errs[0].Error())
+		assert.Equal(t, "configurator: required field [Token] is not set", errs[1].Error())
+	}
+	assert.Equal(t, "", cfg.Optional)
+	assert.Equal(t, 80, cfg.Port)
+}
+
+func TestAllErrorsReported(t *testing.T) {
+	cfg := struct {
+		Host  string `required:"true"`
+		Port  int    `default:"0" validate:"min=1"`
+		Level string `default:"trace" validate:"oneof=info|warn"`
+		Name  string
+	}{}
+
+	c, err := New(&cfg, WithProviders(NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	err = c.InitValues()
+
+	var errs loadErrors
+	if assert.True(t, errors.As(err, &errs)) {
+		assert.Len(t, errs, 4)
+	}
+	assert.True(t, errors.Is(err, ErrRequired))
+	assert.True(t, errors.Is(err, ErrInvalid))
+	assert.Contains(t, err.Error(), "[Name]")
+}
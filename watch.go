@@ -0,0 +1,239 @@
+package configuration
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"time"
+)
+
+// WatchableProvider is an optional interface for providers backed by files (e.g. file provider):
+// Watch polls the files and replaces the provider with the result of Reopen when any of them changes
+type WatchableProvider interface {
+	Provider
+	WatchedFiles() []string
+	Reopen() (Provider, error)
+}
+
+type watchState struct {
+	onChange []func(oldCfg, newCfg interface{})
+	expires  map[string]time.Time // path to the field tagged `ttl` -> when its value expires
+
+	// the watcher started by Start
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error // returned by Watch when the watcher exited
+}
+
+// fileState is what Watch compares to detect changes of files
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// OnChange registers the callback which is called after Watch or Reload changed the configuration.
+// Callbacks get deep copies of the previous and the new configuration objects (pointers of the same type as cfgPtr of New)
+// and are called after the configurator is unlocked, so they can call its methods.
+func (c configurator) OnChange(fn func(oldCfg, newCfg interface{})) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.watch.onChange = append(c.watch.onChange, fn)
+}
+
+// Watch checks files of providers implementing WatchableProvider (see WithWatchInterval) and reloads
+// the configuration when any of them changes. Blocks until the context is done and returns ctx.Err():
+//
+//	go c.Watch(ctx)
+//
+// New values are filled up in a copy of the configuration object which replaces the values of the object
+// only if all fields are set and the health check (see SetHealthCheck) passes, otherwise the previous values
+// are kept and the error is logged. Fields which are set by other providers (flags, defaults) keep their values.
+// The first check reloads the configuration to pick up changes made since InitValues.
+// Between changes of files, only fields tagged with `ttl:"5m"` whose values are expired are set again by providers.
+func (c configurator) Watch(ctx context.Context) error {
+	ticker := time.NewTicker(c.opts.watchInterval)
+	defer ticker.Stop()
+
+	var states map[string]fileState // the first check reloads to pick up changes made before Watch is called
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		current := c.watchedFiles()
+		if reflect.DeepEqual(states, current) {
+			c.refreshExpired(ctx)
+			continue
+		}
+		states = current
+
+		c.opts.logf("configurator: watched files are changed, reloading")
+		old, err := c.reloadWatched(ctx)
+		if err != nil {
+			c.opts.errorf("configurator: watch: %v", err)
+			continue
+		}
+		c.notify(old)
+	}
+}
+
+// Start runs Watch in the background until the context is done or Stop is called (see Done).
+// It returns an error if the watcher is already running; it can be started again after it's stopped.
+func (c configurator) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.watch.done != nil {
+		select {
+		case <-c.watch.done:
+		default:
+			return errors.New("configurator: watcher is already started")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	c.watch.cancel, c.watch.done, c.watch.err = cancel, done, nil
+	go func() {
+		defer close(done)
+		defer cancel()
+
+		err := c.Watch(ctx)
+		c.mu.Lock()
+		c.watch.err = err
+		c.mu.Unlock()
+	}()
+	return nil
+}
+
+// Stop stops the watcher started by Start and waits until its goroutine exits, so the ticker and
+// reloads in progress are finished when it returns. It's safe to call Stop concurrently and more than once.
+func (c configurator) Stop() {
+	c.mu.RLock()
+	cancel, done := c.watch.cancel, c.watch.done
+	c.mu.RUnlock()
+
+	if done == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// Done returns the channel which is closed when the watcher started by Start exits (or closed one if it isn't started),
+// WatchErr returns the reason then: context.Canceled after Stop or the error of the context passed to Start.
+func (c configurator) Done() <-chan struct{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.watch.done == nil {
+		done := make(chan struct{})
+		close(done)
+		return done
+	}
+	return c.watch.done
+}
+
+// WatchErr returns the error of the watcher which exited (see Done), nil if it's running or isn't started
+func (c configurator) WatchErr() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.watch.err
+}
+
+func (c configurator) watchedFiles() map[string]fileState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	states := map[string]fileState{}
+	for _, p := range c.providers {
+		wp, ok := p.(WatchableProvider)
+		if !ok {
+			continue
+		}
+		for _, name := range wp.WatchedFiles() {
+			var state fileState
+			if info, err := os.Stat(name); err == nil {
+				state = fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+			}
+			states[name] = state
+		}
+	}
+	return states
+}
+
+// reloadWatched reopens watchable providers and fills up a copy of the configuration object,
+// returns the copy of the previous configuration for OnChange callbacks
+func (c configurator) reloadWatched(ctx context.Context) (reflect.Value, error) {
+	defer c.lock()()
+	defer c.beginChange()()
+
+	providers := make([]Provider, len(c.providers))
+	copy(providers, c.providers)
+	for i, p := range providers {
+		wp, ok := p.(WatchableProvider)
+		if !ok {
+			continue
+		}
+		reopened, err := wp.Reopen()
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		providers[i] = c.opts.bindProvider(reopened)
+	}
+
+	prev := c.snapshot()
+	fresh := c
+	fresh.providers = providers
+	fresh.config = deepCopy(prev.config).Addr().Interface()
+	fresh.sources = map[string]string{}
+	if err := fresh.initValues(ctx); err != nil {
+		return reflect.Value{}, err
+	}
+
+	c.restore(snapshot{config: reflect.ValueOf(fresh.config).Elem(), sources: fresh.sources})
+	if c.history.healthCheck != nil {
+		if err := c.history.healthCheck(); err != nil {
+			c.restore(prev)
+			return reflect.Value{}, err
+		}
+	}
+
+	copy(c.providers, providers)
+	c.history.push(c.snapshot())
+	return prev.config, nil
+}
+
+// copyForCallbacks returns the copy of the configuration object if there are OnChange callbacks,
+// must be called under the lock
+func (c configurator) copyForCallbacks() reflect.Value {
+	if len(c.watch.onChange) == 0 {
+		return reflect.Value{}
+	}
+	return deepCopy(reflect.ValueOf(c.config).Elem())
+}
+
+// notify calls OnChange callbacks if the configuration differs from the old one
+func (c configurator) notify(old reflect.Value) {
+	c.mu.RLock()
+	if !old.IsValid() || len(c.watch.onChange) == 0 {
+		c.mu.RUnlock()
+		return
+	}
+	callbacks := append([]func(oldCfg, newCfg interface{}){}, c.watch.onChange...)
+	current := deepCopy(reflect.ValueOf(c.config).Elem())
+	c.mu.RUnlock()
+
+	if reflect.DeepEqual(old.Interface(), current.Interface()) {
+		return
+	}
+	for _, fn := range callbacks {
+		fn(deepCopy(old).Addr().Interface(), deepCopy(current).Addr().Interface())
+	}
+}
@@ -0,0 +1,186 @@
+package configuration
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigurator_Watch(t *testing.T) {
+	type config struct {
+		Name     string `default:"app"`
+		LogLevel string `yaml:"log_level" default:"info"`
+		Port     int    `yaml:"port"`
+	}
+
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "config.yml")
+	writeFile := func(content string, modTime time.Time) {
+		if err := ioutil.WriteFile(fileName, []byte(content), 0600); err != nil {
+			t.Fatal("unexpected err: ", err)
+		}
+		// the resolution of modification time may be too coarse for the test
+		if err := os.Chtimes(fileName, modTime, modTime); err != nil {
+			t.Fatal("unexpected err: ", err)
+		}
+	}
+	start := time.Now()
+	writeFile("port: 80\n", start)
+
+	var (
+		cfg       config
+		healthErr = errors.New("unhealthy")
+		changes   = make(chan [2]config, 10)
+	)
+	c, err := New(&cfg, WithProviders(NewFileProvider(fileName), NewDefaultProvider()), WithWatchInterval(5*time.Millisecond))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	c.OnChange(func(oldCfg, newCfg interface{}) {
+		changes <- [2]config{*oldCfg.(*config), *newCfg.(*config)}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- c.Watch(ctx) }()
+
+	waitChange := func(timeout time.Duration) ([2]config, bool) {
+		select {
+		case change := <-changes:
+			return change, true
+		case <-time.After(timeout):
+			return [2]config{}, false
+		}
+	}
+
+	// the file is changed
+	writeFile("port: 8080\nlog_level: debug\n", start.Add(time.Second))
+	change, ok := waitChange(time.Second)
+	if assert.True(t, ok, "callback is not called") {
+		assert.Equal(t, config{Name: "app", LogLevel: "info", Port: 80}, change[0])
+		assert.Equal(t, config{Name: "app", LogLevel: "debug", Port: 8080}, change[1])
+	}
+	port, _ := c.Get("port")
+	assert.Equal(t, 8080, port)
+	assert.Equal(t, "fileProvider", c.Sources()["log_level"])
+
+	// broken file: previous values are kept
+	writeFile("port: [", start.Add(2*time.Second))
+	_, ok = waitChange(100 * time.Millisecond)
+	assert.False(t, ok)
+	port, _ = c.Get("port")
+	assert.Equal(t, 8080, port)
+
+	// health check fails: previous values are kept
+	c.SetHealthCheck(func() error { return healthErr })
+	writeFile("port: 9090\n", start.Add(3*time.Second))
+	_, ok = waitChange(100 * time.Millisecond)
+	assert.False(t, ok)
+	port, _ = c.Get("port")
+	assert.Equal(t, 8080, port)
+
+	// the same file is read again once it's healthy
+	healthErr = nil
+	writeFile("port: 9090\n", start.Add(4*time.Second))
+	change, ok = waitChange(time.Second)
+	if assert.True(t, ok, "callback is not called") {
+		assert.Equal(t, 9090, change[1].Port)
+		assert.Equal(t, "info", change[1].LogLevel, "default is applied again")
+	}
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
+
+func TestConfigurator_OnChangeReload(t *testing.T) {
+	type config struct {
+		Port int `default:"80"`
+	}
+	var (
+		cfg       config
+		overrides = NewOverrideProvider()
+		calls     int
+	)
+
+	c, err := New(&cfg, WithProviders(overrides, NewDefaultProvider()))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+	c.OnChange(func(oldCfg, newCfg interface{}) {
+		calls++
+		assert.Equal(t, 80, oldCfg.(*config).Port)
+		assert.Equal(t, 8080, newCfg.(*config).Port)
+		_, _ = c.Get("Port") // must not deadlock
+	})
+
+	assert.NoError(t, c.Reload())
+	assert.Equal(t, 0, calls, "nothing is changed")
+
+	_ = overrides.Set("Port", "8080")
+	assert.NoError(t, c.Reload())
+	assert.Equal(t, 1, calls)
+}
+
+func TestConfigurator_StartStop(t *testing.T) {
+	cfg := struct {
+		Port int `yaml:"port" default:"80"`
+	}{}
+	c, err := New(&cfg, WithProviders(NewFileProvider("./testdata/input.yml"), NewDefaultProvider()), WithWatchInterval(time.Millisecond))
+	if err != nil {
+		t.Fatal("unexpected err: ", err)
+	}
+	assert.NoError(t, c.InitValues())
+
+	select {
+	case <-c.Done():
+	default:
+		t.Fatal("done channel of the watcher which isn't started must be closed")
+	}
+	c.Stop() // no-op
+
+	assert.NoError(t, c.Start(context.Background()))
+	assert.EqualError(t, c.Start(context.Background()), "configurator: watcher is already started")
+	select {
+	case <-c.Done():
+		t.Fatal("the watcher is stopped")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.NoError(t, c.WatchErr())
+
+	stopped := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		go func() {
+			c.Stop()
+			stopped <- struct{}{}
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		<-stopped
+	}
+	<-c.Done()
+	assert.Equal(t, context.Canceled, c.WatchErr())
+
+	// restart with the context which is done
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, c.Start(ctx))
+	select {
+	case <-c.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the watcher isn't stopped by the context")
+	}
+	assert.Equal(t, context.DeadlineExceeded, c.WatchErr())
+}